@@ -1,6 +1,7 @@
 package main
 
 import (
+	"context"
 	"fmt"
 	"log"
 	"os"
@@ -8,6 +9,7 @@ import (
 	"synapse/internal/handlers"
 	"synapse/internal/repository"
 	"synapse/internal/services"
+	"synapse/internal/storage"
 
 	"github.com/gin-contrib/cors"
 	"github.com/gin-gonic/gin"
@@ -59,17 +61,76 @@ func main() {
 		}
 	}
 
+	blobStore, err := storage.NewFromEnv()
+	if err != nil {
+		log.Fatalf("Failed to initialize blob store: %v", err)
+	}
+
 	// Initialize services
-	aiService := services.NewAIService()
+	prompts, err := services.NewPromptStore("")
+	if err != nil {
+		log.Fatalf("Failed to load prompt templates: %v", err)
+	}
+	llmCache := services.NewLLMCache(repository.NewLLMCacheRepository(db.Pool))
+	usageTracker := services.NewUsageTracker(repository.NewUsageRepository(db.Pool))
+	aiService := services.NewAIService(llmCache, usageTracker, prompts)
 	itemRepo := repository.NewItemRepository(db.Pool)
 	relationRepo := repository.NewRelationRepository(db.Pool)
-	itemService := services.NewItemService(itemRepo, aiService)
-	searchService := services.NewSearchService(aiService, itemRepo)
-	relationService := services.NewRelationService(itemRepo, relationRepo, aiService)
+	categoryService := services.NewCategoryService(repository.NewCategoryRepository(db.Pool), itemRepo, aiService)
+	tagService := services.NewTagService(repository.NewTagRepository(db.Pool))
+	collectionService := services.NewCollectionService(repository.NewCollectionRepository(db.Pool), itemRepo, categoryService, tagService)
+	ruleService := services.NewRuleService(repository.NewRuleRepository(db.Pool), itemRepo, categoryService, tagService, collectionService)
+	annotationRepo := repository.NewAnnotationRepository(db.Pool)
+	chunkRepo := repository.NewChunkRepository(db.Pool)
+	indexer := services.NewIndexer(itemRepo, annotationRepo, chunkRepo, aiService)
+	fileService := services.NewFileService(blobStore, repository.NewFileRepository(db.Pool))
+	imageService := services.NewImageService(itemRepo, fileService)
+	ocrService := services.NewOCRService(aiService, repository.NewOCRRepository(db.Pool))
+	imageEmbeddings := services.NewImageEmbeddingService()
+	itemService := services.NewItemService(itemRepo, aiService, categoryService, tagService, ruleService, collectionService, chunkRepo, indexer, fileService, imageService, ocrService, imageEmbeddings)
+	uploadService := services.NewUploadService(itemService, fileService)
+	searchService := services.NewSearchService(aiService, itemRepo, chunkRepo, categoryService, tagService, collectionService, imageEmbeddings)
+	relationService := services.NewRelationService(itemRepo, relationRepo, aiService, collectionService)
+	trashService := services.NewTrashService(itemRepo, fileService)
+	revisionService := services.NewRevisionService(repository.NewRevisionRepository(db.Pool), itemRepo, indexer)
+	annotationService := services.NewAnnotationService(annotationRepo, itemRepo, indexer)
+
+	// Purge items that have been in the trash longer than TRASH_RETENTION_DAYS
+	go trashService.Run(context.Background())
+
+	// Move screenshots saved as data URLs out of the items table, replace images from
+	// retired hosts, then make thumbnails for images that don't have them yet
+	go func() {
+		if n, err := fileService.MigrateInlineImages(context.Background()); err != nil {
+			log.Printf("Warning: Failed to move inline images to the blob store: %v", err)
+		} else if n > 0 {
+			log.Printf("Moved %d inline images to the blob store", n)
+		}
+		if n, err := itemService.ReplaceRetiredImages(context.Background()); err != nil {
+			log.Printf("Warning: Failed to replace retired images: %v", err)
+		} else if n > 0 {
+			log.Printf("Replaced %d retired images", n)
+		}
+		if n, err := imageService.Backfill(context.Background()); err != nil {
+			log.Printf("Warning: Failed to process item images: %v", err)
+		} else if n > 0 {
+			log.Printf("Processed %d item images", n)
+		}
+	}()
 
 	// Initialize handlers
 	itemHandler := handlers.NewItemHandler(itemService, relationService)
+	uploadHandler := handlers.NewUploadHandler(uploadService)
+	fileHandler := handlers.NewFileHandler(fileService)
 	searchHandler := handlers.NewSearchHandler(searchService)
+	categoryHandler := handlers.NewCategoryHandler(categoryService)
+	tagHandler := handlers.NewTagHandler(tagService)
+	ruleHandler := handlers.NewRuleHandler(ruleService)
+	collectionHandler := handlers.NewCollectionHandler(collectionService)
+	trashHandler := handlers.NewTrashHandler(trashService)
+	revisionHandler := handlers.NewRevisionHandler(revisionService)
+	annotationHandler := handlers.NewAnnotationHandler(annotationService)
+	aiHandler := handlers.NewAIHandler(aiService, llmCache, usageTracker, prompts)
 
 	// Setup router
 	r := gin.Default()
@@ -77,7 +138,7 @@ func main() {
 	// CORS configuration
 	config := cors.DefaultConfig()
 	config.AllowAllOrigins = true
-	config.AllowMethods = []string{"GET", "POST", "PUT", "DELETE", "OPTIONS"}
+	config.AllowMethods = []string{"GET", "POST", "PUT", "PATCH", "DELETE", "OPTIONS"}
 	config.AllowHeaders = []string{"Origin", "Content-Type", "Accept", "Authorization"}
 	r.Use(cors.New(config))
 
@@ -93,13 +154,78 @@ func main() {
 		api.POST("/items", itemHandler.CreateItem)
 		api.GET("/items", itemHandler.GetAllItems)
 		api.GET("/items/:id", itemHandler.GetItem)
+		api.PATCH("/items/:id", itemHandler.UpdateItem)
 		api.DELETE("/items/:id", itemHandler.DeleteItem)
 		api.GET("/items/:id/related", itemHandler.GetRelatedItems)
 		api.POST("/items/:id/refresh-image", itemHandler.RefreshImage)
 		api.POST("/items/:id/refresh-summary", itemHandler.RefreshSummary)
+		api.POST("/items/:id/re-enrich", itemHandler.ReEnrichItem)
+		api.GET("/items/:id/revisions", revisionHandler.GetRevisions)
+		api.POST("/items/:id/revisions/:revisionId/revert", revisionHandler.RevertItem)
+		api.GET("/items/:id/annotations", annotationHandler.GetItemAnnotations)
+		api.POST("/items/:id/annotations", annotationHandler.CreateAnnotation)
+
+		// Uploads
+		api.POST("/uploads", uploadHandler.Upload)
+
+		// Files
+		api.GET("/files/:hash", fileHandler.GetFile)
+		api.HEAD("/files/:hash", fileHandler.GetFile)
+
+		// Annotations
+		api.GET("/annotations", annotationHandler.GetRecentAnnotations)
+		api.PUT("/annotations/:id", annotationHandler.UpdateAnnotation)
+		api.DELETE("/annotations/:id", annotationHandler.DeleteAnnotation)
+
+		// Trash
+		api.GET("/trash", trashHandler.GetTrash)
+		api.DELETE("/trash", trashHandler.EmptyTrash)
+		api.POST("/trash/:id/restore", trashHandler.RestoreItem)
+		api.DELETE("/trash/:id", trashHandler.PurgeItem)
 
 		// Search
 		api.GET("/search", searchHandler.Search)
+
+		// Categories
+		api.GET("/categories", categoryHandler.GetCategories)
+		api.POST("/categories", categoryHandler.CreateCategory)
+		api.GET("/categories/:id", categoryHandler.GetCategory)
+		api.PUT("/categories/:id", categoryHandler.UpdateCategory)
+		api.DELETE("/categories/:id", categoryHandler.DeleteCategory)
+		api.POST("/categories/:id/merge", categoryHandler.MergeCategory)
+
+		// Tags
+		api.GET("/tags", tagHandler.GetTags)
+		api.PUT("/tags/:name", tagHandler.RenameTag)
+		api.POST("/tags/:name/merge", tagHandler.MergeTag)
+		api.PUT("/tags/:name/synonyms", tagHandler.SetTagSynonyms)
+
+		// Collections
+		api.GET("/collections", collectionHandler.GetCollections)
+		api.POST("/collections", collectionHandler.CreateCollection)
+		api.GET("/collections/:id", collectionHandler.GetCollection)
+		api.PUT("/collections/:id", collectionHandler.UpdateCollection)
+		api.DELETE("/collections/:id", collectionHandler.DeleteCollection)
+		api.GET("/collections/:id/items", collectionHandler.GetCollectionItems)
+		api.POST("/collections/:id/items", collectionHandler.AddCollectionItems)
+		api.PUT("/collections/:id/items/order", collectionHandler.ReorderCollectionItems)
+		api.DELETE("/collections/:id/items/:itemId", collectionHandler.RemoveCollectionItem)
+
+		// Rules
+		api.GET("/rules", ruleHandler.GetRules)
+		api.POST("/rules", ruleHandler.CreateRule)
+		api.POST("/rules/dry-run", ruleHandler.DryRunRule)
+		api.GET("/rules/:id", ruleHandler.GetRule)
+		api.PUT("/rules/:id", ruleHandler.UpdateRule)
+		api.DELETE("/rules/:id", ruleHandler.DeleteRule)
+
+		// AI
+		api.GET("/ai/providers", aiHandler.GetProviders)
+		api.GET("/ai/cache", aiHandler.GetCacheStats)
+		api.DELETE("/ai/cache", aiHandler.ClearCache)
+		api.GET("/usage", aiHandler.GetUsage)
+		api.GET("/prompts", aiHandler.GetPrompts)
+		api.POST("/prompts/reload", aiHandler.ReloadPrompts)
 	}
 
 	port := os.Getenv("PORT")
@@ -27,11 +27,13 @@ func InitChroma() error {
 		Client:  &http.Client{},
 	}
 
-	// Create collection if it doesn't exist
-	collectionName := "synapse_items"
-	if err := Chroma.CreateCollection(collectionName); err != nil {
-		// Collection might already exist, that's okay
-		fmt.Printf("Note: Collection creation: %v\n", err)
+	// Create collections if they don't exist: items, chunks of long items, and CLIP
+	// embeddings of item images
+	for _, collectionName := range []string{"synapse_items", "synapse_chunks", "synapse_images"} {
+		if err := Chroma.CreateCollection(collectionName); err != nil {
+			// Collection might already exist, that's okay
+			fmt.Printf("Note: Collection creation: %v\n", err)
+		}
 	}
 
 	return nil
@@ -99,6 +101,77 @@ func (c *ChromaClient) AddEmbedding(collectionName, id string, embedding []float
 	return nil
 }
 
+// UpsertEmbedding adds an embedding or replaces the one stored under id
+func (c *ChromaClient) UpsertEmbedding(collectionName, id string, embedding []float32, metadata map[string]interface{}) error {
+	url := fmt.Sprintf("%s/api/v1/collections/%s/upsert", c.BaseURL, collectionName)
+
+	payload := map[string]interface{}{
+		"ids":        []string{id},
+		"embeddings": [][]float32{embedding},
+		"metadatas":  []map[string]interface{}{metadata},
+	}
+
+	jsonData, _ := json.Marshal(payload)
+	req, _ := http.NewRequest("POST", url, bytes.NewBuffer(jsonData))
+	req.Header.Set("Content-Type", "application/json")
+
+	resp, err := c.Client.Do(req)
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode == 404 || resp.StatusCode == 501 {
+		return fmt.Errorf("ChromaDB v1 API deprecated - semantic search disabled")
+	}
+
+	if resp.StatusCode != 200 {
+		body, _ := io.ReadAll(resp.Body)
+		return fmt.Errorf("failed to upsert embedding: %s", string(body))
+	}
+
+	return nil
+}
+
+// DeleteEmbeddings removes embeddings by id
+func (c *ChromaClient) DeleteEmbeddings(collectionName string, ids []string) error {
+	if len(ids) == 0 {
+		return nil
+	}
+	return c.delete(collectionName, map[string]interface{}{"ids": ids})
+}
+
+// DeleteWhere removes the embeddings whose metadata matches a Chroma where filter,
+// e.g. {"item_id": {"$in": ids}}
+func (c *ChromaClient) DeleteWhere(collectionName string, where map[string]interface{}) error {
+	return c.delete(collectionName, map[string]interface{}{"where": where})
+}
+
+func (c *ChromaClient) delete(collectionName string, payload map[string]interface{}) error {
+	url := fmt.Sprintf("%s/api/v1/collections/%s/delete", c.BaseURL, collectionName)
+
+	jsonData, _ := json.Marshal(payload)
+	req, _ := http.NewRequest("POST", url, bytes.NewBuffer(jsonData))
+	req.Header.Set("Content-Type", "application/json")
+
+	resp, err := c.Client.Do(req)
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode == 404 || resp.StatusCode == 501 {
+		return fmt.Errorf("ChromaDB v1 API deprecated - semantic search disabled")
+	}
+
+	if resp.StatusCode != 200 {
+		body, _ := io.ReadAll(resp.Body)
+		return fmt.Errorf("failed to delete embeddings: %s", string(body))
+	}
+
+	return nil
+}
+
 func (c *ChromaClient) Query(collectionName string, queryEmbedding []float32, nResults int) ([]string, []float64, error) {
 	if queryEmbedding == nil || len(queryEmbedding) == 0 {
 		return []string{}, []float64{}, fmt.Errorf("query embedding cannot be empty")
@@ -97,6 +97,419 @@ func CreateSchema() error {
 	`
 
 	_, err = Pool.Exec(context.Background(), migration2)
+	if err != nil {
+		return err
+	}
+
+	// LLM response cache (completions and embeddings keyed by provider, model, prompt hash and parameters)
+	migration3 := `
+		CREATE TABLE IF NOT EXISTS llm_cache (
+			key TEXT PRIMARY KEY,
+			provider TEXT NOT NULL,
+			model TEXT NOT NULL,
+			kind TEXT NOT NULL,
+			response TEXT NOT NULL,
+			hits INTEGER NOT NULL DEFAULT 0,
+			created_at TIMESTAMP DEFAULT NOW(),
+			last_hit_at TIMESTAMP DEFAULT NOW(),
+			expires_at TIMESTAMP NOT NULL
+		);
+
+		CREATE INDEX IF NOT EXISTS idx_llm_cache_expires_at ON llm_cache(expires_at);
+		CREATE INDEX IF NOT EXISTS idx_llm_cache_last_hit_at ON llm_cache(last_hit_at);
+	`
+
+	_, err = Pool.Exec(context.Background(), migration3)
+	if err != nil {
+		return err
+	}
+
+	// AI usage and cost accounting (one row per provider request)
+	migration4 := `
+		CREATE TABLE IF NOT EXISTS ai_usage (
+			id BIGSERIAL PRIMARY KEY,
+			provider TEXT NOT NULL,
+			model TEXT NOT NULL,
+			operation TEXT NOT NULL,
+			input_tokens INTEGER NOT NULL DEFAULT 0,
+			output_tokens INTEGER NOT NULL DEFAULT 0,
+			latency_ms INTEGER NOT NULL DEFAULT 0,
+			cost_usd DOUBLE PRECISION NOT NULL DEFAULT 0,
+			success BOOLEAN NOT NULL DEFAULT TRUE,
+			created_at TIMESTAMP DEFAULT NOW()
+		);
+
+		CREATE INDEX IF NOT EXISTS idx_ai_usage_created_at ON ai_usage(created_at);
+	`
+
+	_, err = Pool.Exec(context.Background(), migration4)
+	if err != nil {
+		return err
+	}
+
+	// Structured AI enrichment fields (named entities, language, content kind)
+	migration5 := `
+		ALTER TABLE items ADD COLUMN IF NOT EXISTS entities TEXT[] DEFAULT '{}';
+		ALTER TABLE items ADD COLUMN IF NOT EXISTS language TEXT;
+		ALTER TABLE items ADD COLUMN IF NOT EXISTS content_kind TEXT;
+	`
+
+	_, err = Pool.Exec(context.Background(), migration5)
+	if err != nil {
+		return err
+	}
+
+	// User-defined category taxonomy, seeded with the built-in categories on first run.
+	// seeds records which default data was inserted, so it isn't inserted again after the
+	// user deletes it; databases that had the table already were seeded when it was created.
+	migration6 := `
+		CREATE TABLE IF NOT EXISTS seeds (
+			name TEXT PRIMARY KEY,
+			created_at TIMESTAMP DEFAULT NOW()
+		);
+
+		INSERT INTO seeds (name) SELECT 'categories' WHERE to_regclass('categories') IS NOT NULL
+		ON CONFLICT (name) DO NOTHING;
+
+		CREATE TABLE IF NOT EXISTS categories (
+			id UUID PRIMARY KEY DEFAULT gen_random_uuid(),
+			name TEXT NOT NULL UNIQUE,
+			description TEXT NOT NULL DEFAULT '',
+			aliases TEXT[] DEFAULT '{}',
+			icon TEXT NOT NULL DEFAULT '',
+			image_keyword TEXT NOT NULL DEFAULT '',
+			parent_id UUID REFERENCES categories(id) ON DELETE SET NULL,
+			created_at TIMESTAMP DEFAULT NOW()
+		);
+
+		INSERT INTO categories (name, description, aliases, icon, image_keyword)
+		SELECT * FROM (VALUES
+			('Technology', 'Software, gadgets, programming and AI', ARRAY['technology', 'tech'], '💻', 'technology'),
+			('Food & Recipes', 'Recipes, cooking and restaurants', ARRAY['food', 'recipe', 'cooking'], '🍳', 'food'),
+			('Books & Reading', 'Books, reading lists and literature', ARRAY['book', 'books', 'reading'], '📚', 'books'),
+			('Videos & Entertainment', 'Videos, movies, music and games', ARRAY['video', 'videos', 'entertainment'], '🎬', 'entertainment'),
+			('Shopping & Products', 'Products to buy and wishlists', ARRAY['shopping', 'product', 'products'], '🛍️', 'product'),
+			('Articles & News', 'News, blog posts and long-form articles', ARRAY['article', 'articles', 'news'], '📰', 'news'),
+			('Notes & Ideas', 'Personal notes, to-dos and ideas', ARRAY['note', 'notes', 'idea', 'ideas'], '📝', 'notebook'),
+			('Design & Inspiration', 'Design, art and visual inspiration', ARRAY['design', 'inspiration'], '🎨', 'design'),
+			('Travel', 'Destinations, trips and travel tips', ARRAY['travel'], '✈️', 'travel'),
+			('Health & Fitness', 'Exercise, nutrition and wellbeing', ARRAY['health', 'fitness'], '💪', 'fitness'),
+			('Education & Learning', 'Courses, tutorials and study material', ARRAY['education', 'learning'], '🎓', 'education'),
+			('Other', 'Anything that fits no other category', ARRAY[]::TEXT[], '📦', 'abstract')
+		) AS defaults(name, description, aliases, icon, image_keyword)
+		WHERE NOT EXISTS (SELECT 1 FROM seeds WHERE name = 'categories');
+
+		INSERT INTO seeds (name) VALUES ('categories') ON CONFLICT (name) DO NOTHING;
+	`
+
+	_, err = Pool.Exec(context.Background(), migration6)
+	if err != nil {
+		return err
+	}
+
+	// Tag synonyms (each synonym maps to its canonical tag), and normalization of
+	// existing tags to the form NormalizeTag produces: lowercase, hyphen-separated, deduplicated
+	migration7 := `
+		CREATE TABLE IF NOT EXISTS tag_synonyms (
+			synonym TEXT PRIMARY KEY,
+			tag TEXT NOT NULL,
+			created_at TIMESTAMP DEFAULT NOW()
+		);
+
+		CREATE INDEX IF NOT EXISTS idx_tag_synonyms_tag ON tag_synonyms(tag);
+
+		UPDATE items SET tags = ARRAY(
+			SELECT t FROM (
+				SELECT regexp_replace(lower(btrim(raw, E' \t\n#')), '\s+', '-', 'g') AS t, n
+				FROM unnest(items.tags) WITH ORDINALITY AS u(raw, n)
+			) normalized
+			WHERE t <> ''
+			GROUP BY t
+			ORDER BY MIN(n)
+		)
+		WHERE EXISTS (
+			SELECT 1 FROM unnest(items.tags) raw
+			WHERE raw <> regexp_replace(lower(btrim(raw, E' \t\n#')), '\s+', '-', 'g')
+		);
+	`
+
+	_, err = Pool.Exec(context.Background(), migration7)
+	if err != nil {
+		return err
+	}
+
+	// Provenance of the category and of each tag (ai, user, rule or import). Tags missing
+	// from tag_sources were set by the AI.
+	migration8 := `
+		ALTER TABLE items ADD COLUMN IF NOT EXISTS category_source TEXT NOT NULL DEFAULT 'ai';
+		ALTER TABLE items ADD COLUMN IF NOT EXISTS tag_sources JSONB NOT NULL DEFAULT '{}';
+	`
+
+	_, err = Pool.Exec(context.Background(), migration8)
+	if err != nil {
+		return err
+	}
+
+	// Item metadata (kept for rule matching) and categorization rules, seeded once with
+	// the video overrides that used to be hard-coded in the item service
+	migration9 := `
+		ALTER TABLE items ADD COLUMN IF NOT EXISTS metadata JSONB NOT NULL DEFAULT '{}';
+
+		INSERT INTO seeds (name) SELECT 'rules' WHERE to_regclass('rules') IS NOT NULL
+		ON CONFLICT (name) DO NOTHING;
+
+		CREATE TABLE IF NOT EXISTS rules (
+			id UUID PRIMARY KEY DEFAULT gen_random_uuid(),
+			name TEXT NOT NULL,
+			enabled BOOLEAN NOT NULL DEFAULT TRUE,
+			priority INTEGER NOT NULL DEFAULT 0,
+			conditions JSONB NOT NULL DEFAULT '{}',
+			actions JSONB NOT NULL DEFAULT '{}',
+			created_at TIMESTAMP DEFAULT NOW()
+		);
+
+		INSERT INTO rules (name, priority, conditions, actions)
+		SELECT name, priority, conditions::jsonb, actions::jsonb FROM (VALUES
+			('YouTube videos', 0, '{"domains": ["youtube.com", "youtu.be"]}', '{"category": "Videos & Entertainment"}'),
+			('Videos', 0, '{"types": ["video"]}', '{"category": "Videos & Entertainment"}')
+		) AS defaults(name, priority, conditions, actions)
+		WHERE NOT EXISTS (SELECT 1 FROM seeds WHERE name = 'rules');
+
+		INSERT INTO seeds (name) VALUES ('rules') ON CONFLICT (name) DO NOTHING;
+	`
+
+	_, err = Pool.Exec(context.Background(), migration9)
+	if err != nil {
+		return err
+	}
+
+	// Collections and their ordered, many-to-many membership
+	migration10 := `
+		CREATE TABLE IF NOT EXISTS collections (
+			id UUID PRIMARY KEY DEFAULT gen_random_uuid(),
+			name TEXT NOT NULL,
+			description TEXT NOT NULL DEFAULT '',
+			cover_image_url TEXT NOT NULL DEFAULT '',
+			position INTEGER NOT NULL DEFAULT 0,
+			created_at TIMESTAMP DEFAULT NOW()
+		);
+
+		CREATE TABLE IF NOT EXISTS item_collections (
+			collection_id UUID NOT NULL REFERENCES collections(id) ON DELETE CASCADE,
+			item_id UUID NOT NULL REFERENCES items(id) ON DELETE CASCADE,
+			position INTEGER NOT NULL DEFAULT 0,
+			added_at TIMESTAMP DEFAULT NOW(),
+			PRIMARY KEY (collection_id, item_id)
+		);
+
+		CREATE INDEX IF NOT EXISTS idx_item_collections_item_id ON item_collections(item_id);
+	`
+
+	_, err = Pool.Exec(context.Background(), migration10)
+	if err != nil {
+		return err
+	}
+
+	// Smart collections: membership is the result of a stored filter instead of item_collections
+	migration11 := `
+		ALTER TABLE collections ADD COLUMN IF NOT EXISTS filter JSONB;
+	`
+
+	_, err = Pool.Exec(context.Background(), migration11)
+	if err != nil {
+		return err
+	}
+
+	// Soft delete: trashed items keep their row until the purge job removes them
+	migration12 := `
+		ALTER TABLE items ADD COLUMN IF NOT EXISTS deleted_at TIMESTAMP;
+
+		CREATE INDEX IF NOT EXISTS idx_items_deleted_at ON items(deleted_at) WHERE deleted_at IS NOT NULL;
+	`
+
+	_, err = Pool.Exec(context.Background(), migration12)
+	if err != nil {
+		return err
+	}
+
+	// Item revisions: a snapshot of the tracked fields after every change, with its actor.
+	// Existing items get a baseline revision so their first change can be diffed and reverted.
+	migration13 := `
+		CREATE TABLE IF NOT EXISTS item_revisions (
+			id BIGSERIAL PRIMARY KEY,
+			item_id UUID NOT NULL REFERENCES items(id) ON DELETE CASCADE,
+			title TEXT NOT NULL,
+			content TEXT NOT NULL,
+			summary TEXT NOT NULL DEFAULT '',
+			category TEXT NOT NULL DEFAULT '',
+			tags TEXT[] NOT NULL DEFAULT '{}',
+			actor TEXT NOT NULL,
+			created_at TIMESTAMP DEFAULT NOW()
+		);
+
+		CREATE INDEX IF NOT EXISTS idx_item_revisions_item ON item_revisions(item_id, id);
+
+		INSERT INTO item_revisions (item_id, title, content, summary, category, tags, actor, created_at)
+		SELECT id, title, content, COALESCE(summary, ''), COALESCE(category, ''), COALESCE(tags, '{}'), 'import', created_at
+		FROM items
+		WHERE NOT EXISTS (SELECT 1 FROM item_revisions r WHERE r.item_id = items.id);
+	`
+
+	_, err = Pool.Exec(context.Background(), migration13)
+	if err != nil {
+		return err
+	}
+
+	// Annotations: highlights and notes on an item's content, anchored by a quote with its
+	// surrounding text and, when known, character offsets into the content
+	migration14 := `
+		CREATE TABLE IF NOT EXISTS annotations (
+			id UUID PRIMARY KEY DEFAULT gen_random_uuid(),
+			item_id UUID NOT NULL REFERENCES items(id) ON DELETE CASCADE,
+			quote TEXT NOT NULL DEFAULT '',
+			prefix TEXT NOT NULL DEFAULT '',
+			suffix TEXT NOT NULL DEFAULT '',
+			start_offset INT,
+			end_offset INT,
+			note TEXT NOT NULL DEFAULT '',
+			color TEXT NOT NULL DEFAULT 'yellow',
+			created_at TIMESTAMP DEFAULT NOW(),
+			updated_at TIMESTAMP DEFAULT NOW()
+		);
+
+		CREATE INDEX IF NOT EXISTS idx_annotations_item ON annotations(item_id);
+		CREATE INDEX IF NOT EXISTS idx_annotations_created_at ON annotations(created_at DESC);
+	`
+
+	_, err = Pool.Exec(context.Background(), migration14)
+	if err != nil {
+		return err
+	}
+
+	// Item state: favorites, pins, read/unread and archive
+	migration15 := `
+		ALTER TABLE items ADD COLUMN IF NOT EXISTS favorite BOOLEAN NOT NULL DEFAULT FALSE;
+		ALTER TABLE items ADD COLUMN IF NOT EXISTS pinned BOOLEAN NOT NULL DEFAULT FALSE;
+		ALTER TABLE items ADD COLUMN IF NOT EXISTS read_at TIMESTAMP;
+		ALTER TABLE items ADD COLUMN IF NOT EXISTS archived BOOLEAN NOT NULL DEFAULT FALSE;
+
+		CREATE INDEX IF NOT EXISTS idx_items_favorite ON items(created_at DESC) WHERE favorite;
+		CREATE INDEX IF NOT EXISTS idx_items_pinned ON items(created_at DESC) WHERE pinned;
+	`
+
+	_, err = Pool.Exec(context.Background(), migration15)
+	if err != nil {
+		return err
+	}
+
+	// Content is either plain text or sanitized Markdown; content_text is its plain-text
+	// projection, which is what gets embedded and searched
+	migration16 := `
+		ALTER TABLE items ADD COLUMN IF NOT EXISTS content_format TEXT NOT NULL DEFAULT 'text';
+		ALTER TABLE items ADD COLUMN IF NOT EXISTS content_text TEXT NOT NULL DEFAULT '';
+
+		UPDATE items SET content_text = content WHERE content_text = '' AND content_format = 'text';
+	`
+
+	_, err = Pool.Exec(context.Background(), migration16)
+	if err != nil {
+		return err
+	}
+
+	// Long items (PDFs) are also split into chunks, each with its own vector in
+	// ChromaDB, so search results can link to the page that matched
+	migration17 := `
+		CREATE TABLE IF NOT EXISTS item_chunks (
+			id BIGSERIAL PRIMARY KEY,
+			item_id UUID NOT NULL REFERENCES items(id) ON DELETE CASCADE,
+			position INT NOT NULL,
+			page INT NOT NULL DEFAULT 0,
+			content TEXT NOT NULL,
+			embedding_id TEXT NOT NULL,
+			created_at TIMESTAMP NOT NULL DEFAULT NOW(),
+			UNIQUE (item_id, position)
+		);
+
+		CREATE INDEX IF NOT EXISTS idx_item_chunks_embedding_id ON item_chunks(embedding_id);
+	`
+
+	_, err = Pool.Exec(context.Background(), migration17)
+	if err != nil {
+		return err
+	}
+
+	// File contents live in the blob store under their SHA-256; these tables record
+	// what is stored and which items use it
+	migration18 := `
+		CREATE TABLE IF NOT EXISTS blobs (
+			hash TEXT PRIMARY KEY,
+			content_type TEXT NOT NULL,
+			size BIGINT NOT NULL,
+			created_at TIMESTAMP NOT NULL DEFAULT NOW()
+		);
+
+		CREATE TABLE IF NOT EXISTS item_files (
+			item_id UUID NOT NULL REFERENCES items(id) ON DELETE CASCADE,
+			role TEXT NOT NULL,
+			hash TEXT NOT NULL REFERENCES blobs(hash),
+			filename TEXT NOT NULL DEFAULT '',
+			created_at TIMESTAMP NOT NULL DEFAULT NOW(),
+			PRIMARY KEY (item_id, role)
+		);
+
+		CREATE INDEX IF NOT EXISTS idx_item_files_hash ON item_files(hash);
+	`
+
+	_, err = Pool.Exec(context.Background(), migration18)
+	if err != nil {
+		return err
+	}
+
+	// Size, dominant color, blurhash and resized variants of each item's image
+	migration19 := `
+		ALTER TABLE items ADD COLUMN IF NOT EXISTS image_info JSONB;
+	`
+
+	_, err = Pool.Exec(context.Background(), migration19)
+	if err != nil {
+		return err
+	}
+
+	// Text recognized in item images, block by block with the engine's confidence.
+	// items.ocr_text keeps the joined text for search.
+	migration20 := `
+		CREATE TABLE IF NOT EXISTS ocr_results (
+			item_id UUID PRIMARY KEY REFERENCES items(id) ON DELETE CASCADE,
+			engine TEXT NOT NULL,
+			languages TEXT[] NOT NULL DEFAULT '{}',
+			confidence REAL NOT NULL DEFAULT 0,
+			created_at TIMESTAMP NOT NULL DEFAULT NOW()
+		);
+
+		CREATE TABLE IF NOT EXISTS ocr_blocks (
+			item_id UUID NOT NULL REFERENCES ocr_results(item_id) ON DELETE CASCADE,
+			position INT NOT NULL,
+			text TEXT NOT NULL,
+			confidence REAL NOT NULL DEFAULT 0,
+			x INT,
+			y INT,
+			width INT,
+			height INT,
+			PRIMARY KEY (item_id, position)
+		);
+	`
+
+	_, err = Pool.Exec(context.Background(), migration20)
+	if err != nil {
+		return err
+	}
+
+	// Vision model description of image items, embedded with their text
+	migration21 := `
+		ALTER TABLE items ADD COLUMN IF NOT EXISTS image_caption TEXT;
+	`
+
+	_, err = Pool.Exec(context.Background(), migration21)
 	return err
 }
 
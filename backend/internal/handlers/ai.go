@@ -0,0 +1,108 @@
+package handlers
+
+import (
+	"net/http"
+	"synapse/internal/services"
+	"time"
+
+	"github.com/gin-gonic/gin"
+)
+
+type AIHandler struct {
+	aiService *services.AIService
+	llmCache  *services.LLMCache
+	usage     *services.UsageTracker
+	prompts   *services.PromptStore
+}
+
+func NewAIHandler(aiService *services.AIService, llmCache *services.LLMCache, usage *services.UsageTracker, prompts *services.PromptStore) *AIHandler {
+	return &AIHandler{
+		aiService: aiService,
+		llmCache:  llmCache,
+		usage:     usage,
+		prompts:   prompts,
+	}
+}
+
+// GetProviders returns each AI provider's role, circuit breaker state and rate limit
+func (h *AIHandler) GetProviders(c *gin.Context) {
+	c.JSON(http.StatusOK, h.aiService.ProviderStatuses())
+}
+
+// GetCacheStats returns LLM cache size and hit/miss metrics
+func (h *AIHandler) GetCacheStats(c *gin.Context) {
+	stats, err := h.llmCache.Stats(c.Request.Context())
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusOK, stats)
+}
+
+// ClearCache drops every cached completion and embedding
+func (h *AIHandler) ClearCache(c *gin.Context) {
+	if err := h.llmCache.Clear(c.Request.Context()); err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"message": "cache cleared"})
+}
+
+// GetUsage aggregates AI usage and estimated cost. Query params: from and to (YYYY-MM-DD,
+// inclusive, defaulting to the current month) and group_by (day, provider, model or operation).
+func (h *AIHandler) GetUsage(c *gin.Context) {
+	now := time.Now()
+	from := time.Date(now.Year(), now.Month(), 1, 0, 0, 0, 0, now.Location())
+	to := now
+
+	if v := c.Query("from"); v != "" {
+		t, err := time.ParseInLocation("2006-01-02", v, now.Location())
+		if err != nil {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "from must be a date (YYYY-MM-DD)"})
+			return
+		}
+		from = t
+	}
+	if v := c.Query("to"); v != "" {
+		t, err := time.ParseInLocation("2006-01-02", v, now.Location())
+		if err != nil {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "to must be a date (YYYY-MM-DD)"})
+			return
+		}
+		to = t.AddDate(0, 0, 1)
+	}
+
+	groupBy := c.DefaultQuery("group_by", "day")
+	switch groupBy {
+	case "day", "provider", "model", "operation":
+	default:
+		c.JSON(http.StatusBadRequest, gin.H{"error": "group_by must be one of day, provider, model, operation"})
+		return
+	}
+
+	report, err := h.usage.Report(c.Request.Context(), from, to, groupBy)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusOK, report)
+}
+
+// GetPrompts lists the loaded prompt templates with their version and source
+func (h *AIHandler) GetPrompts(c *gin.Context) {
+	c.JSON(http.StatusOK, h.prompts.List())
+}
+
+// ReloadPrompts re-reads prompt overrides from PROMPTS_DIR. Invalid templates are
+// rejected and the current ones stay in use.
+func (h *AIHandler) ReloadPrompts(c *gin.Context) {
+	if err := h.prompts.Reload(); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusOK, h.prompts.List())
+}
@@ -0,0 +1,121 @@
+package handlers
+
+import (
+	"errors"
+	"net/http"
+	"strconv"
+	"synapse/internal/models"
+	"synapse/internal/services"
+
+	"github.com/gin-gonic/gin"
+	"github.com/google/uuid"
+)
+
+type AnnotationHandler struct {
+	annotationService *services.AnnotationService
+}
+
+func NewAnnotationHandler(annotationService *services.AnnotationService) *AnnotationHandler {
+	return &AnnotationHandler{annotationService: annotationService}
+}
+
+// GetRecentAnnotations is the feed of the latest highlights and notes across items
+func (h *AnnotationHandler) GetRecentAnnotations(c *gin.Context) {
+	limit, err := strconv.Atoi(c.DefaultQuery("limit", "50"))
+	if err != nil {
+		limit = 50
+	}
+
+	annotations, err := h.annotationService.Recent(c.Request.Context(), limit)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusOK, annotations)
+}
+
+func (h *AnnotationHandler) GetItemAnnotations(c *gin.Context) {
+	id, err := uuid.Parse(c.Param("id"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "invalid id"})
+		return
+	}
+
+	annotations, err := h.annotationService.ListForItem(c.Request.Context(), id)
+	if err != nil {
+		c.JSON(annotationErrorStatus(err), gin.H{"error": err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusOK, annotations)
+}
+
+func (h *AnnotationHandler) CreateAnnotation(c *gin.Context) {
+	id, err := uuid.Parse(c.Param("id"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "invalid id"})
+		return
+	}
+
+	var req models.AnnotationRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	annotation, err := h.annotationService.Create(c.Request.Context(), id, &req)
+	if err != nil {
+		c.JSON(annotationErrorStatus(err), gin.H{"error": err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusCreated, annotation)
+}
+
+func (h *AnnotationHandler) UpdateAnnotation(c *gin.Context) {
+	id, err := uuid.Parse(c.Param("id"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "invalid id"})
+		return
+	}
+
+	var req models.AnnotationRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	annotation, err := h.annotationService.Update(c.Request.Context(), id, &req)
+	if err != nil {
+		c.JSON(annotationErrorStatus(err), gin.H{"error": err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusOK, annotation)
+}
+
+func (h *AnnotationHandler) DeleteAnnotation(c *gin.Context) {
+	id, err := uuid.Parse(c.Param("id"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "invalid id"})
+		return
+	}
+
+	if err := h.annotationService.Delete(c.Request.Context(), id); err != nil {
+		c.JSON(annotationErrorStatus(err), gin.H{"error": err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"message": "annotation deleted"})
+}
+
+func annotationErrorStatus(err error) int {
+	switch {
+	case errors.Is(err, services.ErrAnnotationNotFound), errors.Is(err, services.ErrItemNotFound):
+		return http.StatusNotFound
+	case errors.Is(err, services.ErrInvalidAnnotation):
+		return http.StatusBadRequest
+	}
+	return http.StatusInternalServerError
+}
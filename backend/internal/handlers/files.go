@@ -0,0 +1,79 @@
+package handlers
+
+import (
+	"errors"
+	"io"
+	"net/http"
+	"strconv"
+	"strings"
+	"synapse/internal/services"
+
+	"github.com/gin-gonic/gin"
+)
+
+type FileHandler struct {
+	fileService *services.FileService
+}
+
+func NewFileHandler(fileService *services.FileService) *FileHandler {
+	return &FileHandler{fileService: fileService}
+}
+
+// GetFile serves a stored file. Files are addressed by the hash of their contents and
+// never change, so clients may cache them for good.
+func (h *FileHandler) GetFile(c *gin.Context) {
+	hash := c.Param("hash")
+	etag := `"` + hash + `"`
+	if match := c.GetHeader("If-None-Match"); match != "" && strings.Contains(match, etag) {
+		c.Status(http.StatusNotModified)
+		return
+	}
+
+	blob, body, err := h.fileService.Open(c.Request.Context(), hash)
+	if err != nil {
+		c.JSON(fileErrorStatus(err), gin.H{"error": err.Error()})
+		return
+	}
+	defer body.Close()
+
+	header := c.Writer.Header()
+	header.Set("Content-Type", blob.ContentType)
+	header.Set("ETag", etag)
+	header.Set("Cache-Control", "public, max-age=31536000, immutable")
+	header.Set("X-Content-Type-Options", "nosniff")
+	// Stored files come from users and web pages; never let one run as a page of ours.
+	// Browsers refuse to render PDFs in a sandbox, and their viewers don't run the
+	// document's scripts anyway.
+	if blob.ContentType != "application/pdf" {
+		header.Set("Content-Security-Policy", "default-src 'none'; img-src 'self' data:; style-src 'unsafe-inline'; sandbox")
+	}
+	if !inlineContentType(blob.ContentType) {
+		header.Set("Content-Disposition", "attachment")
+	}
+
+	// Seekable stores also get range requests, which PDF viewers use
+	if seeker, ok := body.(io.ReadSeeker); ok {
+		http.ServeContent(c.Writer, c.Request, "", blob.CreatedAt, seeker)
+		return
+	}
+	header.Set("Content-Length", strconv.FormatInt(blob.Size, 10))
+	header.Set("Last-Modified", blob.CreatedAt.UTC().Format(http.TimeFormat))
+	c.Status(http.StatusOK)
+	if c.Request.Method != http.MethodHead {
+		io.Copy(c.Writer, body)
+	}
+}
+
+// inlineContentType reports whether a browser may display a file in place rather
+// than download it
+func inlineContentType(contentType string) bool {
+	return strings.HasPrefix(contentType, "image/") || contentType == "application/pdf" || contentType == "text/plain"
+}
+
+func fileErrorStatus(err error) int {
+	switch {
+	case errors.Is(err, services.ErrFileNotFound):
+		return http.StatusNotFound
+	}
+	return http.StatusInternalServerError
+}
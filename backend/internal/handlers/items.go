@@ -1,7 +1,9 @@
 package handlers
 
 import (
+	"errors"
 	"net/http"
+	"strconv"
 	"synapse/internal/models"
 	"synapse/internal/services"
 
@@ -28,9 +30,9 @@ func (h *ItemHandler) CreateItem(c *gin.Context) {
 		return
 	}
 
-	// Validate required fields
-	if req.Title == "" && req.Content == "" {
-		c.JSON(http.StatusBadRequest, gin.H{"error": "title or content is required"})
+	// Validate required fields; a bare URL is fetched for its text
+	if req.Title == "" && req.Content == "" && req.HTML == "" && req.SourceURL == "" {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "title, content or source_url is required"})
 		return
 	}
 
@@ -65,8 +67,103 @@ func (h *ItemHandler) GetItem(c *gin.Context) {
 	c.JSON(http.StatusOK, item)
 }
 
+// UpdateItem edits an item's category and tags. Edited values are marked as set by the
+// user and survive re-enrichment.
+func (h *ItemHandler) UpdateItem(c *gin.Context) {
+	id, err := uuid.Parse(c.Param("id"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "invalid id"})
+		return
+	}
+
+	var req models.UpdateItemRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	item, err := h.itemService.UpdateItem(c.Request.Context(), id, &req)
+	if err != nil {
+		c.JSON(itemErrorStatus(err), gin.H{"error": err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusOK, item)
+}
+
+// ReEnrichItem re-runs AI categorization and tagging, replacing only AI-set values
+func (h *ItemHandler) ReEnrichItem(c *gin.Context) {
+	id, err := uuid.Parse(c.Param("id"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "invalid id"})
+		return
+	}
+
+	item, err := h.itemService.ReEnrichItem(c.Request.Context(), id)
+	if err != nil {
+		c.JSON(itemErrorStatus(err), gin.H{"error": err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusOK, item)
+}
+
+// itemStateFilter reads the favorite, pinned, read and archived query parameters. archived
+// is used when the archived parameter is absent; "all" or an absent parameter matches both
+// values. It responds with 400 and returns false on an invalid value.
+func itemStateFilter(c *gin.Context, archived *bool) (models.ItemStateFilter, bool) {
+	state := models.ItemStateFilter{Archived: archived}
+	params := []struct {
+		name  string
+		value **bool
+	}{
+		{"favorite", &state.Favorite},
+		{"pinned", &state.Pinned},
+		{"read", &state.Read},
+		{"archived", &state.Archived},
+	}
+	for _, param := range params {
+		raw, present := c.GetQuery(param.name)
+		if !present {
+			continue
+		}
+		if raw == "all" {
+			*param.value = nil
+			continue
+		}
+		value, err := strconv.ParseBool(raw)
+		if err != nil {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "invalid " + param.name + " filter"})
+			return state, false
+		}
+		*param.value = &value
+	}
+	return state, true
+}
+
+func itemErrorStatus(err error) int {
+	switch {
+	case errors.Is(err, services.ErrItemNotFound):
+		return http.StatusNotFound
+	case errors.Is(err, services.ErrInvalidCategory), errors.Is(err, services.ErrInvalidTag):
+		return http.StatusBadRequest
+	case errors.Is(err, services.ErrEnrichmentFailed):
+		return http.StatusBadGateway
+	}
+	return http.StatusInternalServerError
+}
+
+// GetAllItems lists items, optionally filtered with favorite, pinned, read and archived
+// set to true or false. Archived items are left out unless archived is given; "all"
+// includes both.
 func (h *ItemHandler) GetAllItems(c *gin.Context) {
-	items, err := h.itemService.GetAllItems(c.Request.Context())
+	notArchived := false
+	state, ok := itemStateFilter(c, &notArchived)
+	if !ok {
+		return
+	}
+
+	items, err := h.itemService.GetAllItems(c.Request.Context(), state)
 	if err != nil {
 		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
 		return
@@ -89,11 +186,11 @@ func (h *ItemHandler) DeleteItem(c *gin.Context) {
 	}
 
 	if err := h.itemService.DeleteItem(c.Request.Context(), id); err != nil {
-		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		c.JSON(itemErrorStatus(err), gin.H{"error": err.Error()})
 		return
 	}
 
-	c.JSON(http.StatusOK, gin.H{"message": "item deleted"})
+	c.JSON(http.StatusOK, gin.H{"message": "item moved to trash"})
 }
 
 func (h *ItemHandler) GetRelatedItems(c *gin.Context) {
@@ -104,10 +201,15 @@ func (h *ItemHandler) GetRelatedItems(c *gin.Context) {
 		return
 	}
 
+	collectionID, ok := collectionScope(c)
+	if !ok {
+		return
+	}
+
 	limit := 5
-	related, err := h.relationService.FindRelatedItems(c.Request.Context(), id, limit)
+	related, err := h.relationService.FindRelatedItems(c.Request.Context(), id, limit, collectionID)
 	if err != nil {
-		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		c.JSON(collectionErrorStatus(err), gin.H{"error": err.Error()})
 		return
 	}
 
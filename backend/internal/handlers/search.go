@@ -29,9 +29,20 @@ func (h *SearchHandler) Search(c *gin.Context) {
 		limit = 10
 	}
 
-	results, err := h.searchService.Search(c.Request.Context(), query, limit)
+	collectionID, ok := collectionScope(c)
+	if !ok {
+		return
+	}
+
+	// Archived items are searched too unless archived=false
+	state, ok := itemStateFilter(c, nil)
+	if !ok {
+		return
+	}
+
+	results, err := h.searchService.Search(c.Request.Context(), query, limit, collectionID, state)
 	if err != nil {
-		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		c.JSON(collectionErrorStatus(err), gin.H{"error": err.Error()})
 		return
 	}
 
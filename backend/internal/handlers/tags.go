@@ -0,0 +1,90 @@
+package handlers
+
+import (
+	"errors"
+	"net/http"
+	"synapse/internal/models"
+	"synapse/internal/services"
+
+	"github.com/gin-gonic/gin"
+)
+
+type TagHandler struct {
+	tagService *services.TagService
+}
+
+func NewTagHandler(tagService *services.TagService) *TagHandler {
+	return &TagHandler{tagService: tagService}
+}
+
+// GetTags lists every tag in use with its item count and synonyms
+func (h *TagHandler) GetTags(c *gin.Context) {
+	tags, err := h.tagService.List(c.Request.Context())
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusOK, tags)
+}
+
+// RenameTag renames a tag on every item
+func (h *TagHandler) RenameTag(c *gin.Context) {
+	var req models.RenameTagRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	changed, err := h.tagService.Rename(c.Request.Context(), c.Param("name"), req.Name)
+	if err != nil {
+		c.JSON(tagErrorStatus(err), gin.H{"error": err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"tag": services.NormalizeTag(req.Name), "items_updated": changed})
+}
+
+// MergeTag replaces a tag with another on every item and keeps the old name as a synonym
+func (h *TagHandler) MergeTag(c *gin.Context) {
+	var req models.MergeTagRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	changed, err := h.tagService.Merge(c.Request.Context(), c.Param("name"), req.Into)
+	if err != nil {
+		c.JSON(tagErrorStatus(err), gin.H{"error": err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"tag": services.NormalizeTag(req.Into), "items_updated": changed})
+}
+
+// SetTagSynonyms replaces a tag's synonym group
+func (h *TagHandler) SetTagSynonyms(c *gin.Context) {
+	var req models.TagSynonymsRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	tag, err := h.tagService.SetSynonyms(c.Request.Context(), c.Param("name"), req.Synonyms)
+	if err != nil {
+		c.JSON(tagErrorStatus(err), gin.H{"error": err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusOK, tag)
+}
+
+func tagErrorStatus(err error) int {
+	switch {
+	case errors.Is(err, services.ErrTagNotFound):
+		return http.StatusNotFound
+	case errors.Is(err, services.ErrInvalidTag):
+		return http.StatusBadRequest
+	}
+	return http.StatusInternalServerError
+}
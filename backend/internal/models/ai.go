@@ -0,0 +1,93 @@
+package models
+
+import "time"
+
+// LLMCacheKindStats describes cached entries of one kind ("completion" or "embedding")
+type LLMCacheKindStats struct {
+	Kind       string `json:"kind"`
+	Entries    int64  `json:"entries"`
+	StoredHits int64  `json:"stored_hits"` // Hits recorded on entries that are still live
+	Hits       int64  `json:"hits"`        // Hits since the server started
+	Misses     int64  `json:"misses"`      // Misses since the server started
+}
+
+// LLMCacheStats is returned by GET /api/ai/cache
+type LLMCacheStats struct {
+	Enabled    bool                `json:"enabled"`
+	TTLSeconds int64               `json:"ttl_seconds"`
+	MaxEntries int                 `json:"max_entries"`
+	Entries    int64               `json:"entries"`
+	Hits       int64               `json:"hits"`
+	Misses     int64               `json:"misses"`
+	HitRate    float64             `json:"hit_rate"`
+	Kinds      []LLMCacheKindStats `json:"kinds"`
+}
+
+// ProviderStatus is reported by GET /api/ai/providers
+type ProviderStatus struct {
+	Provider          string `json:"provider"`
+	Role              string `json:"role"` // "primary", "fallback" or "unused"
+	Configured        bool   `json:"configured"`
+	CircuitState      string `json:"circuit_state"`
+	ConsecutiveErrors int    `json:"consecutive_errors"`
+	LastError         string `json:"last_error,omitempty"`
+	RequestsPerMinute int    `json:"requests_per_minute"`
+}
+
+// AIUsageRecord is one provider request recorded for usage and cost accounting
+type AIUsageRecord struct {
+	Provider     string    `json:"provider"`
+	Model        string    `json:"model"`
+	Operation    string    `json:"operation"` // "enrich", "tags", "category", "summary", "rerank", "enhance", "embed", "ocr", "caption"
+	InputTokens  int       `json:"input_tokens"`
+	OutputTokens int       `json:"output_tokens"`
+	LatencyMs    int       `json:"latency_ms"`
+	CostUSD      float64   `json:"cost_usd"`
+	Success      bool      `json:"success"`
+	CreatedAt    time.Time `json:"created_at"`
+}
+
+// AIUsageGroup aggregates usage records sharing the same group key
+type AIUsageGroup struct {
+	Key          string  `json:"key"`
+	Calls        int64   `json:"calls"`
+	Failures     int64   `json:"failures"`
+	InputTokens  int64   `json:"input_tokens"`
+	OutputTokens int64   `json:"output_tokens"`
+	CostUSD      float64 `json:"cost_usd"`
+	AvgLatencyMs float64 `json:"avg_latency_ms"`
+}
+
+// AIBudgetStatus describes spend against the optional monthly budget
+type AIBudgetStatus struct {
+	MonthlyUSD float64 `json:"monthly_usd"` // 0 means no budget
+	SpentUSD   float64 `json:"spent_usd"`   // Spent since the start of the current month
+	Exceeded   bool    `json:"exceeded"`
+}
+
+// AIUsageReport is returned by GET /api/usage
+type AIUsageReport struct {
+	From    time.Time      `json:"from"`
+	To      time.Time      `json:"to"`
+	GroupBy string         `json:"group_by"`
+	Totals  AIUsageGroup   `json:"totals"`
+	Groups  []AIUsageGroup `json:"groups"`
+	Budget  AIBudgetStatus `json:"budget"`
+}
+
+// PromptInfo describes a loaded prompt template, returned by GET /api/prompts
+type PromptInfo struct {
+	Name    string `json:"name"`
+	Version string `json:"version"`
+	Source  string `json:"source"` // "builtin" or the override file path
+}
+
+// Enrichment is the structured result of a single AI enrichment call
+type Enrichment struct {
+	Category    string   `json:"category"`
+	Tags        []string `json:"tags"`
+	Summary     string   `json:"summary"`
+	Entities    []string `json:"entities"`
+	Language    string   `json:"language"`
+	ContentKind string   `json:"content_kind"`
+}
@@ -0,0 +1,39 @@
+package models
+
+import (
+	"time"
+
+	"github.com/google/uuid"
+)
+
+// Annotation is a highlight or margin note on an item. A highlight is anchored by the
+// quoted text with a little of the text around it, so it can be found again if offsets
+// drift; Start and End are rune offsets into the plain text of the item's content when
+// the client knows them. A note without a quote applies to the whole item.
+type Annotation struct {
+	ID        uuid.UUID `json:"id"`
+	ItemID    uuid.UUID `json:"item_id"`
+	Quote     string    `json:"quote"`
+	Prefix    string    `json:"prefix"` // Text just before the quote
+	Suffix    string    `json:"suffix"` // Text just after the quote
+	Start     *int      `json:"start,omitempty"`
+	End       *int      `json:"end,omitempty"`
+	Note      string    `json:"note"`
+	Color     string    `json:"color"`
+	ItemTitle string    `json:"item_title,omitempty"` // Set in the highlights feed
+	CreatedAt time.Time `json:"created_at"`
+	UpdatedAt time.Time `json:"updated_at"`
+}
+
+// AnnotationColors are the highlight colors clients can pick
+var AnnotationColors = []string{"yellow", "green", "blue", "pink", "purple"}
+
+type AnnotationRequest struct {
+	Quote  string `json:"quote"`
+	Prefix string `json:"prefix"`
+	Suffix string `json:"suffix"`
+	Start  *int   `json:"start"`
+	End    *int   `json:"end"`
+	Note   string `json:"note"`
+	Color  string `json:"color"` // Defaults to yellow
+}
@@ -0,0 +1,15 @@
+package models
+
+import "time"
+
+// Article is the readable part of a web page, extracted server-side for URL items
+type Article struct {
+	Title       string     `json:"title"`
+	Content     string     `json:"content"` // Main text as sanitized Markdown
+	Excerpt     string     `json:"excerpt"` // Page description, or the first paragraph
+	Byline      string     `json:"byline"`
+	PublishedAt *time.Time `json:"published_at,omitempty"`
+	SiteName    string     `json:"site_name"`
+	Language    string     `json:"language"`   // ISO 639-1 code
+	LeadImage   string     `json:"lead_image"` // Absolute URL
+}
@@ -0,0 +1,33 @@
+package models
+
+import (
+	"time"
+
+	"github.com/google/uuid"
+)
+
+// Category is a section of the user-defined taxonomy items are sorted into
+type Category struct {
+	ID           uuid.UUID  `json:"id"`
+	Name         string     `json:"name"`
+	Description  string     `json:"description"` // Shown to the AI to tell categories apart
+	Aliases      []string   `json:"aliases"`     // Lowercase words that refer to the category in search queries
+	Icon         string     `json:"icon"`
+	ImageKeyword string     `json:"image_keyword"` // Search term for category-based cover images
+	ParentID     *uuid.UUID `json:"parent_id"`
+	ItemCount    int        `json:"item_count"`
+	CreatedAt    time.Time  `json:"created_at"`
+}
+
+type CategoryRequest struct {
+	Name         string     `json:"name"`
+	Description  string     `json:"description"`
+	Aliases      []string   `json:"aliases"`
+	Icon         string     `json:"icon"`
+	ImageKeyword string     `json:"image_keyword"`
+	ParentID     *uuid.UUID `json:"parent_id"`
+}
+
+type MergeCategoryRequest struct {
+	Into uuid.UUID `json:"into"` // Category that receives the merged category's items and aliases
+}
@@ -0,0 +1,27 @@
+package models
+
+import (
+	"time"
+
+	"github.com/google/uuid"
+)
+
+// ItemChunk is a passage of a long item, such as a PDF, embedded on its own so search
+// can point at the page that matched. Page is 1-based, or 0 when the item has no pages.
+type ItemChunk struct {
+	ID          int64     `json:"id"`
+	ItemID      uuid.UUID `json:"item_id"`
+	Position    int       `json:"position"` // Order of the chunk within the item
+	Page        int       `json:"page"`
+	Content     string    `json:"content"`
+	EmbeddingID string    `json:"embedding_id"`
+	CreatedAt   time.Time `json:"created_at"`
+}
+
+// PDFDocument is the text and metadata extracted from a PDF
+type PDFDocument struct {
+	Title     string   `json:"title"`
+	Author    string   `json:"author"`
+	PageCount int      `json:"page_count"`
+	Pages     []string `json:"pages"` // Text of each page, in order
+}
@@ -0,0 +1,46 @@
+package models
+
+import (
+	"time"
+
+	"github.com/google/uuid"
+)
+
+// Collection is a user-created, ordered group of items (a folder or board)
+type Collection struct {
+	ID            uuid.UUID    `json:"id"`
+	Name          string       `json:"name"`
+	Description   string       `json:"description"`
+	CoverImageURL string       `json:"cover_image_url"`  // Set by the user; may be empty
+	Cover         string       `json:"cover"`            // CoverImageURL, or else the image of the first item
+	Position      int          `json:"position"`         // Collections are listed by ascending position
+	Filter        *SmartFilter `json:"filter,omitempty"` // Set for smart collections, whose items are the ones matching it
+	ItemCount     int          `json:"item_count"`
+	CreatedAt     time.Time    `json:"created_at"`
+}
+
+// SmartFilter defines the members of a smart collection. It is evaluated whenever the
+// collection is read, so the collection follows new and changed items. Every field that
+// is set must match.
+type SmartFilter struct {
+	Text     string     `json:"text,omitempty"` // Words that must all appear in the title, content, summary, OCR text, caption or notes
+	Type     string     `json:"type,omitempty"` // Item type
+	Category string     `json:"category,omitempty"`
+	Tags     []string   `json:"tags,omitempty"`  // Items with any of these tags (or their synonyms)
+	Since    string     `json:"since,omitempty"` // Relative window ending now: "14d", "2w", "3m" or "1y"
+	From     *time.Time `json:"from,omitempty"`
+	To       *time.Time `json:"to,omitempty"`
+}
+
+type CollectionRequest struct {
+	Name          string       `json:"name"`
+	Description   string       `json:"description"`
+	CoverImageURL string       `json:"cover_image_url"`
+	Position      int          `json:"position"`
+	Filter        *SmartFilter `json:"filter"` // Makes the collection smart
+}
+
+// CollectionItemsRequest lists items to add to a collection, or the new order of its items
+type CollectionItemsRequest struct {
+	ItemIDs []uuid.UUID `json:"item_ids"`
+}
@@ -0,0 +1,54 @@
+package models
+
+import (
+	"time"
+
+	"github.com/google/uuid"
+)
+
+// Roles of the files attached to an item
+const (
+	FileRoleImage    = "image"    // The item's preview image (screenshots, uploaded images)
+	FileRoleOriginal = "original" // The file an item was uploaded from
+	FileRoleVariant  = "variant_" // Prefix of the roles of resized images, e.g. "variant_small"
+)
+
+// Blob is a stored file, addressed by the SHA-256 of its contents
+type Blob struct {
+	Hash        string    `json:"hash"`
+	ContentType string    `json:"content_type"`
+	Size        int64     `json:"size"`
+	CreatedAt   time.Time `json:"created_at"`
+}
+
+// ItemFile attaches a blob to an item in a role; an item has at most one file per role
+type ItemFile struct {
+	ItemID      uuid.UUID `json:"item_id"`
+	Role        string    `json:"role"`
+	Hash        string    `json:"hash"`
+	Filename    string    `json:"filename,omitempty"`
+	ContentType string    `json:"content_type"`
+	Size        int64     `json:"size"`
+	URL         string    `json:"url"`
+	CreatedAt   time.Time `json:"created_at"`
+}
+
+// ImageInfo describes an item's processed image: its size, placeholders to show while
+// it loads, and smaller variants for cards
+type ImageInfo struct {
+	Source   string         `json:"source"` // The image_url this was made from
+	Width    int            `json:"width,omitempty"`
+	Height   int            `json:"height,omitempty"`
+	Color    string         `json:"color,omitempty"`    // Dominant color, "#rrggbb"
+	Blurhash string         `json:"blurhash,omitempty"` // https://blurha.sh placeholder
+	Variants []ImageVariant `json:"variants,omitempty"` // Smallest first
+	Error    string         `json:"error,omitempty"`    // Why the image couldn't be processed
+}
+
+// ImageVariant is a resized copy of an item's image
+type ImageVariant struct {
+	Name   string `json:"name"` // "small", "medium" or "large"
+	Width  int    `json:"width"`
+	Height int    `json:"height"`
+	URL    string `json:"url"`
+}
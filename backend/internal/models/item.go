@@ -16,22 +16,51 @@ type QueryFilters struct {
 	PriceMin      *float64
 	Author        string
 	Source        string
+	ItemIDs       []uuid.UUID // Only these items (a collection's members); nil means no restriction
+	StrictType    bool        // Apply Type even when SearchTerms are set
+	AllTerms      bool        // Match items containing every word of SearchTerms, not just one
+	ItemStateFilter
+}
+
+// ItemStateFilter selects items by their flags. A nil field matches either value.
+type ItemStateFilter struct {
+	Favorite *bool
+	Pinned   *bool
+	Read     *bool
+	Archived *bool
 }
 
 type Item struct {
-	ID          uuid.UUID `json:"id"`
-	Title       string    `json:"title"`
-	Content     string    `json:"content"`
-	Summary     string    `json:"summary"`
-	SourceURL   string    `json:"source_url"`
-	Type        string    `json:"type"` // "text", "url", "image", "book", "recipe"
-	Category    string    `json:"category"` // AI-categorized section: "Technology", "Food & Recipes", "Books", "Videos", "Shopping", "Articles", "Notes", etc.
-	Tags        []string  `json:"tags"`
-	EmbeddingID string    `json:"embedding_id"`
-	ImageURL    string    `json:"image_url"`    // For book covers, recipe images, or page previews
-	EmbedHTML   string    `json:"embed_html"`   // For URL embeds/previews
-	OcrText     string    `json:"ocr_text"`     // Extracted text from images/screenshots via OCR
-	CreatedAt   time.Time `json:"created_at"`
+	ID             uuid.UUID         `json:"id"`
+	Title          string            `json:"title"`
+	Content        string            `json:"content"`
+	ContentFormat  string            `json:"content_format"` // "text" or "markdown"
+	ContentText    string            `json:"-"`              // Plain-text projection of the content, embedded and searched
+	Summary        string            `json:"summary"`
+	SourceURL      string            `json:"source_url"`
+	Type           string            `json:"type"`            // "text", "url", "image", "book", "recipe"
+	Category       string            `json:"category"`        // AI-categorized section: "Technology", "Food & Recipes", "Books", "Videos", "Shopping", "Articles", "Notes", etc.
+	CategorySource string            `json:"category_source"` // Who set the category: "ai", "user", "rule" or "import"
+	Tags           []string          `json:"tags"`
+	TagSources     map[string]string `json:"tag_sources"` // Who set each tag; tags not listed were set by the AI
+	EmbeddingID    string            `json:"embedding_id"`
+	ImageURL       string            `json:"image_url"`    // For book covers, recipe images, or page previews
+	EmbedHTML      string            `json:"embed_html"`   // For URL embeds/previews
+	OcrText        string            `json:"ocr_text"`     // Extracted text from images/screenshots via OCR
+	ImageCaption   string            `json:"image_caption,omitempty"` // Vision model description of image items, embedded with their text
+	Entities       []string          `json:"entities"`     // Named entities (people, organizations, places, products) found by AI enrichment
+	Language       string            `json:"language"`     // ISO 639-1 code of the content language
+	ContentKind    string            `json:"content_kind"` // Form of the content: "article", "tutorial", "recipe", "product", ...
+	Metadata       map[string]string `json:"metadata"`     // Metadata sent with the item (price, author, ...), matched by rules
+	Favorite       bool              `json:"favorite"`
+	Pinned         bool              `json:"pinned"`            // Pinned items are listed first
+	ReadAt         *time.Time        `json:"read_at,omitempty"` // Set once the item is marked read
+	Archived       bool              `json:"archived"`          // Archived items are hidden from the item list but still searchable
+	CreatedAt      time.Time         `json:"created_at"`
+	DeletedAt      *time.Time        `json:"deleted_at,omitempty"` // Set while the item is in the trash
+	Image          *ImageInfo        `json:"image,omitempty"`      // Size, placeholders and thumbnails of image_url, once processed
+	Files          []ItemFile        `json:"files,omitempty"`      // Stored files (image, uploaded original); only loaded for a single item
+	OCR            *OCRResult        `json:"ocr,omitempty"`        // Text blocks recognized in the image; only loaded for a single item
 }
 
 type CreateItemRequest struct {
@@ -41,6 +70,35 @@ type CreateItemRequest struct {
 	Type      string            `json:"type"` // "text", "url", "image", "amazon", "blog", "video"
 	ImageURL  string            `json:"image_url"` // For pre-extracted images
 	Metadata  map[string]string `json:"metadata"` // Additional metadata (price, rating, etc.)
+	HTML      string            `json:"html"`      // Captured HTML, stored as sanitized Markdown in place of content
+	Format    string            `json:"content_format"` // Format of content: "text" (default) or "markdown"
+	OCRLanguages []string       `json:"ocr_languages"` // Language hints for OCR of images, e.g. ["en", "de"]; defaults to OCR_LANGUAGES
+}
+
+// Formats of an item's content
+const (
+	FormatText     = "text"
+	FormatMarkdown = "markdown"
+)
+
+// Sources of an item's category and tags. Values set by the user, a rule or an import are
+// locked: AI re-enrichment only replaces values whose source is SourceAI.
+const (
+	SourceAI     = "ai"
+	SourceUser   = "user"
+	SourceRule   = "rule"
+	SourceImport = "import"
+)
+
+// UpdateItemRequest edits an item. Omitted fields are left unchanged; the category and
+// tags given are marked as set by the user.
+type UpdateItemRequest struct {
+	Category *string   `json:"category"`
+	Tags     *[]string `json:"tags"`
+	Favorite *bool     `json:"favorite"`
+	Pinned   *bool     `json:"pinned"`
+	Read     *bool     `json:"read"` // Marking an item read sets read_at; unread clears it
+	Archived *bool     `json:"archived"`
 }
 
 type RelatedItem struct {
@@ -51,5 +109,7 @@ type RelatedItem struct {
 type SearchResult struct {
 	Item           Item    `json:"item"`
 	SimilarityScore float64 `json:"similarity_score"`
+	Page           int     `json:"page,omitempty"`    // Page of the best matching chunk, for PDFs
+	Snippet        string  `json:"snippet,omitempty"` // Text of the best matching chunk
 }
 
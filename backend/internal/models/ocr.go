@@ -0,0 +1,33 @@
+package models
+
+import "time"
+
+// OCR engines
+const (
+	OCREngineTesseract = "tesseract" // Local Tesseract CLI
+	OCREngineVision    = "vision"    // Vision model of the configured AI provider
+)
+
+// OCRResult is the text recognized in an item's image, in reading order
+type OCRResult struct {
+	Engine     string     `json:"engine"`
+	Languages  []string   `json:"languages"`  // Language hints the engine was given
+	Confidence float64    `json:"confidence"` // Mean block confidence weighted by text length, 0-1
+	Blocks     []OCRBlock `json:"blocks"`
+	CreatedAt  time.Time  `json:"created_at"`
+}
+
+// OCRBlock is a paragraph or region of recognized text
+type OCRBlock struct {
+	Text       string  `json:"text"`
+	Confidence float64 `json:"confidence"`    // 0-1; vision models estimate their own
+	Box        *OCRBox `json:"box,omitempty"` // Where the block is; nil if the engine doesn't locate text
+}
+
+// OCRBox is a block's bounding box in image pixels
+type OCRBox struct {
+	X      int `json:"x"`
+	Y      int `json:"y"`
+	Width  int `json:"width"`
+	Height int `json:"height"`
+}
@@ -0,0 +1,29 @@
+package models
+
+import (
+	"time"
+
+	"github.com/google/uuid"
+)
+
+// ItemRevision is a snapshot of an item's title, content, summary, category and tags
+// taken after a change. Actor is who made the change: SourceUser, SourceAI or SourceImport.
+type ItemRevision struct {
+	ID        int64       `json:"id"`
+	ItemID    uuid.UUID   `json:"item_id"`
+	Title     string      `json:"title"`
+	Content   string      `json:"content"`
+	Summary   string      `json:"summary"`
+	Category  string      `json:"category"`
+	Tags      []string    `json:"tags"`
+	Actor     string      `json:"actor"`
+	CreatedAt time.Time   `json:"created_at"`
+	Changes   []FieldDiff `json:"changes"` // Differences from the previous revision
+}
+
+// FieldDiff is a line diff of one field. Each line of Diff starts with "-" (removed),
+// "+" (added) or " " (unchanged); tags are diffed one tag per line.
+type FieldDiff struct {
+	Field string `json:"field"`
+	Diff  string `json:"diff"`
+}
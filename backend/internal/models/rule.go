@@ -0,0 +1,74 @@
+package models
+
+import (
+	"time"
+
+	"github.com/google/uuid"
+)
+
+// AI steps a rule can skip
+const (
+	StepEnrichment = "enrichment" // Categorization, tagging, entities, language and content kind
+	StepSummary    = "summary"
+)
+
+// Rule categorizes and tags items automatically. It is evaluated when an item is saved,
+// before the AI is called; every condition that is set must match.
+type Rule struct {
+	ID         uuid.UUID      `json:"id"`
+	Name       string         `json:"name"`
+	Enabled    bool           `json:"enabled"`
+	Priority   int            `json:"priority"` // Lower runs first; the first matching rule that sets a category wins
+	Conditions RuleConditions `json:"conditions"`
+	Actions    RuleActions    `json:"actions"`
+	CreatedAt  time.Time      `json:"created_at"`
+}
+
+type RuleConditions struct {
+	Domains        []string          `json:"domains,omitempty"`         // Source URL host or one of its parent domains
+	URLPattern     string            `json:"url_pattern,omitempty"`     // Regular expression on the source URL
+	Types          []string          `json:"types,omitempty"`           // Item types
+	TitlePattern   string            `json:"title_pattern,omitempty"`   // Regular expression on the title
+	ContentPattern string            `json:"content_pattern,omitempty"` // Regular expression on the content
+	Metadata       map[string]string `json:"metadata,omitempty"`        // Metadata field -> regular expression
+}
+
+type RuleActions struct {
+	Category   string     `json:"category,omitempty"`
+	Tags       []string   `json:"tags,omitempty"`
+	Collection *uuid.UUID `json:"collection,omitempty"` // Collection the item is added to
+	SkipAI     []string   `json:"skip_ai,omitempty"`    // StepEnrichment and/or StepSummary
+}
+
+type RuleRequest struct {
+	Name       string         `json:"name"`
+	Enabled    *bool          `json:"enabled"` // Defaults to true
+	Priority   int            `json:"priority"`
+	Conditions RuleConditions `json:"conditions"`
+	Actions    RuleActions    `json:"actions"`
+}
+
+// RuleMatch is the combined effect of the rules matching an item
+type RuleMatch struct {
+	Rules       []string    `json:"rules"` // Names of the matching rules
+	Category    string      `json:"category,omitempty"`
+	Tags        []string    `json:"tags"`
+	Collections []uuid.UUID `json:"collections"`
+	SkipAI      []string    `json:"skip_ai"`
+}
+
+// RuleDryRunItem is an item a rule would affect, with the changes it would make
+type RuleDryRunItem struct {
+	ID                uuid.UUID `json:"id"`
+	Title             string    `json:"title"`
+	Category          string    `json:"category"`
+	NewCategory       string    `json:"new_category,omitempty"` // Set when the rule would change the category
+	AddedTags         []string  `json:"added_tags"`
+	AddedToCollection bool      `json:"added_to_collection"` // The rule would add the item to its collection
+	CategoryLocked    bool      `json:"category_locked"`     // The category was set by the user or an import and won't change
+}
+
+type RuleDryRunResult struct {
+	Matched int              `json:"matched"`
+	Items   []RuleDryRunItem `json:"items"`
+}
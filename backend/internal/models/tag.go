@@ -0,0 +1,20 @@
+package models
+
+// Tag is a tag in use, returned by GET /api/tags
+type Tag struct {
+	Name     string   `json:"name"`
+	Count    int      `json:"count"`    // Number of items with the tag
+	Synonyms []string `json:"synonyms"` // Tags rewritten to this one on write and matched by #tag filters
+}
+
+type RenameTagRequest struct {
+	Name string `json:"name"`
+}
+
+type MergeTagRequest struct {
+	Into string `json:"into"`
+}
+
+type TagSynonymsRequest struct {
+	Synonyms []string `json:"synonyms"`
+}
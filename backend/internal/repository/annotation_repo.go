@@ -0,0 +1,118 @@
+package repository
+
+import (
+	"context"
+	"synapse/internal/models"
+
+	"github.com/google/uuid"
+	"github.com/jackc/pgx/v5"
+	"github.com/jackc/pgx/v5/pgxpool"
+)
+
+type AnnotationRepository struct {
+	pool *pgxpool.Pool
+}
+
+func NewAnnotationRepository(pool *pgxpool.Pool) *AnnotationRepository {
+	return &AnnotationRepository{pool: pool}
+}
+
+const annotationColumns = `a.id, a.item_id, a.quote, a.prefix, a.suffix, a.start_offset, a.end_offset, a.note, a.color, a.created_at, a.updated_at`
+
+func scanAnnotation(row pgx.Row, extra ...interface{}) (*models.Annotation, error) {
+	var annotation models.Annotation
+	dest := []interface{}{
+		&annotation.ID, &annotation.ItemID, &annotation.Quote, &annotation.Prefix, &annotation.Suffix,
+		&annotation.Start, &annotation.End, &annotation.Note, &annotation.Color, &annotation.CreatedAt, &annotation.UpdatedAt,
+	}
+	if err := row.Scan(append(dest, extra...)...); err != nil {
+		return nil, err
+	}
+	return &annotation, nil
+}
+
+// ListForItem returns an item's annotations in reading order: highlights by position,
+// then notes on the whole item
+func (r *AnnotationRepository) ListForItem(ctx context.Context, itemID uuid.UUID) ([]models.Annotation, error) {
+	rows, err := r.pool.Query(ctx, `
+		SELECT `+annotationColumns+`
+		FROM annotations a
+		WHERE a.item_id = $1
+		ORDER BY a.start_offset NULLS LAST, a.created_at
+	`, itemID)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	annotations := []models.Annotation{}
+	for rows.Next() {
+		annotation, err := scanAnnotation(rows)
+		if err != nil {
+			return nil, err
+		}
+		annotations = append(annotations, *annotation)
+	}
+	return annotations, rows.Err()
+}
+
+// Recent returns the latest annotations across items outside the trash, with their item's title
+func (r *AnnotationRepository) Recent(ctx context.Context, limit int) ([]models.Annotation, error) {
+	rows, err := r.pool.Query(ctx, `
+		SELECT `+annotationColumns+`, i.title
+		FROM annotations a JOIN items i ON i.id = a.item_id
+		WHERE i.deleted_at IS NULL
+		ORDER BY a.created_at DESC
+		LIMIT $1
+	`, limit)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	annotations := []models.Annotation{}
+	for rows.Next() {
+		var title string
+		annotation, err := scanAnnotation(rows, &title)
+		if err != nil {
+			return nil, err
+		}
+		annotation.ItemTitle = title
+		annotations = append(annotations, *annotation)
+	}
+	return annotations, rows.Err()
+}
+
+func (r *AnnotationRepository) GetByID(ctx context.Context, id uuid.UUID) (*models.Annotation, error) {
+	return scanAnnotation(r.pool.QueryRow(ctx, `SELECT `+annotationColumns+` FROM annotations a WHERE a.id = $1`, id))
+}
+
+func (r *AnnotationRepository) Create(ctx context.Context, annotation *models.Annotation) error {
+	query := `
+		INSERT INTO annotations (item_id, quote, prefix, suffix, start_offset, end_offset, note, color)
+		VALUES ($1, $2, $3, $4, $5, $6, $7, $8)
+		RETURNING id, created_at, updated_at
+	`
+	return r.pool.QueryRow(ctx, query,
+		annotation.ItemID, annotation.Quote, annotation.Prefix, annotation.Suffix,
+		annotation.Start, annotation.End, annotation.Note, annotation.Color,
+	).Scan(&annotation.ID, &annotation.CreatedAt, &annotation.UpdatedAt)
+}
+
+func (r *AnnotationRepository) Update(ctx context.Context, annotation *models.Annotation) error {
+	query := `
+		UPDATE annotations
+		SET quote = $1, prefix = $2, suffix = $3, start_offset = $4, end_offset = $5, note = $6, color = $7, updated_at = NOW()
+		WHERE id = $8
+		RETURNING updated_at
+	`
+	return r.pool.QueryRow(ctx, query,
+		annotation.Quote, annotation.Prefix, annotation.Suffix, annotation.Start, annotation.End,
+		annotation.Note, annotation.Color, annotation.ID,
+	).Scan(&annotation.UpdatedAt)
+}
+
+func (r *AnnotationRepository) Delete(ctx context.Context, id uuid.UUID) error {
+	_, err := r.pool.Exec(ctx, `DELETE FROM annotations WHERE id = $1`, id)
+	return err
+}
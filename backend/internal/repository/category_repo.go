@@ -0,0 +1,245 @@
+package repository
+
+import (
+	"context"
+	"strings"
+	"synapse/internal/models"
+
+	"github.com/google/uuid"
+	"github.com/jackc/pgx/v5"
+	"github.com/jackc/pgx/v5/pgtype"
+	"github.com/jackc/pgx/v5/pgxpool"
+)
+
+type CategoryRepository struct {
+	pool *pgxpool.Pool
+}
+
+func NewCategoryRepository(pool *pgxpool.Pool) *CategoryRepository {
+	return &CategoryRepository{pool: pool}
+}
+
+const categorySelect = `
+	SELECT c.id, c.name, c.description, c.aliases, c.icon, c.image_keyword, c.parent_id, c.created_at,
+		(SELECT COUNT(*) FROM items i WHERE i.category = c.name AND i.deleted_at IS NULL)
+	FROM categories c
+`
+
+func scanCategory(row pgx.Row) (*models.Category, error) {
+	var category models.Category
+	var aliases pgtype.Array[string]
+	err := row.Scan(
+		&category.ID, &category.Name, &category.Description, &aliases, &category.Icon,
+		&category.ImageKeyword, &category.ParentID, &category.CreatedAt, &category.ItemCount,
+	)
+	if err != nil {
+		return nil, err
+	}
+	category.Aliases = aliases.Elements
+	if category.Aliases == nil {
+		category.Aliases = []string{}
+	}
+	return &category, nil
+}
+
+func (r *CategoryRepository) List(ctx context.Context) ([]models.Category, error) {
+	rows, err := r.pool.Query(ctx, categorySelect+` ORDER BY c.name`)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	categories := []models.Category{}
+	for rows.Next() {
+		category, err := scanCategory(rows)
+		if err != nil {
+			return nil, err
+		}
+		categories = append(categories, *category)
+	}
+	return categories, rows.Err()
+}
+
+func (r *CategoryRepository) GetByID(ctx context.Context, id uuid.UUID) (*models.Category, error) {
+	return scanCategory(r.pool.QueryRow(ctx, categorySelect+` WHERE c.id = $1`, id))
+}
+
+func (r *CategoryRepository) Create(ctx context.Context, category *models.Category) error {
+	query := `
+		INSERT INTO categories (name, description, aliases, icon, image_keyword, parent_id)
+		VALUES ($1, $2, $3, $4, $5, $6)
+		RETURNING id, created_at
+	`
+	return r.pool.QueryRow(ctx, query,
+		category.Name, category.Description, category.Aliases, category.Icon, category.ImageKeyword, category.ParentID,
+	).Scan(&category.ID, &category.CreatedAt)
+}
+
+// Update saves category. If the name changed, items filed under the old name, rules
+// setting it and smart collections filtering on it are moved to the new one in the same
+// transaction.
+func (r *CategoryRepository) Update(ctx context.Context, oldName string, category *models.Category) error {
+	tx, err := r.pool.Begin(ctx)
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback(ctx)
+
+	_, err = tx.Exec(ctx, `
+		UPDATE categories
+		SET name = $1, description = $2, aliases = $3, icon = $4, image_keyword = $5, parent_id = $6
+		WHERE id = $7
+	`, category.Name, category.Description, category.Aliases, category.Icon, category.ImageKeyword, category.ParentID, category.ID)
+	if err != nil {
+		return err
+	}
+
+	if oldName != category.Name {
+		if _, err := tx.Exec(ctx, `UPDATE items SET category = $1 WHERE category = $2`, category.Name, oldName); err != nil {
+			return err
+		}
+		if err := recordRevisions(ctx, tx, models.SourceUser, `i.category = $2`, category.Name); err != nil {
+			return err
+		}
+		if err := renameRuleCategory(ctx, tx, oldName, category.Name); err != nil {
+			return err
+		}
+		if err := renameCollectionCategory(ctx, tx, oldName, category.Name); err != nil {
+			return err
+		}
+	}
+	return tx.Commit(ctx)
+}
+
+// Delete removes a category and returns the IDs of the items that were filed under it.
+// Their category is cleared and handed back to the AI so they can be recategorized.
+// A category that smart collections filter on is kept: Delete changes nothing and
+// returns the names of those collections instead.
+func (r *CategoryRepository) Delete(ctx context.Context, category *models.Category) (ids []uuid.UUID, usedBy []string, err error) {
+	tx, err := r.pool.Begin(ctx)
+	if err != nil {
+		return nil, nil, err
+	}
+	defer tx.Rollback(ctx)
+
+	rows, err := tx.Query(ctx, `SELECT name FROM collections WHERE filter->>'category' = $1 ORDER BY position, created_at FOR UPDATE`, category.Name)
+	if err != nil {
+		return nil, nil, err
+	}
+	usedBy, err = pgx.CollectRows(rows, pgx.RowTo[string])
+	if err != nil || len(usedBy) > 0 {
+		return nil, usedBy, err
+	}
+
+	rows, err = tx.Query(ctx, `UPDATE items SET category = NULL, category_source = 'ai' WHERE category = $1 RETURNING id`, category.Name)
+	if err != nil {
+		return nil, nil, err
+	}
+	ids, err = pgx.CollectRows(rows, pgx.RowTo[uuid.UUID])
+	if err != nil {
+		return nil, nil, err
+	}
+	if err := recordRevisions(ctx, tx, models.SourceUser, `i.id = ANY($2)`, ids); err != nil {
+		return nil, nil, err
+	}
+
+	if _, err := tx.Exec(ctx, `DELETE FROM categories WHERE id = $1`, category.ID); err != nil {
+		return nil, nil, err
+	}
+	return ids, nil, tx.Commit(ctx)
+}
+
+// Merge moves from's items, aliases and subcategories into into and deletes from.
+// from's name becomes an alias of into so searches for it keep working. If into is a
+// subcategory of from it takes from's place under from's parent. Rules and smart
+// collections that use from are pointed at into.
+func (r *CategoryRepository) Merge(ctx context.Context, from, into *models.Category) error {
+	tx, err := r.pool.Begin(ctx)
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback(ctx)
+
+	if _, err := tx.Exec(ctx, `UPDATE items SET category = $1 WHERE category = $2`, into.Name, from.Name); err != nil {
+		return err
+	}
+	if err := recordRevisions(ctx, tx, models.SourceUser, `i.category = $2`, into.Name); err != nil {
+		return err
+	}
+	if _, err := tx.Exec(ctx, `UPDATE categories SET parent_id = $1 WHERE parent_id = $2 AND id <> $1`, into.ID, from.ID); err != nil {
+		return err
+	}
+	if into.ParentID != nil && *into.ParentID == from.ID {
+		if _, err := tx.Exec(ctx, `UPDATE categories SET parent_id = $1 WHERE id = $2`, from.ParentID, into.ID); err != nil {
+			return err
+		}
+		into.ParentID = from.ParentID
+	}
+	if err := renameRuleCategory(ctx, tx, from.Name, into.Name); err != nil {
+		return err
+	}
+	if err := renameCollectionCategory(ctx, tx, from.Name, into.Name); err != nil {
+		return err
+	}
+	if _, err := tx.Exec(ctx, `DELETE FROM categories WHERE id = $1`, from.ID); err != nil {
+		return err
+	}
+
+	aliases := append([]string{}, into.Aliases...)
+	for _, alias := range append([]string{from.Name}, from.Aliases...) {
+		alias = normalizeAlias(alias)
+		if alias != "" && !containsAlias(aliases, alias) {
+			aliases = append(aliases, alias)
+		}
+	}
+	if _, err := tx.Exec(ctx, `UPDATE categories SET aliases = $1 WHERE id = $2`, aliases, into.ID); err != nil {
+		return err
+	}
+	into.Aliases = aliases
+	return tx.Commit(ctx)
+}
+
+// ItemIDsInCategories returns the items filed by the AI under one of names or under no
+// category at all
+func (r *CategoryRepository) ItemIDsInCategories(ctx context.Context, names []string) ([]uuid.UUID, error) {
+	rows, err := r.pool.Query(ctx, `
+		SELECT id FROM items
+		WHERE (category IS NULL OR category = '' OR category = ANY($1)) AND category_source = 'ai' AND deleted_at IS NULL
+	`, names)
+	if err != nil {
+		return nil, err
+	}
+	return pgx.CollectRows(rows, pgx.RowTo[uuid.UUID])
+}
+
+// renameRuleCategory points rules that set category from at category to
+func renameRuleCategory(ctx context.Context, tx pgx.Tx, from, to string) error {
+	_, err := tx.Exec(ctx, `
+		UPDATE rules SET actions = jsonb_set(actions, '{category}', to_jsonb($2::text))
+		WHERE actions->>'category' = $1
+	`, from, to)
+	return err
+}
+
+// renameCollectionCategory points smart collections that filter on category from at
+// category to
+func renameCollectionCategory(ctx context.Context, tx pgx.Tx, from, to string) error {
+	_, err := tx.Exec(ctx, `
+		UPDATE collections SET filter = jsonb_set(filter, '{category}', to_jsonb($2::text))
+		WHERE filter->>'category' = $1
+	`, from, to)
+	return err
+}
+
+func normalizeAlias(alias string) string {
+	return strings.ToLower(strings.TrimSpace(alias))
+}
+
+func containsAlias(aliases []string, alias string) bool {
+	for _, a := range aliases {
+		if a == alias {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,116 @@
+package repository
+
+import (
+	"context"
+	"synapse/internal/models"
+
+	"github.com/google/uuid"
+	"github.com/jackc/pgx/v5"
+	"github.com/jackc/pgx/v5/pgxpool"
+)
+
+type ChunkRepository struct {
+	pool *pgxpool.Pool
+}
+
+func NewChunkRepository(pool *pgxpool.Pool) *ChunkRepository {
+	return &ChunkRepository{pool: pool}
+}
+
+const chunkColumns = `c.id, c.item_id, c.position, c.page, c.content, c.embedding_id, c.created_at`
+
+func scanChunks(rows pgx.Rows) ([]models.ItemChunk, error) {
+	defer rows.Close()
+
+	chunks := []models.ItemChunk{}
+	for rows.Next() {
+		var chunk models.ItemChunk
+		err := rows.Scan(&chunk.ID, &chunk.ItemID, &chunk.Position, &chunk.Page, &chunk.Content, &chunk.EmbeddingID, &chunk.CreatedAt)
+		if err != nil {
+			return nil, err
+		}
+		chunks = append(chunks, chunk)
+	}
+	return chunks, rows.Err()
+}
+
+// Replace swaps an item's chunks for the given ones
+func (r *ChunkRepository) Replace(ctx context.Context, itemID uuid.UUID, chunks []models.ItemChunk) error {
+	tx, err := r.pool.Begin(ctx)
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback(ctx)
+
+	if _, err := tx.Exec(ctx, `DELETE FROM item_chunks WHERE item_id = $1`, itemID); err != nil {
+		return err
+	}
+	for _, chunk := range chunks {
+		_, err := tx.Exec(ctx, `
+			INSERT INTO item_chunks (item_id, position, page, content, embedding_id)
+			VALUES ($1, $2, $3, $4, $5)
+		`, itemID, chunk.Position, chunk.Page, chunk.Content, chunk.EmbeddingID)
+		if err != nil {
+			return err
+		}
+	}
+	return tx.Commit(ctx)
+}
+
+// ListForItem returns an item's chunks in order
+func (r *ChunkRepository) ListForItem(ctx context.Context, itemID uuid.UUID) ([]models.ItemChunk, error) {
+	rows, err := r.pool.Query(ctx, `
+		SELECT `+chunkColumns+`
+		FROM item_chunks c
+		WHERE c.item_id = $1
+		ORDER BY c.position
+	`, itemID)
+	if err != nil {
+		return nil, err
+	}
+	return scanChunks(rows)
+}
+
+// GetByEmbeddingIDs returns the chunks stored under the given vector ids
+func (r *ChunkRepository) GetByEmbeddingIDs(ctx context.Context, ids []string) ([]models.ItemChunk, error) {
+	if len(ids) == 0 {
+		return []models.ItemChunk{}, nil
+	}
+
+	rows, err := r.pool.Query(ctx, `
+		SELECT `+chunkColumns+`
+		FROM item_chunks c
+		WHERE c.embedding_id = ANY($1)
+	`, ids)
+	if err != nil {
+		return nil, err
+	}
+	return scanChunks(rows)
+}
+
+// FirstMatches returns, for each of the items that has chunks, its first chunk matching
+// any of the ILIKE patterns
+func (r *ChunkRepository) FirstMatches(ctx context.Context, itemIDs []uuid.UUID, patterns []string) (map[uuid.UUID]models.ItemChunk, error) {
+	matches := make(map[uuid.UUID]models.ItemChunk)
+	if len(itemIDs) == 0 || len(patterns) == 0 {
+		return matches, nil
+	}
+
+	rows, err := r.pool.Query(ctx, `
+		SELECT DISTINCT ON (c.item_id) `+chunkColumns+`
+		FROM item_chunks c
+		WHERE c.item_id = ANY($1) AND c.content ILIKE ANY($2)
+		ORDER BY c.item_id, c.position
+	`, itemIDs, patterns)
+	if err != nil {
+		return nil, err
+	}
+	chunks, err := scanChunks(rows)
+	if err != nil {
+		return nil, err
+	}
+	for _, chunk := range chunks {
+		matches[chunk.ItemID] = chunk
+	}
+	return matches, nil
+}
@@ -0,0 +1,189 @@
+package repository
+
+import (
+	"context"
+	"synapse/internal/models"
+
+	"github.com/google/uuid"
+	"github.com/jackc/pgx/v5"
+	"github.com/jackc/pgx/v5/pgxpool"
+)
+
+type CollectionRepository struct {
+	pool *pgxpool.Pool
+}
+
+func NewCollectionRepository(pool *pgxpool.Pool) *CollectionRepository {
+	return &CollectionRepository{pool: pool}
+}
+
+const collectionSelect = `
+	SELECT c.id, c.name, c.description, c.cover_image_url,
+		COALESCE(NULLIF(c.cover_image_url, ''), (
+			SELECT i.image_url FROM item_collections ic JOIN items i ON i.id = ic.item_id
+			WHERE ic.collection_id = c.id AND i.deleted_at IS NULL AND COALESCE(i.image_url, '') <> ''
+			ORDER BY ic.position, ic.added_at
+			LIMIT 1
+		), ''),
+		c.position, c.filter, (
+			SELECT COUNT(*) FROM item_collections ic JOIN items i ON i.id = ic.item_id
+			WHERE ic.collection_id = c.id AND i.deleted_at IS NULL
+		), c.created_at
+	FROM collections c
+`
+
+func scanCollection(row pgx.Row) (*models.Collection, error) {
+	var collection models.Collection
+	err := row.Scan(
+		&collection.ID, &collection.Name, &collection.Description, &collection.CoverImageURL,
+		&collection.Cover, &collection.Position, &collection.Filter, &collection.ItemCount, &collection.CreatedAt,
+	)
+	if err != nil {
+		return nil, err
+	}
+	return &collection, nil
+}
+
+func (r *CollectionRepository) List(ctx context.Context) ([]models.Collection, error) {
+	rows, err := r.pool.Query(ctx, collectionSelect+` ORDER BY c.position, c.name`)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	collections := []models.Collection{}
+	for rows.Next() {
+		collection, err := scanCollection(rows)
+		if err != nil {
+			return nil, err
+		}
+		collections = append(collections, *collection)
+	}
+	return collections, rows.Err()
+}
+
+func (r *CollectionRepository) GetByID(ctx context.Context, id uuid.UUID) (*models.Collection, error) {
+	return scanCollection(r.pool.QueryRow(ctx, collectionSelect+` WHERE c.id = $1`, id))
+}
+
+func (r *CollectionRepository) Create(ctx context.Context, collection *models.Collection) error {
+	query := `
+		INSERT INTO collections (name, description, cover_image_url, position, filter)
+		VALUES ($1, $2, $3, $4, $5)
+		RETURNING id, created_at
+	`
+	return r.pool.QueryRow(ctx, query,
+		collection.Name, collection.Description, collection.CoverImageURL, collection.Position, collection.Filter,
+	).Scan(&collection.ID, &collection.CreatedAt)
+}
+
+func (r *CollectionRepository) Update(ctx context.Context, collection *models.Collection) error {
+	query := `
+		UPDATE collections SET name = $1, description = $2, cover_image_url = $3, position = $4, filter = $5
+		WHERE id = $6
+	`
+	_, err := r.pool.Exec(ctx, query,
+		collection.Name, collection.Description, collection.CoverImageURL, collection.Position, collection.Filter, collection.ID,
+	)
+	return err
+}
+
+// Delete removes a collection (not its items) and drops it from the rules that add to it
+func (r *CollectionRepository) Delete(ctx context.Context, id uuid.UUID) error {
+	tx, err := r.pool.Begin(ctx)
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback(ctx)
+
+	if _, err := tx.Exec(ctx, `UPDATE rules SET actions = actions - 'collection' WHERE actions->>'collection' = $1`, id.String()); err != nil {
+		return err
+	}
+	if _, err := tx.Exec(ctx, `DELETE FROM collections WHERE id = $1`, id); err != nil {
+		return err
+	}
+	return tx.Commit(ctx)
+}
+
+// Items returns the items of a collection in order
+func (r *CollectionRepository) Items(ctx context.Context, id uuid.UUID) ([]models.Item, error) {
+	rows, err := r.pool.Query(ctx, `
+		SELECT `+itemColumns+`
+		FROM items JOIN item_collections ic ON ic.item_id = items.id
+		WHERE ic.collection_id = $1 AND items.deleted_at IS NULL
+		ORDER BY ic.position, ic.added_at
+	`, id)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	items := []models.Item{}
+	for rows.Next() {
+		item, err := scanItem(rows)
+		if err != nil {
+			return nil, err
+		}
+		items = append(items, *item)
+	}
+	return items, rows.Err()
+}
+
+// ItemIDs returns the IDs of the items in a collection
+func (r *CollectionRepository) ItemIDs(ctx context.Context, id uuid.UUID) ([]uuid.UUID, error) {
+	rows, err := r.pool.Query(ctx, `SELECT item_id FROM item_collections WHERE collection_id = $1 ORDER BY position, added_at`, id)
+	if err != nil {
+		return nil, err
+	}
+	return pgx.CollectRows(rows, pgx.RowTo[uuid.UUID])
+}
+
+// AddItems appends items to the end of a collection, skipping those already in it.
+// It returns the number of items added.
+func (r *CollectionRepository) AddItems(ctx context.Context, id uuid.UUID, itemIDs []uuid.UUID) (int64, error) {
+	tx, err := r.pool.Begin(ctx)
+	if err != nil {
+		return 0, err
+	}
+	defer tx.Rollback(ctx)
+
+	var added int64
+	for _, itemID := range itemIDs {
+		tag, err := tx.Exec(ctx, `
+			INSERT INTO item_collections (collection_id, item_id, position)
+			SELECT $1, $2, COALESCE(MAX(position) + 1, 0) FROM item_collections WHERE collection_id = $1
+			ON CONFLICT (collection_id, item_id) DO NOTHING
+		`, id, itemID)
+		if err != nil {
+			return 0, err
+		}
+		added += tag.RowsAffected()
+	}
+	return added, tx.Commit(ctx)
+}
+
+// RemoveItem takes an item out of a collection and reports whether it was in it
+func (r *CollectionRepository) RemoveItem(ctx context.Context, id, itemID uuid.UUID) (bool, error) {
+	tag, err := r.pool.Exec(ctx, `DELETE FROM item_collections WHERE collection_id = $1 AND item_id = $2`, id, itemID)
+	if err != nil {
+		return false, err
+	}
+	return tag.RowsAffected() > 0, nil
+}
+
+// SetOrder renumbers the items of a collection in the order of itemIDs
+func (r *CollectionRepository) SetOrder(ctx context.Context, id uuid.UUID, itemIDs []uuid.UUID) error {
+	tx, err := r.pool.Begin(ctx)
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback(ctx)
+
+	for position, itemID := range itemIDs {
+		_, err := tx.Exec(ctx, `UPDATE item_collections SET position = $3 WHERE collection_id = $1 AND item_id = $2`, id, itemID, position)
+		if err != nil {
+			return err
+		}
+	}
+	return tx.Commit(ctx)
+}
@@ -0,0 +1,146 @@
+package repository
+
+import (
+	"context"
+	"synapse/internal/models"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/jackc/pgx/v5/pgxpool"
+)
+
+type FileRepository struct {
+	pool *pgxpool.Pool
+}
+
+func NewFileRepository(pool *pgxpool.Pool) *FileRepository {
+	return &FileRepository{pool: pool}
+}
+
+// CreateBlob records a stored blob. Recording a hash again keeps the row but restarts
+// its created_at, so an orphaned blob stored anew gets the full grace period before
+// DeleteUnreferenced may remove it.
+func (r *FileRepository) CreateBlob(ctx context.Context, blob *models.Blob) error {
+	query := `
+		INSERT INTO blobs (hash, content_type, size)
+		VALUES ($1, $2, $3)
+		ON CONFLICT (hash) DO UPDATE SET created_at = NOW()
+	`
+	_, err := r.pool.Exec(ctx, query, blob.Hash, blob.ContentType, blob.Size)
+	return err
+}
+
+// GetBlob returns a blob's record, or pgx.ErrNoRows
+func (r *FileRepository) GetBlob(ctx context.Context, hash string) (*models.Blob, error) {
+	var blob models.Blob
+	err := r.pool.QueryRow(ctx, `SELECT hash, content_type, size, created_at FROM blobs WHERE hash = $1`, hash).
+		Scan(&blob.Hash, &blob.ContentType, &blob.Size, &blob.CreatedAt)
+	if err != nil {
+		return nil, err
+	}
+	return &blob, nil
+}
+
+// Attach sets the file an item has in a role, replacing any earlier one
+func (r *FileRepository) Attach(ctx context.Context, itemID uuid.UUID, role, hash, filename string) error {
+	query := `
+		INSERT INTO item_files (item_id, role, hash, filename)
+		VALUES ($1, $2, $3, $4)
+		ON CONFLICT (item_id, role) DO UPDATE SET hash = EXCLUDED.hash, filename = EXCLUDED.filename, created_at = NOW()
+	`
+	_, err := r.pool.Exec(ctx, query, itemID, role, hash, filename)
+	return err
+}
+
+// ListForItem returns the files attached to an item
+func (r *FileRepository) ListForItem(ctx context.Context, itemID uuid.UUID) ([]models.ItemFile, error) {
+	query := `
+		SELECT f.item_id, f.role, f.hash, f.filename, b.content_type, b.size, f.created_at
+		FROM item_files f
+		JOIN blobs b ON b.hash = f.hash
+		WHERE f.item_id = $1
+		ORDER BY f.role
+	`
+	rows, err := r.pool.Query(ctx, query, itemID)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	files := []models.ItemFile{}
+	for rows.Next() {
+		var file models.ItemFile
+		if err := rows.Scan(&file.ItemID, &file.Role, &file.Hash, &file.Filename, &file.ContentType, &file.Size, &file.CreatedAt); err != nil {
+			return nil, err
+		}
+		files = append(files, file)
+	}
+	return files, rows.Err()
+}
+
+// DeleteUnreferenced removes the records of blobs no item uses and that were stored
+// before cutoff, returning their hashes. The cutoff leaves blobs that are being
+// attached to a new item alone.
+func (r *FileRepository) DeleteUnreferenced(ctx context.Context, cutoff time.Time) ([]string, error) {
+	query := `
+		DELETE FROM blobs b
+		WHERE b.created_at < $1
+		  AND NOT EXISTS (SELECT 1 FROM item_files f WHERE f.hash = b.hash)
+		RETURNING b.hash
+	`
+	rows, err := r.pool.Query(ctx, query, cutoff)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	hashes := []string{}
+	for rows.Next() {
+		var hash string
+		if err := rows.Scan(&hash); err != nil {
+			return nil, err
+		}
+		hashes = append(hashes, hash)
+	}
+	return hashes, rows.Err()
+}
+
+// ListInlineImages returns up to limit items, including trashed ones, whose image_url
+// still holds a data URL, keyed by item id
+func (r *FileRepository) ListInlineImages(ctx context.Context, limit int) (map[uuid.UUID]string, error) {
+	rows, err := r.pool.Query(ctx, `SELECT id, image_url FROM items WHERE image_url LIKE 'data:%' LIMIT $1`, limit)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	images := map[uuid.UUID]string{}
+	for rows.Next() {
+		var id uuid.UUID
+		var imageURL string
+		if err := rows.Scan(&id, &imageURL); err != nil {
+			return nil, err
+		}
+		images[id] = imageURL
+	}
+	return images, rows.Err()
+}
+
+// ReplaceImage points an item's image at a stored file, in image_url and in any embed
+// that inlined the old image. It leaves the item alone if its image_url changed since
+// oldURL was read.
+func (r *FileRepository) ReplaceImage(ctx context.Context, itemID uuid.UUID, oldURL, newURL string) error {
+	query := `
+		UPDATE items
+		SET image_url = $3, embed_html = REPLACE(embed_html, $2, $3)
+		WHERE id = $1 AND image_url = $2
+	`
+	_, err := r.pool.Exec(ctx, query, itemID, oldURL, newURL)
+	return err
+}
+
+// DetachRoles removes an item's files whose role starts with prefix
+func (r *FileRepository) DetachRoles(ctx context.Context, itemID uuid.UUID, prefix string) error {
+	_, err := r.pool.Exec(ctx, `DELETE FROM item_files WHERE item_id = $1 AND starts_with(role, $2)`, itemID, prefix)
+	return err
+}
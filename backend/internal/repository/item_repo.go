@@ -6,12 +6,17 @@ import (
 	"fmt"
 	"strings"
 	"synapse/internal/models"
+	"time"
 
 	"github.com/google/uuid"
+	"github.com/jackc/pgx/v5"
 	"github.com/jackc/pgx/v5/pgxpool"
 	"github.com/jackc/pgx/v5/pgtype"
 )
 
+// itemColumns lists the items columns in the order scanItem reads them
+const itemColumns = `id, title, content, content_format, content_text, summary, source_url, type, category, tags, embedding_id, image_url, embed_html, image_info, ocr_text, image_caption, entities, language, content_kind, category_source, tag_sources, metadata, favorite, pinned, read_at, archived, created_at, deleted_at`
+
 type ItemRepository struct {
 	pool *pgxpool.Pool
 }
@@ -20,63 +25,57 @@ func NewItemRepository(pool *pgxpool.Pool) *ItemRepository {
 	return &ItemRepository{pool: pool}
 }
 
+// Create saves a new item and records its first revision
 func (r *ItemRepository) Create(ctx context.Context, item *models.Item) error {
+	tx, err := r.pool.Begin(ctx)
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback(ctx)
+
 	query := `
-		INSERT INTO items (id, title, content, summary, source_url, type, category, tags, embedding_id, image_url, embed_html, ocr_text, created_at)
-		VALUES ($1, $2, $3, $4, $5, $6, $7, $8, $9, $10, $11, $12, $13)
+		INSERT INTO items (id, title, content, content_format, content_text, summary, source_url, type, category, tags, embedding_id, image_url, embed_html, ocr_text, image_caption, entities, language, content_kind, category_source, tag_sources, metadata, created_at)
+		VALUES ($1, $2, $3, $4, $5, $6, $7, $8, $9, $10, $11, $12, $13, $14, $15, $16, $17, $18, $19, $20, $21, $22)
 	`
 	
 	tagsArray := pgtype.Array[string]{
 		Elements: item.Tags,
 		Valid:    true,
 	}
+	entitiesArray := pgtype.Array[string]{
+		Elements: item.Entities,
+		Valid:    true,
+	}
 	
-	_, err := r.pool.Exec(ctx, query,
-		item.ID, item.Title, item.Content, item.Summary, item.SourceURL,
-		item.Type, item.Category, tagsArray, item.EmbeddingID, item.ImageURL, item.EmbedHTML, item.OcrText, item.CreatedAt,
+	_, err = tx.Exec(ctx, query,
+		item.ID, item.Title, item.Content, contentFormat(item), contentText(item), item.Summary, item.SourceURL,
+		item.Type, item.Category, tagsArray, item.EmbeddingID, item.ImageURL, item.EmbedHTML, item.OcrText, item.ImageCaption,
+		entitiesArray, item.Language, item.ContentKind, categorySource(item), tagSources(item), itemMetadata(item), item.CreatedAt,
 	)
-	return err
+	if err != nil {
+		return err
+	}
+	if err := recordRevisions(ctx, tx, models.SourceUser, `i.id = $2`, item.ID); err != nil {
+		return err
+	}
+	return tx.Commit(ctx)
 }
 
 func (r *ItemRepository) GetByID(ctx context.Context, id uuid.UUID) (*models.Item, error) {
 	query := `
-		SELECT id, title, content, summary, source_url, type, category, tags, embedding_id, image_url, embed_html, ocr_text, created_at
+		SELECT ` + itemColumns + `
 		FROM items
-		WHERE id = $1
+		WHERE id = $1 AND deleted_at IS NULL
 	`
 	
-	var item models.Item
-	var tagsArray pgtype.Array[string]
-	var imageURL, embedHTML, category, ocrText sql.NullString
-	
-	err := r.pool.QueryRow(ctx, query, id).Scan(
-		&item.ID, &item.Title, &item.Content, &item.Summary, &item.SourceURL,
-		&item.Type, &category, &tagsArray, &item.EmbeddingID, &imageURL, &embedHTML, &ocrText, &item.CreatedAt,
-	)
-	if err != nil {
-		return nil, err
-	}
-	
-	item.Tags = tagsArray.Elements
-	if category.Valid {
-		item.Category = category.String
-	}
-	if imageURL.Valid {
-		item.ImageURL = imageURL.String
-	}
-	if embedHTML.Valid {
-		item.EmbedHTML = embedHTML.String
-	}
-	if ocrText.Valid {
-		item.OcrText = ocrText.String
-	}
-	return &item, nil
+	return scanItem(r.pool.QueryRow(ctx, query, id))
 }
 
 func (r *ItemRepository) GetAll(ctx context.Context) ([]models.Item, error) {
 	query := `
-		SELECT id, title, content, summary, source_url, type, category, tags, embedding_id, image_url, embed_html, ocr_text, created_at
+		SELECT ` + itemColumns + `
 		FROM items
+		WHERE deleted_at IS NULL
 		ORDER BY created_at DESC
 	`
 	
@@ -88,46 +87,69 @@ func (r *ItemRepository) GetAll(ctx context.Context) ([]models.Item, error) {
 	
 	items := []models.Item{}
 	for rows.Next() {
-		var item models.Item
-		var tagsArray pgtype.Array[string]
-		var imageURL, embedHTML, category, ocrText sql.NullString
-		
-		err := rows.Scan(
-			&item.ID, &item.Title, &item.Content, &item.Summary, &item.SourceURL,
-			&item.Type, &category, &tagsArray, &item.EmbeddingID, &imageURL, &embedHTML, &ocrText, &item.CreatedAt,
-		)
+		item, err := scanItem(rows)
 		if err != nil {
 			return []models.Item{}, err
 		}
-		
-		item.Tags = tagsArray.Elements
-		if category.Valid {
-			item.Category = category.String
-		}
-		if imageURL.Valid {
-			item.ImageURL = imageURL.String
-		}
-		if embedHTML.Valid {
-			item.EmbedHTML = embedHTML.String
-		}
-		if ocrText.Valid {
-			item.OcrText = ocrText.String
-		}
-		items = append(items, item)
+		items = append(items, *item)
 	}
 	
 	return items, nil
 }
 
+// List returns the items outside the trash matching state, pinned items first and then
+// newest first
+func (r *ItemRepository) List(ctx context.Context, state models.ItemStateFilter) ([]models.Item, error) {
+	rows, err := r.pool.Query(ctx, `
+		SELECT `+itemColumns+`
+		FROM items
+		WHERE deleted_at IS NULL`+stateConditions(state)+`
+		ORDER BY pinned DESC, created_at DESC
+	`)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	items := []models.Item{}
+	for rows.Next() {
+		item, err := scanItem(rows)
+		if err != nil {
+			return nil, err
+		}
+		items = append(items, *item)
+	}
+	return items, rows.Err()
+}
+
+// UpdateState sets the flags given in req (favorite, pinned, read, archived) and leaves
+// the others unchanged. Marking an item read keeps an earlier read_at. It returns
+// pgx.ErrNoRows if there is no such item outside the trash.
+func (r *ItemRepository) UpdateState(ctx context.Context, id uuid.UUID, req *models.UpdateItemRequest) error {
+	query := `
+		UPDATE items
+		SET favorite = COALESCE($1, favorite),
+			pinned = COALESCE($2, pinned),
+			read_at = CASE WHEN $3::boolean IS NULL THEN read_at WHEN $3 THEN COALESCE(read_at, NOW()) END,
+			archived = COALESCE($4, archived)
+		WHERE id = $5 AND deleted_at IS NULL
+	`
+	tag, err := r.pool.Exec(ctx, query, req.Favorite, req.Pinned, req.Read, req.Archived, id)
+	if err == nil && tag.RowsAffected() == 0 {
+		return pgx.ErrNoRows
+	}
+	return err
+}
+
 func (r *ItemRepository) GetByIDs(ctx context.Context, ids []uuid.UUID) ([]models.Item, error) {
 	if len(ids) == 0 {
 		return []models.Item{}, nil
 	}
 	
 	query := `
-		SELECT id, title, content, summary, source_url, type, category, tags, embedding_id, image_url, embed_html, ocr_text, created_at
+		SELECT ` + itemColumns + `
 		FROM items
-		WHERE id = ANY($1)
+		WHERE id = ANY($1) AND deleted_at IS NULL
 	`
 	
 	rows, err := r.pool.Query(ctx, query, ids)
@@ -138,48 +160,98 @@ func (r *ItemRepository) GetByIDs(ctx context.Context, ids []uuid.UUID) ([]model
 	
 	var items []models.Item
 	for rows.Next() {
-		var item models.Item
-		var tagsArray pgtype.Array[string]
-		var imageURL, embedHTML, category, ocrText sql.NullString
-		
-		err := rows.Scan(
-			&item.ID, &item.Title, &item.Content, &item.Summary, &item.SourceURL,
-			&item.Type, &category, &tagsArray, &item.EmbeddingID, &imageURL, &embedHTML, &ocrText, &item.CreatedAt,
-		)
+		item, err := scanItem(rows)
 		if err != nil {
 			return nil, err
 		}
-		
-		item.Tags = tagsArray.Elements
-		if category.Valid {
-			item.Category = category.String
-		}
-		if imageURL.Valid {
-			item.ImageURL = imageURL.String
-		}
-		if embedHTML.Valid {
-			item.EmbedHTML = embedHTML.String
-		}
-		if ocrText.Valid {
-			item.OcrText = ocrText.String
-		}
-		items = append(items, item)
+		items = append(items, *item)
 	}
 	
 	return items, nil
 }
 
+// Delete moves an item to the trash. It returns pgx.ErrNoRows if there is no such item
+// outside the trash.
 func (r *ItemRepository) Delete(ctx context.Context, id uuid.UUID) error {
-	query := `DELETE FROM items WHERE id = $1`
-	_, err := r.pool.Exec(ctx, query, id)
+	query := `UPDATE items SET deleted_at = NOW() WHERE id = $1 AND deleted_at IS NULL`
+	tag, err := r.pool.Exec(ctx, query, id)
+	if err == nil && tag.RowsAffected() == 0 {
+		return pgx.ErrNoRows
+	}
+	return err
+}
+
+// GetTrash returns the items in the trash, most recently deleted first
+func (r *ItemRepository) GetTrash(ctx context.Context) ([]models.Item, error) {
+	rows, err := r.pool.Query(ctx, `
+		SELECT `+itemColumns+`
+		FROM items
+		WHERE deleted_at IS NOT NULL
+		ORDER BY deleted_at DESC
+	`)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	items := []models.Item{}
+	for rows.Next() {
+		item, err := scanItem(rows)
+		if err != nil {
+			return nil, err
+		}
+		items = append(items, *item)
+	}
+	return items, rows.Err()
+}
+
+// Restore takes an item out of the trash. It returns pgx.ErrNoRows if the item isn't in it.
+func (r *ItemRepository) Restore(ctx context.Context, id uuid.UUID) error {
+	tag, err := r.pool.Exec(ctx, `UPDATE items SET deleted_at = NULL WHERE id = $1 AND deleted_at IS NOT NULL`, id)
+	if err == nil && tag.RowsAffected() == 0 {
+		return pgx.ErrNoRows
+	}
 	return err
 }
 
+// PurgeItem permanently deletes a trashed item. It returns the purged items (none if id
+// isn't in the trash) so their vectors and files can be removed too.
+func (r *ItemRepository) PurgeItem(ctx context.Context, id uuid.UUID) ([]models.Item, error) {
+	return r.purge(ctx, `id = $1`, id)
+}
+
+// PurgeDeletedBefore permanently deletes the items trashed before cutoff
+func (r *ItemRepository) PurgeDeletedBefore(ctx context.Context, cutoff time.Time) ([]models.Item, error) {
+	return r.purge(ctx, `deleted_at < $1`, cutoff)
+}
+
+// purge deletes the trashed items matching condition. Relations and collection
+// memberships cascade.
+func (r *ItemRepository) purge(ctx context.Context, condition string, args ...interface{}) ([]models.Item, error) {
+	rows, err := r.pool.Query(ctx, `
+		DELETE FROM items
+		WHERE deleted_at IS NOT NULL AND `+condition+`
+		RETURNING `+itemColumns, args...)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	items := []models.Item{}
+	for rows.Next() {
+		item, err := scanItem(rows)
+		if err != nil {
+			return nil, err
+		}
+		items = append(items, *item)
+	}
+	return items, rows.Err()
+}
+
 // UpdateSummary updates the summary field of an item (for async summarization)
 func (r *ItemRepository) UpdateSummary(ctx context.Context, id uuid.UUID, summary string) error {
 	query := `UPDATE items SET summary = $1 WHERE id = $2`
-	_, err := r.pool.Exec(ctx, query, summary, id)
-	return err
+	return r.update(ctx, id, models.SourceAI, query, summary, id)
 }
 
 // UpdateImageURL updates the image_url field of an item
@@ -189,31 +261,196 @@ func (r *ItemRepository) UpdateImageURL(ctx context.Context, id uuid.UUID, image
 	return err
 }
 
-// UpdateOCRText updates the ocr_text field of an item
-func (r *ItemRepository) UpdateOCRText(ctx context.Context, id uuid.UUID, ocrText string) error {
-	query := `UPDATE items SET ocr_text = $1 WHERE id = $2`
-	_, err := r.pool.Exec(ctx, query, ocrText, id)
+// UpdateImageInfo saves the processed form of an item's image. It does nothing if the
+// item's image_url is no longer the one info was made from.
+func (r *ItemRepository) UpdateImageInfo(ctx context.Context, id uuid.UUID, info *models.ImageInfo) error {
+	query := `UPDATE items SET image_info = $1 WHERE id = $2 AND image_url = $3`
+	_, err := r.pool.Exec(ctx, query, info, id, info.Source)
+	return err
+}
+
+// UpdateImageCaption saves the vision model description of an item's image
+func (r *ItemRepository) UpdateImageCaption(ctx context.Context, id uuid.UUID, caption string) error {
+	_, err := r.pool.Exec(ctx, `UPDATE items SET image_caption = $1 WHERE id = $2`, caption, id)
 	return err
 }
 
-// SearchItems performs text search with filters (includes OCR text)
+// ListIDsByImageURL returns the ids of the items whose image_url matches a LIKE pattern
+func (r *ItemRepository) ListIDsByImageURL(ctx context.Context, pattern string) ([]uuid.UUID, error) {
+	rows, err := r.pool.Query(ctx, `SELECT id FROM items WHERE deleted_at IS NULL AND image_url LIKE $1`, pattern)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	ids := []uuid.UUID{}
+	for rows.Next() {
+		var id uuid.UUID
+		if err := rows.Scan(&id); err != nil {
+			return nil, err
+		}
+		ids = append(ids, id)
+	}
+	return ids, rows.Err()
+}
+
+// ListUnprocessedImages returns up to limit items whose image hasn't been processed
+// since it was set, keyed by item id with their image_url
+func (r *ItemRepository) ListUnprocessedImages(ctx context.Context, limit int) (map[uuid.UUID]string, error) {
+	query := `
+		SELECT id, image_url FROM items
+		WHERE deleted_at IS NULL AND COALESCE(image_url, '') <> ''
+		  AND image_url IS DISTINCT FROM image_info->>'source'
+		ORDER BY created_at DESC
+		LIMIT $1
+	`
+	rows, err := r.pool.Query(ctx, query, limit)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	images := map[uuid.UUID]string{}
+	for rows.Next() {
+		var id uuid.UUID
+		var imageURL string
+		if err := rows.Scan(&id, &imageURL); err != nil {
+			return nil, err
+		}
+		images[id] = imageURL
+	}
+	return images, rows.Err()
+}
+
+// UpdateCategory sets an AI-chosen category. Items whose category was set by the user,
+// a rule or an import are left unchanged.
+func (r *ItemRepository) UpdateCategory(ctx context.Context, id uuid.UUID, category string) error {
+	query := `UPDATE items SET category = $1 WHERE id = $2 AND category_source = 'ai'`
+	return r.update(ctx, id, models.SourceAI, query, category, id)
+}
+
+// UpdateClassification saves the category, tags, entities, language and content kind of
+// an item together with their sources. A change to the category or tags is recorded as
+// a revision by actor.
+func (r *ItemRepository) UpdateClassification(ctx context.Context, item *models.Item, actor string) error {
+	query := `
+		UPDATE items
+		SET category = $1, category_source = $2, tags = $3, tag_sources = $4, entities = $5, language = $6, content_kind = $7
+		WHERE id = $8
+	`
+	return r.update(ctx, item.ID, actor, query,
+		item.Category, categorySource(item), item.Tags, tagSources(item), item.Entities, item.Language, item.ContentKind, item.ID,
+	)
+}
+
+// Revert sets an item's title, content, summary, category and tags back to a revision.
+// A restored category or tag the item doesn't have now counts as set by the user; ones
+// it still has keep their source. contentText is the plain-text projection of the
+// restored content. It returns pgx.ErrNoRows if the item doesn't exist or is in the trash.
+func (r *ItemRepository) Revert(ctx context.Context, revision *models.ItemRevision, contentText string) error {
+	tx, err := r.pool.Begin(ctx)
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback(ctx)
+
+	tag, err := tx.Exec(ctx, `
+		UPDATE items
+		SET title = $1, content = $2, content_text = $3, summary = $4, category = NULLIF($5, ''),
+			category_source = CASE WHEN $5 = '' THEN 'ai' WHEN category = $5 THEN category_source ELSE 'user' END,
+			tags = $6,
+			tag_sources = COALESCE((
+				SELECT jsonb_object_agg(t, COALESCE(items.tag_sources -> t, to_jsonb('user'::text)))
+				FROM unnest($6::text[]) AS t
+				WHERE NOT t = ANY(items.tags) OR items.tag_sources ? t
+			), '{}')
+		WHERE id = $7 AND deleted_at IS NULL
+	`, revision.Title, revision.Content, contentText, revision.Summary, revision.Category, revision.Tags, revision.ItemID)
+	if err != nil {
+		return err
+	}
+	if tag.RowsAffected() == 0 {
+		return pgx.ErrNoRows
+	}
+	if err := recordRevisions(ctx, tx, models.SourceUser, `i.id = $2`, revision.ItemID); err != nil {
+		return err
+	}
+	return tx.Commit(ctx)
+}
+
+// update runs a single-item update and records the change as a revision by actor
+func (r *ItemRepository) update(ctx context.Context, id uuid.UUID, actor, query string, args ...interface{}) error {
+	tx, err := r.pool.Begin(ctx)
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback(ctx)
+
+	if _, err := tx.Exec(ctx, query, args...); err != nil {
+		return err
+	}
+	if err := recordRevisions(ctx, tx, actor, `i.id = $2`, id); err != nil {
+		return err
+	}
+	return tx.Commit(ctx)
+}
+
+// annotationMatch matches items with a highlight or note containing the pattern given
+// twice as its arguments
+const annotationMatch = `EXISTS (SELECT 1 FROM annotations a WHERE a.item_id = items.id AND (a.quote ILIKE $%d OR a.note ILIKE $%d))`
+
+// SearchItems performs text search with filters (includes OCR text and annotations)
 func (r *ItemRepository) SearchItems(ctx context.Context, filters *models.QueryFilters, limit int) ([]models.Item, error) {
+	conditions, args := searchConditions(filters)
 	query := `
-		SELECT id, title, content, summary, source_url, type, category, tags, embedding_id, image_url, embed_html, ocr_text, created_at
+		SELECT ` + itemColumns + `
 		FROM items
-		WHERE 1=1
-	`
+		WHERE deleted_at IS NULL
+	` + conditions
+	query += ` ORDER BY created_at DESC LIMIT $` + fmt.Sprintf("%d", len(args)+1)
+	args = append(args, limit)
+
+	rows, err := r.pool.Query(ctx, query, args...)
+	if err != nil {
+		return []models.Item{}, err
+	}
+	defer rows.Close()
+
+	items := []models.Item{}
+	for rows.Next() {
+		item, err := scanItem(rows)
+		if err != nil {
+			return []models.Item{}, err
+		}
+		items = append(items, *item)
+	}
+
+	return items, nil
+}
+
+// CountItems returns how many items SearchItems would find without a limit
+func (r *ItemRepository) CountItems(ctx context.Context, filters *models.QueryFilters) (int, error) {
+	conditions, args := searchConditions(filters)
+	var count int
+	err := r.pool.QueryRow(ctx, `SELECT COUNT(*) FROM items WHERE deleted_at IS NULL`+conditions, args...).Scan(&count)
+	return count, err
+}
+
+// searchConditions builds the AND conditions of SearchItems and CountItems with their
+// arguments, numbered from $1
+func searchConditions(filters *models.QueryFilters) (string, []interface{}) {
+	query := ""
 	args := []interface{}{}
 	argIndex := 1
 
-	// Text search (includes OCR text for images/screenshots)
+	// Text search (includes OCR text and captions of images/screenshots)
 	// Enhanced to handle multiple terms from Claude query expansion
 	if filters.SearchTerms != "" {
 		// Split enhanced query into individual terms for better matching
 		terms := strings.Fields(filters.SearchTerms)
 		
-		// Build OR conditions for each term - matches if ANY term is found
-		// This allows finding content even when exact phrase doesn't match
+		// Build OR conditions for each term - matches if ANY term is found (every term
+		// with AllTerms). This allows finding content even when exact phrase doesn't match
 		var conditions []string
 		for _, term := range terms {
 			if len(term) < 2 { // Skip very short terms
@@ -222,23 +459,29 @@ func (r *ItemRepository) SearchItems(ctx context.Context, filters *models.QueryF
 			termPattern := "%" + term + "%"
 			conditions = append(conditions, fmt.Sprintf(`(
 				title ILIKE $%d OR 
-				content ILIKE $%d OR 
+				content_text ILIKE $%d OR 
 				summary ILIKE $%d OR
-				ocr_text ILIKE $%d
-			)`, argIndex, argIndex, argIndex, argIndex))
+				ocr_text ILIKE $%d OR
+				image_caption ILIKE $%d OR
+				`+annotationMatch+`
+			)`, argIndex, argIndex, argIndex, argIndex, argIndex, argIndex, argIndex))
 			args = append(args, termPattern)
 			argIndex++
 		}
 		
-		if len(conditions) > 0 {
+		if len(conditions) > 0 && filters.AllTerms {
+			query += " AND " + strings.Join(conditions, " AND ")
+		} else if len(conditions) > 0 {
 			// Also try exact phrase match for better relevance
 			exactPattern := "%" + filters.SearchTerms + "%"
 			conditions = append(conditions, fmt.Sprintf(`(
 				title ILIKE $%d OR 
-				content ILIKE $%d OR 
+				content_text ILIKE $%d OR 
 				summary ILIKE $%d OR
-				ocr_text ILIKE $%d
-			)`, argIndex, argIndex, argIndex, argIndex))
+				ocr_text ILIKE $%d OR
+				image_caption ILIKE $%d OR
+				`+annotationMatch+`
+			)`, argIndex, argIndex, argIndex, argIndex, argIndex, argIndex, argIndex))
 			args = append(args, exactPattern)
 			argIndex++
 			
@@ -254,7 +497,7 @@ func (r *ItemRepository) SearchItems(ctx context.Context, filters *models.QueryF
 		// For now, if type is set and search terms exist, we'll search in that type OR in content
 		// This is a bit complex, so let's make type filter optional when search terms exist
 	}
-	if filters.Type != "" && filters.SearchTerms == "" {
+	if filters.Type != "" && (filters.SearchTerms == "" || filters.StrictType) {
 		// Only apply type filter if no search terms (pure type filter)
 		query += fmt.Sprintf(` AND type = $%d`, argIndex)
 		args = append(args, filters.Type)
@@ -282,7 +525,7 @@ func (r *ItemRepository) SearchItems(ctx context.Context, filters *models.QueryF
 
 	// Author filter (search in content)
 	if filters.Author != "" {
-		query += fmt.Sprintf(` AND (content ILIKE $%d OR title ILIKE $%d)`, argIndex, argIndex)
+		query += fmt.Sprintf(` AND (content_text ILIKE $%d OR title ILIKE $%d)`, argIndex, argIndex)
 		authorPattern := "%" + filters.Author + "%"
 		args = append(args, authorPattern)
 		argIndex++
@@ -295,44 +538,114 @@ func (r *ItemRepository) SearchItems(ctx context.Context, filters *models.QueryF
 		argIndex++
 	}
 
-	query += ` ORDER BY created_at DESC LIMIT $` + fmt.Sprintf("%d", argIndex)
-	args = append(args, limit)
+	query += stateConditions(filters.ItemStateFilter)
 
-	rows, err := r.pool.Query(ctx, query, args...)
+	// Collection scope
+	if filters.ItemIDs != nil {
+		query += fmt.Sprintf(` AND id = ANY($%d)`, argIndex)
+		args = append(args, filters.ItemIDs)
+		argIndex++
+	}
+
+	return query, args
+}
+
+// scanItem reads one row selected with itemColumns
+func scanItem(row pgx.Row) (*models.Item, error) {
+	var item models.Item
+	var tagsArray, entitiesArray pgtype.Array[string]
+	var imageURL, embedHTML, category, ocrText, imageCaption, language, contentKind sql.NullString
+	var sources map[string]string
+
+	err := row.Scan(
+		&item.ID, &item.Title, &item.Content, &item.ContentFormat, &item.ContentText, &item.Summary, &item.SourceURL,
+		&item.Type, &category, &tagsArray, &item.EmbeddingID, &imageURL, &embedHTML, &item.Image, &ocrText, &imageCaption,
+		&entitiesArray, &language, &contentKind, &item.CategorySource, &sources, &item.Metadata,
+		&item.Favorite, &item.Pinned, &item.ReadAt, &item.Archived, &item.CreatedAt, &item.DeletedAt,
+	)
 	if err != nil {
-		return []models.Item{}, err
+		return nil, err
 	}
-	defer rows.Close()
 
-	items := []models.Item{}
-	for rows.Next() {
-		var item models.Item
-		var tagsArray pgtype.Array[string]
-		var imageURL, embedHTML, category, ocrText sql.NullString
-
-		err := rows.Scan(
-			&item.ID, &item.Title, &item.Content, &item.Summary, &item.SourceURL,
-			&item.Type, &category, &tagsArray, &item.EmbeddingID, &imageURL, &embedHTML, &ocrText, &item.CreatedAt,
-		)
-		if err != nil {
-			return []models.Item{}, err
-		}
+	item.Tags = tagsArray.Elements
+	item.Entities = entitiesArray.Elements
+	item.Category = category.String
+	item.ImageURL = imageURL.String
+	item.EmbedHTML = embedHTML.String
+	item.OcrText = ocrText.String
+	item.ImageCaption = imageCaption.String
+	item.Language = language.String
+	item.ContentKind = contentKind.String
 
-		item.Tags = tagsArray.Elements
-		if category.Valid {
-			item.Category = category.String
+	// Tags without a recorded source were set by the AI
+	item.TagSources = make(map[string]string, len(item.Tags))
+	for _, tag := range item.Tags {
+		item.TagSources[tag] = models.SourceAI
+		if source, ok := sources[tag]; ok {
+			item.TagSources[tag] = source
 		}
-		if imageURL.Valid {
-			item.ImageURL = imageURL.String
-		}
-		if embedHTML.Valid {
-			item.EmbedHTML = embedHTML.String
+	}
+	return &item, nil
+}
+
+// stateConditions returns the SQL conditions, each starting with AND, for the flags set
+// in state
+func stateConditions(state models.ItemStateFilter) string {
+	conditions := ""
+	flag := func(value *bool, column string) {
+		if value == nil {
+			return
 		}
-		if ocrText.Valid {
-			item.OcrText = ocrText.String
+		if *value {
+			conditions += " AND " + column
+		} else {
+			conditions += " AND NOT " + column
 		}
-		items = append(items, item)
 	}
+	flag(state.Favorite, "favorite")
+	flag(state.Pinned, "pinned")
+	flag(state.Read, "read_at IS NOT NULL")
+	flag(state.Archived, "archived")
+	return conditions
+}
 
-	return items, nil
+func itemMetadata(item *models.Item) map[string]string {
+	if item.Metadata == nil {
+		return map[string]string{}
+	}
+	return item.Metadata
+}
+
+func contentFormat(item *models.Item) string {
+	if item.ContentFormat == "" {
+		return models.FormatText
+	}
+	return item.ContentFormat
+}
+
+// contentText returns the plain-text projection to store, which for text content is
+// the content itself
+func contentText(item *models.Item) string {
+	if item.ContentText == "" {
+		return item.Content
+	}
+	return item.ContentText
+}
+
+func categorySource(item *models.Item) string {
+	if item.CategorySource == "" {
+		return models.SourceAI
+	}
+	return item.CategorySource
+}
+
+// tagSources returns the sources of the item's current tags, for storage
+func tagSources(item *models.Item) map[string]string {
+	sources := make(map[string]string, len(item.Tags))
+	for _, tag := range item.Tags {
+		if source, ok := item.TagSources[tag]; ok {
+			sources[tag] = source
+		}
+	}
+	return sources
 }
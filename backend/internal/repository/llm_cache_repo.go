@@ -0,0 +1,106 @@
+package repository
+
+import (
+	"context"
+	"errors"
+	"synapse/internal/models"
+	"time"
+
+	"github.com/jackc/pgx/v5"
+	"github.com/jackc/pgx/v5/pgxpool"
+)
+
+type LLMCacheRepository struct {
+	pool *pgxpool.Pool
+}
+
+func NewLLMCacheRepository(pool *pgxpool.Pool) *LLMCacheRepository {
+	return &LLMCacheRepository{pool: pool}
+}
+
+// Get returns the cached response for key if it exists and has not expired.
+// A hit bumps the entry's hit counter and last_hit_at so size trimming keeps hot entries.
+func (r *LLMCacheRepository) Get(ctx context.Context, key string) (string, bool, error) {
+	query := `
+		UPDATE llm_cache
+		SET hits = hits + 1, last_hit_at = NOW()
+		WHERE key = $1 AND expires_at > NOW()
+		RETURNING response
+	`
+
+	var response string
+	err := r.pool.QueryRow(ctx, query, key).Scan(&response)
+	if errors.Is(err, pgx.ErrNoRows) {
+		return "", false, nil
+	}
+	if err != nil {
+		return "", false, err
+	}
+	return response, true, nil
+}
+
+// Put stores (or replaces) a cached response
+func (r *LLMCacheRepository) Put(ctx context.Context, key, provider, model, kind, response string, ttl time.Duration) error {
+	query := `
+		INSERT INTO llm_cache (key, provider, model, kind, response, created_at, last_hit_at, expires_at)
+		VALUES ($1, $2, $3, $4, $5, NOW(), NOW(), $6)
+		ON CONFLICT (key)
+		DO UPDATE SET response = $5, created_at = NOW(), last_hit_at = NOW(), expires_at = $6
+	`
+	_, err := r.pool.Exec(ctx, query, key, provider, model, kind, response, time.Now().Add(ttl))
+	return err
+}
+
+// Prune deletes expired entries and then the least recently used entries above maxEntries
+func (r *LLMCacheRepository) Prune(ctx context.Context, maxEntries int) error {
+	if _, err := r.pool.Exec(ctx, `DELETE FROM llm_cache WHERE expires_at <= NOW()`); err != nil {
+		return err
+	}
+	if maxEntries <= 0 {
+		return nil
+	}
+
+	query := `
+		DELETE FROM llm_cache
+		WHERE key IN (
+			SELECT key FROM llm_cache
+			ORDER BY last_hit_at DESC
+			OFFSET $1
+		)
+	`
+	_, err := r.pool.Exec(ctx, query, maxEntries)
+	return err
+}
+
+// Clear removes every cached entry
+func (r *LLMCacheRepository) Clear(ctx context.Context) error {
+	_, err := r.pool.Exec(ctx, `DELETE FROM llm_cache`)
+	return err
+}
+
+// CountByKind returns the number of live entries per kind (completion, embedding)
+func (r *LLMCacheRepository) CountByKind(ctx context.Context) ([]models.LLMCacheKindStats, error) {
+	query := `
+		SELECT kind, COUNT(*), COALESCE(SUM(hits), 0)
+		FROM llm_cache
+		WHERE expires_at > NOW()
+		GROUP BY kind
+		ORDER BY kind
+	`
+
+	rows, err := r.pool.Query(ctx, query)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	stats := []models.LLMCacheKindStats{}
+	for rows.Next() {
+		var s models.LLMCacheKindStats
+		if err := rows.Scan(&s.Kind, &s.Entries, &s.StoredHits); err != nil {
+			return nil, err
+		}
+		stats = append(stats, s)
+	}
+	return stats, rows.Err()
+}
@@ -0,0 +1,113 @@
+package repository
+
+import (
+	"context"
+	"synapse/internal/models"
+
+	"github.com/google/uuid"
+	"github.com/jackc/pgx/v5/pgxpool"
+)
+
+type OCRRepository struct {
+	pool *pgxpool.Pool
+}
+
+func NewOCRRepository(pool *pgxpool.Pool) *OCRRepository {
+	return &OCRRepository{pool: pool}
+}
+
+// Save replaces an item's OCR result and sets its ocr_text to text, the joined blocks
+func (r *OCRRepository) Save(ctx context.Context, itemID uuid.UUID, result *models.OCRResult, text string) error {
+	tx, err := r.pool.Begin(ctx)
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback(ctx)
+
+	languages := result.Languages
+	if languages == nil {
+		languages = []string{}
+	}
+	_, err = tx.Exec(ctx, `
+		INSERT INTO ocr_results (item_id, engine, languages, confidence)
+		VALUES ($1, $2, $3, $4)
+		ON CONFLICT (item_id) DO UPDATE SET engine = EXCLUDED.engine, languages = EXCLUDED.languages,
+			confidence = EXCLUDED.confidence, created_at = NOW()
+	`, itemID, result.Engine, languages, result.Confidence)
+	if err != nil {
+		return err
+	}
+	if _, err := tx.Exec(ctx, `DELETE FROM ocr_blocks WHERE item_id = $1`, itemID); err != nil {
+		return err
+	}
+	for i, block := range result.Blocks {
+		var x, y, width, height *int
+		if block.Box != nil {
+			x, y, width, height = &block.Box.X, &block.Box.Y, &block.Box.Width, &block.Box.Height
+		}
+		_, err := tx.Exec(ctx, `
+			INSERT INTO ocr_blocks (item_id, position, text, confidence, x, y, width, height)
+			VALUES ($1, $2, $3, $4, $5, $6, $7, $8)
+		`, itemID, i, block.Text, block.Confidence, x, y, width, height)
+		if err != nil {
+			return err
+		}
+	}
+	if _, err := tx.Exec(ctx, `UPDATE items SET ocr_text = $1 WHERE id = $2`, text, itemID); err != nil {
+		return err
+	}
+	return tx.Commit(ctx)
+}
+
+// Delete removes an item's OCR result and clears its ocr_text
+func (r *OCRRepository) Delete(ctx context.Context, itemID uuid.UUID) error {
+	tx, err := r.pool.Begin(ctx)
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback(ctx)
+
+	if _, err := tx.Exec(ctx, `DELETE FROM ocr_results WHERE item_id = $1`, itemID); err != nil {
+		return err
+	}
+	if _, err := tx.Exec(ctx, `UPDATE items SET ocr_text = NULL WHERE id = $1`, itemID); err != nil {
+		return err
+	}
+	return tx.Commit(ctx)
+}
+
+// Get returns an item's OCR result with its blocks in reading order, or pgx.ErrNoRows
+func (r *OCRRepository) Get(ctx context.Context, itemID uuid.UUID) (*models.OCRResult, error) {
+	var result models.OCRResult
+	err := r.pool.QueryRow(ctx, `
+		SELECT engine, languages, confidence, created_at FROM ocr_results WHERE item_id = $1
+	`, itemID).Scan(&result.Engine, &result.Languages, &result.Confidence, &result.CreatedAt)
+	if err != nil {
+		return nil, err
+	}
+
+	rows, err := r.pool.Query(ctx, `
+		SELECT text, confidence, x, y, width, height
+		FROM ocr_blocks
+		WHERE item_id = $1
+		ORDER BY position
+	`, itemID)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	result.Blocks = []models.OCRBlock{}
+	for rows.Next() {
+		var block models.OCRBlock
+		var x, y, width, height *int
+		if err := rows.Scan(&block.Text, &block.Confidence, &x, &y, &width, &height); err != nil {
+			return nil, err
+		}
+		if x != nil && y != nil && width != nil && height != nil {
+			block.Box = &models.OCRBox{X: *x, Y: *y, Width: *width, Height: *height}
+		}
+		result.Blocks = append(result.Blocks, block)
+	}
+	return &result, rows.Err()
+}
@@ -33,7 +33,7 @@ func (r *RelationRepository) GetRelated(ctx context.Context, itemID uuid.UUID, l
 		SELECT i.id, i.title, i.content, i.summary, i.source_url, i.type, i.tags, i.embedding_id, i.created_at, ir.similarity_score
 		FROM item_relations ir
 		JOIN items i ON ir.related_item_id = i.id
-		WHERE ir.item_id = $1
+		WHERE ir.item_id = $1 AND i.deleted_at IS NULL
 		ORDER BY ir.similarity_score DESC
 		LIMIT $2
 	`
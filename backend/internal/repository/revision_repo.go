@@ -0,0 +1,70 @@
+package repository
+
+import (
+	"context"
+	"synapse/internal/models"
+
+	"github.com/google/uuid"
+	"github.com/jackc/pgx/v5"
+	"github.com/jackc/pgx/v5/pgxpool"
+)
+
+type RevisionRepository struct {
+	pool *pgxpool.Pool
+}
+
+func NewRevisionRepository(pool *pgxpool.Pool) *RevisionRepository {
+	return &RevisionRepository{pool: pool}
+}
+
+// List returns the revisions of an item, oldest first
+func (r *RevisionRepository) List(ctx context.Context, itemID uuid.UUID) ([]models.ItemRevision, error) {
+	rows, err := r.pool.Query(ctx, `
+		SELECT id, item_id, title, content, summary, category, tags, actor, created_at
+		FROM item_revisions
+		WHERE item_id = $1
+		ORDER BY id
+	`, itemID)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	revisions := []models.ItemRevision{}
+	for rows.Next() {
+		var revision models.ItemRevision
+		err := rows.Scan(&revision.ID, &revision.ItemID, &revision.Title, &revision.Content, &revision.Summary,
+			&revision.Category, &revision.Tags, &revision.Actor, &revision.CreatedAt)
+		if err != nil {
+			return nil, err
+		}
+		revisions = append(revisions, revision)
+	}
+	return revisions, rows.Err()
+}
+
+// recordRevisionsSQL snapshots the items matching the condition appended to it. Items
+// whose tracked fields equal their latest revision are skipped, so callers can pass a
+// superset of the items they changed.
+const recordRevisionsSQL = `
+	INSERT INTO item_revisions (item_id, title, content, summary, category, tags, actor)
+	SELECT i.id, i.title, i.content, COALESCE(i.summary, ''), COALESCE(i.category, ''), COALESCE(i.tags, '{}'), $1
+	FROM items i
+	WHERE NOT EXISTS (
+		SELECT 1 FROM (
+			SELECT title, content, summary, category, tags
+			FROM item_revisions
+			WHERE item_id = i.id
+			ORDER BY id DESC
+			LIMIT 1
+		) last
+		WHERE last.title = i.title AND last.content = i.content AND last.summary = COALESCE(i.summary, '')
+			AND last.category = COALESCE(i.category, '') AND last.tags = COALESCE(i.tags, '{}')
+	) AND `
+
+// recordRevisions records a revision by actor for every item matching condition whose
+// tracked fields changed. condition refers to items as i and to its arguments from $2 on.
+func recordRevisions(ctx context.Context, tx pgx.Tx, actor, condition string, args ...interface{}) error {
+	_, err := tx.Exec(ctx, recordRevisionsSQL+condition, append([]interface{}{actor}, args...)...)
+	return err
+}
@@ -0,0 +1,77 @@
+package repository
+
+import (
+	"context"
+	"synapse/internal/models"
+
+	"github.com/google/uuid"
+	"github.com/jackc/pgx/v5"
+	"github.com/jackc/pgx/v5/pgxpool"
+)
+
+type RuleRepository struct {
+	pool *pgxpool.Pool
+}
+
+func NewRuleRepository(pool *pgxpool.Pool) *RuleRepository {
+	return &RuleRepository{pool: pool}
+}
+
+const ruleSelect = `SELECT id, name, enabled, priority, conditions, actions, created_at FROM rules`
+
+func scanRule(row pgx.Row) (*models.Rule, error) {
+	var rule models.Rule
+	err := row.Scan(&rule.ID, &rule.Name, &rule.Enabled, &rule.Priority, &rule.Conditions, &rule.Actions, &rule.CreatedAt)
+	if err != nil {
+		return nil, err
+	}
+	return &rule, nil
+}
+
+// List returns every rule in evaluation order
+func (r *RuleRepository) List(ctx context.Context) ([]models.Rule, error) {
+	rows, err := r.pool.Query(ctx, ruleSelect+` ORDER BY priority, created_at`)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	rules := []models.Rule{}
+	for rows.Next() {
+		rule, err := scanRule(rows)
+		if err != nil {
+			return nil, err
+		}
+		rules = append(rules, *rule)
+	}
+	return rules, rows.Err()
+}
+
+func (r *RuleRepository) GetByID(ctx context.Context, id uuid.UUID) (*models.Rule, error) {
+	return scanRule(r.pool.QueryRow(ctx, ruleSelect+` WHERE id = $1`, id))
+}
+
+func (r *RuleRepository) Create(ctx context.Context, rule *models.Rule) error {
+	query := `
+		INSERT INTO rules (name, enabled, priority, conditions, actions)
+		VALUES ($1, $2, $3, $4, $5)
+		RETURNING id, created_at
+	`
+	return r.pool.QueryRow(ctx, query,
+		rule.Name, rule.Enabled, rule.Priority, rule.Conditions, rule.Actions,
+	).Scan(&rule.ID, &rule.CreatedAt)
+}
+
+func (r *RuleRepository) Update(ctx context.Context, rule *models.Rule) error {
+	query := `
+		UPDATE rules SET name = $1, enabled = $2, priority = $3, conditions = $4, actions = $5
+		WHERE id = $6
+	`
+	_, err := r.pool.Exec(ctx, query, rule.Name, rule.Enabled, rule.Priority, rule.Conditions, rule.Actions, rule.ID)
+	return err
+}
+
+func (r *RuleRepository) Delete(ctx context.Context, id uuid.UUID) error {
+	_, err := r.pool.Exec(ctx, `DELETE FROM rules WHERE id = $1`, id)
+	return err
+}
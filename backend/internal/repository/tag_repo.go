@@ -0,0 +1,169 @@
+package repository
+
+import (
+	"context"
+	"synapse/internal/models"
+
+	"github.com/jackc/pgx/v5"
+	"github.com/jackc/pgx/v5/pgxpool"
+)
+
+type TagRepository struct {
+	pool *pgxpool.Pool
+}
+
+func NewTagRepository(pool *pgxpool.Pool) *TagRepository {
+	return &TagRepository{pool: pool}
+}
+
+// List returns every tag in use with its item count, most used first
+func (r *TagRepository) List(ctx context.Context, limit int) ([]models.Tag, error) {
+	query := `
+		SELECT t, COUNT(*) AS n
+		FROM items, unnest(items.tags) AS t
+		WHERE items.deleted_at IS NULL
+		GROUP BY t
+		ORDER BY n DESC, t
+	`
+	args := []interface{}{}
+	if limit > 0 {
+		query += ` LIMIT $1`
+		args = append(args, limit)
+	}
+
+	rows, err := r.pool.Query(ctx, query, args...)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	tags := []models.Tag{}
+	for rows.Next() {
+		var tag models.Tag
+		if err := rows.Scan(&tag.Name, &tag.Count); err != nil {
+			return nil, err
+		}
+		tag.Synonyms = []string{}
+		tags = append(tags, tag)
+	}
+	return tags, rows.Err()
+}
+
+// Count returns the number of items tagged with tag
+func (r *TagRepository) Count(ctx context.Context, tag string) (int, error) {
+	var count int
+	err := r.pool.QueryRow(ctx, `SELECT COUNT(*) FROM items WHERE $1 = ANY(tags) AND deleted_at IS NULL`, tag).Scan(&count)
+	return count, err
+}
+
+// Synonyms returns the synonym to canonical tag mapping
+func (r *TagRepository) Synonyms(ctx context.Context) (map[string]string, error) {
+	rows, err := r.pool.Query(ctx, `SELECT synonym, tag FROM tag_synonyms`)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	synonyms := map[string]string{}
+	for rows.Next() {
+		var synonym, tag string
+		if err := rows.Scan(&synonym, &tag); err != nil {
+			return nil, err
+		}
+		synonyms[synonym] = tag
+	}
+	return synonyms, rows.Err()
+}
+
+// rewriteTagSQL replaces $1 with $2 in an item's tags, dropping the duplicate if the item
+// already had $2 and keeping the original order. The source of $1 moves to $2 unless the
+// item already had a source for $2.
+const rewriteTagSQL = `
+	UPDATE items SET tags = ARRAY(
+		SELECT t FROM unnest(array_replace(items.tags, $1, $2)) WITH ORDINALITY AS u(t, n)
+		GROUP BY t
+		ORDER BY MIN(n)
+	),
+	tag_sources = CASE WHEN items.tag_sources ? $1
+		THEN (items.tag_sources - $1::text) || jsonb_build_object($2::text, COALESCE(items.tag_sources -> $2::text, items.tag_sources -> $1::text))
+		ELSE items.tag_sources
+	END
+	WHERE $1 = ANY(items.tags)
+`
+
+// Rewrite replaces tag from with to on every item, atomically. When keepSynonym is set,
+// from is recorded as a synonym of to so future occurrences are rewritten on write.
+// It returns the number of items changed.
+func (r *TagRepository) Rewrite(ctx context.Context, from, to string, keepSynonym bool) (int64, error) {
+	tx, err := r.pool.Begin(ctx)
+	if err != nil {
+		return 0, err
+	}
+	defer tx.Rollback(ctx)
+
+	changed, err := rewriteTag(ctx, tx, from, to)
+	if err != nil {
+		return 0, err
+	}
+
+	// Synonyms of from now belong to to
+	if _, err := tx.Exec(ctx, `UPDATE tag_synonyms SET tag = $2 WHERE tag = $1`, from, to); err != nil {
+		return 0, err
+	}
+	if _, err := tx.Exec(ctx, `DELETE FROM tag_synonyms WHERE synonym = $1`, to); err != nil {
+		return 0, err
+	}
+	if keepSynonym {
+		_, err := tx.Exec(ctx, `
+			INSERT INTO tag_synonyms (synonym, tag) VALUES ($1, $2)
+			ON CONFLICT (synonym) DO UPDATE SET tag = EXCLUDED.tag
+		`, from, to)
+		if err != nil {
+			return 0, err
+		}
+	}
+	return changed, tx.Commit(ctx)
+}
+
+// SetSynonyms replaces the synonym group of tag. Synonyms that were canonical tags bring
+// their own synonyms along, and items tagged with any synonym are rewritten to tag.
+func (r *TagRepository) SetSynonyms(ctx context.Context, tag string, synonyms []string) error {
+	tx, err := r.pool.Begin(ctx)
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback(ctx)
+
+	if _, err := tx.Exec(ctx, `DELETE FROM tag_synonyms WHERE tag = $1`, tag); err != nil {
+		return err
+	}
+	for _, synonym := range synonyms {
+		if _, err := tx.Exec(ctx, `UPDATE tag_synonyms SET tag = $2 WHERE tag = $1`, synonym, tag); err != nil {
+			return err
+		}
+		_, err := tx.Exec(ctx, `
+			INSERT INTO tag_synonyms (synonym, tag) VALUES ($1, $2)
+			ON CONFLICT (synonym) DO UPDATE SET tag = EXCLUDED.tag
+		`, synonym, tag)
+		if err != nil {
+			return err
+		}
+		if _, err := rewriteTag(ctx, tx, synonym, tag); err != nil {
+			return err
+		}
+	}
+	return tx.Commit(ctx)
+}
+
+func rewriteTag(ctx context.Context, tx pgx.Tx, from, to string) (int64, error) {
+	tag, err := tx.Exec(ctx, rewriteTagSQL, from, to)
+	if err != nil {
+		return 0, err
+	}
+	if tag.RowsAffected() > 0 {
+		if err := recordRevisions(ctx, tx, models.SourceUser, `$2 = ANY(i.tags)`, to); err != nil {
+			return 0, err
+		}
+	}
+	return tag.RowsAffected(), nil
+}
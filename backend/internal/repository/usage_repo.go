@@ -0,0 +1,83 @@
+package repository
+
+import (
+	"context"
+	"fmt"
+	"synapse/internal/models"
+	"time"
+
+	"github.com/jackc/pgx/v5/pgxpool"
+)
+
+type UsageRepository struct {
+	pool *pgxpool.Pool
+}
+
+func NewUsageRepository(pool *pgxpool.Pool) *UsageRepository {
+	return &UsageRepository{pool: pool}
+}
+
+func (r *UsageRepository) Create(ctx context.Context, record *models.AIUsageRecord) error {
+	query := `
+		INSERT INTO ai_usage (provider, model, operation, input_tokens, output_tokens, latency_ms, cost_usd, success, created_at)
+		VALUES ($1, $2, $3, $4, $5, $6, $7, $8, $9)
+	`
+	_, err := r.pool.Exec(ctx, query,
+		record.Provider, record.Model, record.Operation, record.InputTokens, record.OutputTokens,
+		record.LatencyMs, record.CostUSD, record.Success, record.CreatedAt,
+	)
+	return err
+}
+
+// usageGroupColumns maps the group_by values accepted by the API to SQL expressions
+var usageGroupColumns = map[string]string{
+	"day":       `TO_CHAR(created_at, 'YYYY-MM-DD')`,
+	"provider":  `provider`,
+	"model":     `model`,
+	"operation": `operation`,
+}
+
+// Aggregate groups usage between from and to by day, provider, model or operation
+func (r *UsageRepository) Aggregate(ctx context.Context, from, to time.Time, groupBy string) ([]models.AIUsageGroup, error) {
+	column, ok := usageGroupColumns[groupBy]
+	if !ok {
+		return nil, fmt.Errorf("invalid group_by %q", groupBy)
+	}
+
+	query := fmt.Sprintf(`
+		SELECT %s AS key,
+			COUNT(*),
+			COUNT(*) FILTER (WHERE NOT success),
+			COALESCE(SUM(input_tokens), 0),
+			COALESCE(SUM(output_tokens), 0),
+			COALESCE(SUM(cost_usd), 0),
+			COALESCE(AVG(latency_ms), 0)
+		FROM ai_usage
+		WHERE created_at >= $1 AND created_at < $2
+		GROUP BY key
+		ORDER BY key
+	`, column)
+
+	rows, err := r.pool.Query(ctx, query, from, to)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	groups := []models.AIUsageGroup{}
+	for rows.Next() {
+		var g models.AIUsageGroup
+		if err := rows.Scan(&g.Key, &g.Calls, &g.Failures, &g.InputTokens, &g.OutputTokens, &g.CostUSD, &g.AvgLatencyMs); err != nil {
+			return nil, err
+		}
+		groups = append(groups, g)
+	}
+	return groups, rows.Err()
+}
+
+// CostSince returns the total estimated cost of requests made at or after since
+func (r *UsageRepository) CostSince(ctx context.Context, since time.Time) (float64, error) {
+	var cost float64
+	err := r.pool.QueryRow(ctx, `SELECT COALESCE(SUM(cost_usd), 0) FROM ai_usage WHERE created_at >= $1`, since).Scan(&cost)
+	return cost, err
+}
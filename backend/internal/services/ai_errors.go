@@ -0,0 +1,189 @@
+package services
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"net/url"
+	"regexp"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// ProviderErrorKind classifies failures returned by AI providers
+type ProviderErrorKind string
+
+const (
+	ErrKindRateLimited   ProviderErrorKind = "rate_limited"   // 429 without quota exhaustion; retry later
+	ErrKindQuotaExceeded ProviderErrorKind = "quota_exceeded" // Billing/daily quota used up; retrying won't help soon
+	ErrKindAuth          ProviderErrorKind = "auth"           // Missing or invalid API key
+	ErrKindTransient     ProviderErrorKind = "transient"      // Network errors, timeouts and 5xx responses
+	ErrKindInvalid       ProviderErrorKind = "invalid"        // Bad request or unknown model; specific to the call
+	ErrKindCircuitOpen   ProviderErrorKind = "circuit_open"   // Provider skipped because its circuit breaker is open
+)
+
+// ProviderError is returned by every AI provider call
+type ProviderError struct {
+	Provider   string
+	Model      string
+	Kind       ProviderErrorKind
+	StatusCode int
+	RetryAfter time.Duration
+	Message    string
+	Err        error
+}
+
+func (e *ProviderError) Error() string {
+	var b strings.Builder
+	fmt.Fprintf(&b, "%s API error (%s", e.Provider, e.Kind)
+	if e.Model != "" {
+		fmt.Fprintf(&b, ", model: %s", e.Model)
+	}
+	if e.StatusCode != 0 {
+		fmt.Fprintf(&b, ", status: %d", e.StatusCode)
+	}
+	b.WriteString(")")
+	if e.Message != "" {
+		b.WriteString(": " + e.Message)
+	} else if e.Err != nil {
+		b.WriteString(": " + e.Err.Error())
+	}
+	return b.String()
+}
+
+func (e *ProviderError) Unwrap() error {
+	return e.Err
+}
+
+// ProviderErrorKindOf returns the kind of a provider error, or "" for other errors
+func ProviderErrorKindOf(err error) ProviderErrorKind {
+	var perr *ProviderError
+	if errors.As(err, &perr) {
+		return perr.Kind
+	}
+	return ""
+}
+
+// IsRateLimited reports whether err means the provider is throttling us or out of quota
+func IsRateLimited(err error) bool {
+	kind := ProviderErrorKindOf(err)
+	return kind == ErrKindRateLimited || kind == ErrKindQuotaExceeded
+}
+
+// isRetryable reports whether the same request may succeed if sent again after a pause
+func isRetryable(err error) bool {
+	kind := ProviderErrorKindOf(err)
+	return kind == ErrKindRateLimited || kind == ErrKindTransient
+}
+
+// newTransportError wraps a network-level failure. The request URL is dropped from it
+// since it may carry credentials.
+func newTransportError(provider, model string, err error) *ProviderError {
+	kind := ErrKindTransient
+	if errors.Is(err, context.Canceled) {
+		kind = ErrKindInvalid
+	}
+	var urlErr *url.Error
+	if errors.As(err, &urlErr) {
+		err = fmt.Errorf("%s request: %w", urlErr.Op, urlErr.Err)
+	}
+	return &ProviderError{Provider: provider, Model: model, Kind: kind, Err: err}
+}
+
+// secretRe matches credentials that can show up in error messages: API keys and tokens
+// in query strings, and bearer tokens
+var secretRe = regexp.MustCompile(`(?i)([?&](?:key|api_key|apikey|access_token|token)=|bearer\s+)[^&\s"']+`)
+
+// redactSecrets hides the credentials in an error message before it is kept or shown
+func redactSecrets(message string) string {
+	return secretRe.ReplaceAllString(message, "${1}[redacted]")
+}
+
+// newHTTPProviderError classifies a non-2xx provider response
+func newHTTPProviderError(provider, model string, resp *http.Response, body []byte) *ProviderError {
+	status, message := parseProviderErrorBody(body)
+	if message == "" {
+		message = strings.TrimSpace(string(body))
+	}
+	return &ProviderError{
+		Provider:   provider,
+		Model:      model,
+		Kind:       classifyProviderError(resp.StatusCode, status, message),
+		StatusCode: resp.StatusCode,
+		RetryAfter: parseRetryAfter(resp.Header.Get("Retry-After")),
+		Message:    message,
+	}
+}
+
+// newPayloadProviderError classifies an error embedded in a 200 response body (Gemini does this)
+func newPayloadProviderError(provider, model string, code int, status, message string) *ProviderError {
+	return &ProviderError{
+		Provider:   provider,
+		Model:      model,
+		Kind:       classifyProviderError(code, status, message),
+		StatusCode: code,
+		Message:    message,
+	}
+}
+
+func classifyProviderError(code int, status, message string) ProviderErrorKind {
+	lower := strings.ToLower(status + " " + message)
+	switch {
+	case code == http.StatusUnauthorized || code == http.StatusForbidden:
+		return ErrKindAuth
+	case strings.Contains(lower, "quota") || strings.Contains(lower, "billing"):
+		return ErrKindQuotaExceeded
+	case code == http.StatusTooManyRequests || strings.Contains(lower, "resource_exhausted") || strings.Contains(lower, "rate limit"):
+		return ErrKindRateLimited
+	case strings.Contains(lower, "api key") || strings.Contains(lower, "permission_denied") || strings.Contains(lower, "unauthenticated"):
+		return ErrKindAuth
+	case code == http.StatusRequestTimeout || code >= 500 || strings.Contains(lower, "overloaded") || strings.Contains(lower, "unavailable"):
+		return ErrKindTransient
+	default:
+		return ErrKindInvalid
+	}
+}
+
+// parseProviderErrorBody extracts status and message from OpenAI/LiteLLM or Gemini error bodies
+func parseProviderErrorBody(body []byte) (status, message string) {
+	var apiError struct {
+		Error struct {
+			Message string      `json:"message"`
+			Type    string      `json:"type"`
+			Status  string      `json:"status"`
+			Code    interface{} `json:"code"`
+		} `json:"error"`
+	}
+	if err := json.Unmarshal(body, &apiError); err != nil {
+		return "", ""
+	}
+
+	status = apiError.Error.Status
+	if status == "" {
+		status = apiError.Error.Type
+	}
+	if code, ok := apiError.Error.Code.(string); ok && code != "" {
+		status = strings.TrimSpace(status + " " + code)
+	}
+	return status, apiError.Error.Message
+}
+
+// parseRetryAfter accepts both delay-seconds and HTTP-date forms
+func parseRetryAfter(value string) time.Duration {
+	value = strings.TrimSpace(value)
+	if value == "" {
+		return 0
+	}
+	if seconds, err := strconv.Atoi(value); err == nil && seconds > 0 {
+		return time.Duration(seconds) * time.Second
+	}
+	if at, err := http.ParseTime(value); err == nil {
+		if d := time.Until(at); d > 0 {
+			return d
+		}
+	}
+	return 0
+}
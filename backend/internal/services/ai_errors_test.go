@@ -0,0 +1,51 @@
+package services
+
+import (
+	"errors"
+	"net/url"
+	"strings"
+	"testing"
+)
+
+func TestRedactSecrets(t *testing.T) {
+	tests := []struct {
+		message string
+		want    string
+	}{
+		{`Post "https://example.com/v1?key=AIzaSecret": timeout`, `Post "https://example.com/v1?key=[redacted]": timeout`},
+		{"https://example.com/v1?alt=json&api_key=abc&x=1", "https://example.com/v1?alt=json&api_key=[redacted]&x=1"},
+		{`Authorization: Bearer sk-123 rejected`, `Authorization: Bearer [redacted] rejected`},
+		{"no secrets here", "no secrets here"},
+	}
+	for _, tt := range tests {
+		if got := redactSecrets(tt.message); got != tt.want {
+			t.Errorf("redactSecrets(%q) = %q, want %q", tt.message, got, tt.want)
+		}
+	}
+}
+
+func TestNewTransportErrorDropsURL(t *testing.T) {
+	cause := errors.New("connection refused")
+	err := newTransportError(providerGemini, "gemini-2.5-flash", &url.Error{
+		Op:  "Post",
+		URL: "https://generativelanguage.googleapis.com/v1beta/models/x:generateContent?key=AIzaSecret",
+		Err: cause,
+	})
+	if strings.Contains(err.Error(), "AIzaSecret") || strings.Contains(err.Error(), "googleapis") {
+		t.Fatalf("error keeps the request URL: %v", err)
+	}
+	if !errors.Is(err, cause) {
+		t.Fatalf("error no longer wraps its cause: %v", err)
+	}
+	if err.Kind != ErrKindTransient {
+		t.Fatalf("kind = %s, want %s", err.Kind, ErrKindTransient)
+	}
+}
+
+func TestCircuitBreakerRedactsLastError(t *testing.T) {
+	b := newCircuitBreaker(3, 0)
+	b.RecordFailure(newTransportError(providerGemini, "", errors.New(`Post "https://example.com/?key=AIzaSecret": EOF`)))
+	if _, _, lastError := b.status(); strings.Contains(lastError, "AIzaSecret") {
+		t.Fatalf("last error keeps the key: %s", lastError)
+	}
+}
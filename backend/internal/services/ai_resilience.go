@@ -0,0 +1,460 @@
+package services
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"math/rand"
+	"net/http"
+	"os"
+	"strconv"
+	"strings"
+	"synapse/internal/models"
+	"sync"
+	"time"
+)
+
+const (
+	providerClaude = "claude"
+	providerGemini = "gemini"
+	providerOpenAI = "openai"
+)
+
+// Default client-side limits in requests per minute. Gemini's free tier allows 15 RPM.
+var defaultProviderRPM = map[string]int{
+	providerClaude: 60,
+	providerGemini: 15,
+	providerOpenAI: 60,
+}
+
+const (
+	retryBaseDelay    = 500 * time.Millisecond
+	retryMaxDelay     = 20 * time.Second
+	maxRetryAfterWait = time.Minute // Give up (and fail over) instead of honoring longer Retry-After values
+)
+
+// tokenBucket is a client-side rate limiter: capacity tokens, refilled at rate tokens per second
+type tokenBucket struct {
+	mu       sync.Mutex
+	capacity float64
+	tokens   float64
+	rate     float64
+	last     time.Time
+}
+
+func newTokenBucket(requestsPerMinute int) *tokenBucket {
+	if requestsPerMinute <= 0 {
+		return nil
+	}
+	capacity := float64(requestsPerMinute) / 4
+	if capacity < 3 {
+		capacity = 3
+	}
+	if capacity > float64(requestsPerMinute) {
+		capacity = float64(requestsPerMinute)
+	}
+	return &tokenBucket{
+		capacity: capacity,
+		tokens:   capacity,
+		rate:     float64(requestsPerMinute) / 60,
+		last:     time.Now(),
+	}
+}
+
+// Wait blocks until a token is available or ctx is done. A nil bucket never blocks.
+func (b *tokenBucket) Wait(ctx context.Context) error {
+	if b == nil {
+		return nil
+	}
+
+	for {
+		b.mu.Lock()
+		now := time.Now()
+		b.tokens += now.Sub(b.last).Seconds() * b.rate
+		if b.tokens > b.capacity {
+			b.tokens = b.capacity
+		}
+		b.last = now
+
+		if b.tokens >= 1 {
+			b.tokens--
+			b.mu.Unlock()
+			return nil
+		}
+		wait := time.Duration((1 - b.tokens) / b.rate * float64(time.Second))
+		b.mu.Unlock()
+
+		timer := time.NewTimer(wait)
+		select {
+		case <-ctx.Done():
+			timer.Stop()
+			return ctx.Err()
+		case <-timer.C:
+		}
+	}
+}
+
+type breakerState string
+
+const (
+	breakerClosed   breakerState = "closed"
+	breakerOpen     breakerState = "open"
+	breakerHalfOpen breakerState = "half_open"
+)
+
+// circuitBreaker stops sending requests to a provider after repeated failures.
+// After the cooldown a single trial request is let through (half-open); its outcome
+// closes or re-opens the circuit.
+type circuitBreaker struct {
+	mu        sync.Mutex
+	threshold int
+	cooldown  time.Duration
+	state     breakerState
+	failures  int
+	openedAt  time.Time
+	lastError string
+	trial     bool
+}
+
+func newCircuitBreaker(threshold int, cooldown time.Duration) *circuitBreaker {
+	return &circuitBreaker{threshold: threshold, cooldown: cooldown, state: breakerClosed}
+}
+
+// Allow reports whether a request may be sent now
+func (b *circuitBreaker) Allow() bool {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	switch b.state {
+	case breakerOpen:
+		if time.Since(b.openedAt) < b.cooldown {
+			return false
+		}
+		b.state = breakerHalfOpen
+		b.trial = true
+		return true
+	case breakerHalfOpen:
+		// Only one trial request at a time
+		if b.trial {
+			return false
+		}
+		b.trial = true
+		return true
+	default:
+		return true
+	}
+}
+
+func (b *circuitBreaker) RecordSuccess() {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	b.state = breakerClosed
+	b.failures = 0
+	b.trial = false
+}
+
+// RecordFailure counts err against the provider. Request-specific errors (bad request,
+// unknown model) don't indicate provider health and are ignored. Auth and quota errors
+// open the circuit immediately since retrying won't help until someone intervenes.
+func (b *circuitBreaker) RecordFailure(err error) {
+	kind := ProviderErrorKindOf(err)
+	if kind == ErrKindInvalid || kind == ErrKindCircuitOpen {
+		b.mu.Lock()
+		b.trial = false
+		b.mu.Unlock()
+		return
+	}
+
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	b.failures++
+	b.lastError = redactSecrets(err.Error())
+	b.trial = false
+	if b.state == breakerHalfOpen || b.failures >= b.threshold || kind == ErrKindAuth || kind == ErrKindQuotaExceeded {
+		b.state = breakerOpen
+		b.openedAt = time.Now()
+	}
+}
+
+func (b *circuitBreaker) status() (breakerState, int, string) {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	state := b.state
+	if state == breakerOpen && time.Since(b.openedAt) >= b.cooldown {
+		state = breakerHalfOpen
+	}
+	return state, b.failures, b.lastError
+}
+
+// providerGuards holds the rate limiter and circuit breaker of every provider
+type providerGuards struct {
+	limiters   map[string]*tokenBucket
+	breakers   map[string]*circuitBreaker
+	rpm        map[string]int
+	maxRetries int
+}
+
+func newProviderGuards() *providerGuards {
+	threshold := envInt("AI_BREAKER_THRESHOLD", 5)
+	cooldown := envDuration("AI_BREAKER_COOLDOWN", time.Minute)
+
+	maxRetries := envCount("AI_MAX_RETRIES", 2)
+
+	g := &providerGuards{
+		limiters:   map[string]*tokenBucket{},
+		breakers:   map[string]*circuitBreaker{},
+		rpm:        map[string]int{},
+		maxRetries: maxRetries,
+	}
+	for _, provider := range []string{providerClaude, providerGemini, providerOpenAI} {
+		rpm := envCount("AI_RATE_LIMIT_"+strings.ToUpper(provider), defaultProviderRPM[provider])
+		g.rpm[provider] = rpm
+		g.limiters[provider] = newTokenBucket(rpm)
+		g.breakers[provider] = newCircuitBreaker(threshold, cooldown)
+	}
+	return g
+}
+
+// doProviderRequest sends a request built by newRequest, waiting for the provider's
+// rate limiter and retrying rate-limited and transient failures with exponential
+// backoff (honoring Retry-After). It returns the body of a 2xx response or a *ProviderError.
+// Failed requests are recorded for usage accounting here; callers record successes with token counts.
+func (s *AIService) doProviderRequest(ctx context.Context, provider, model string, newRequest func() (*http.Request, error)) ([]byte, error) {
+	// The breaker only sees requests that reach the provider; cache hits never get here
+	breaker := s.guards.breakers[provider]
+	if !breaker.Allow() {
+		return nil, &ProviderError{Provider: provider, Model: model, Kind: ErrKindCircuitOpen, Message: "circuit breaker open, skipping provider"}
+	}
+
+	start := time.Now()
+	body, err := s.sendWithRetries(ctx, provider, model, newRequest)
+	if err != nil {
+		breaker.RecordFailure(err)
+		s.usage.Record(ctx, provider, model, 0, 0, time.Since(start), false)
+		return nil, err
+	}
+	breaker.RecordSuccess()
+	return body, nil
+}
+
+func (s *AIService) sendWithRetries(ctx context.Context, provider, model string, newRequest func() (*http.Request, error)) ([]byte, error) {
+	var lastErr *ProviderError
+	for attempt := 0; attempt <= s.guards.maxRetries; attempt++ {
+		if attempt > 0 {
+			delay := backoffDelay(attempt, lastErr.RetryAfter)
+			if delay < 0 {
+				break
+			}
+			timer := time.NewTimer(delay)
+			select {
+			case <-ctx.Done():
+				timer.Stop()
+				return nil, newTransportError(provider, model, ctx.Err())
+			case <-timer.C:
+			}
+		}
+
+		if err := s.guards.limiters[provider].Wait(ctx); err != nil {
+			return nil, newTransportError(provider, model, err)
+		}
+
+		req, err := newRequest()
+		if err != nil {
+			return nil, &ProviderError{Provider: provider, Model: model, Kind: ErrKindInvalid, Err: err}
+		}
+
+		resp, err := s.client.Do(req)
+		if err != nil {
+			lastErr = newTransportError(provider, model, err)
+			if ctx.Err() != nil {
+				return nil, lastErr
+			}
+			continue
+		}
+
+		body, err := io.ReadAll(resp.Body)
+		resp.Body.Close()
+		if err != nil {
+			lastErr = newTransportError(provider, model, err)
+			continue
+		}
+
+		if resp.StatusCode >= 200 && resp.StatusCode < 300 {
+			return body, nil
+		}
+
+		lastErr = newHTTPProviderError(provider, model, resp, body)
+		if !isRetryable(lastErr) {
+			return nil, lastErr
+		}
+	}
+	return nil, lastErr
+}
+
+// backoffDelay returns how long to wait before retry number attempt, or -1 when the
+// server asked us to wait longer than we're willing to
+func backoffDelay(attempt int, retryAfter time.Duration) time.Duration {
+	if retryAfter > 0 {
+		if retryAfter > maxRetryAfterWait {
+			return -1
+		}
+		return retryAfter
+	}
+
+	delay := retryBaseDelay << (attempt - 1)
+	if delay > retryMaxDelay {
+		delay = retryMaxDelay
+	}
+	// Full jitter keeps concurrent callers from retrying in lockstep
+	return delay/2 + time.Duration(rand.Int63n(int64(delay/2)+1))
+}
+
+// primaryProvider returns the provider used for text generation and embeddings
+func (s *AIService) primaryProvider() string {
+	if s.provider == providerClaude && s.claudeKey != "" {
+		return providerClaude
+	}
+	if s.provider == providerGemini {
+		return providerGemini
+	}
+	return providerOpenAI
+}
+
+func (s *AIService) providerConfigured(provider string) bool {
+	switch provider {
+	case providerClaude:
+		return s.claudeKey != ""
+	case providerGemini:
+		return s.geminiKey != ""
+	case providerOpenAI:
+		return s.openaiKey != ""
+	}
+	return false
+}
+
+// textProviders returns the primary provider followed by the fallback provider, if one is configured
+func (s *AIService) textProviders() []string {
+	providers := []string{s.primaryProvider()}
+	if s.fallbackProvider != "" && s.fallbackProvider != providers[0] && s.providerConfigured(s.fallbackProvider) {
+		providers = append(providers, s.fallbackProvider)
+	}
+	return providers
+}
+
+// complete generates text for prompt. Requests go to the primary provider unless its
+// circuit is open; failures fail over to the fallback provider. pro selects the
+// higher-quality model list where a provider has one (Gemini Pro for summaries).
+func (s *AIService) complete(ctx context.Context, prompt string, maxTokens int, pro bool) (string, error) {
+	return s.generate(ctx, prompt, maxTokens, pro, nil)
+}
+
+// completeJSON is like complete but asks the provider for a JSON object matching schema,
+// using the provider's native JSON mode
+func (s *AIService) completeJSON(ctx context.Context, prompt string, maxTokens int, schema map[string]interface{}) (string, error) {
+	return s.generate(ctx, prompt, maxTokens, true, schema)
+}
+
+func (s *AIService) generate(ctx context.Context, prompt string, maxTokens int, pro bool, schema map[string]interface{}) (string, error) {
+	var lastErr error
+	for i, provider := range s.textProviders() {
+		if i > 0 {
+			fmt.Printf("Falling back to %s after error: %v\n", provider, lastErr)
+		}
+
+		// The provider checks the cache before its circuit breaker, so an open circuit
+		// still serves cached answers
+		text, err := s.callProvider(ctx, provider, prompt, maxTokens, pro, schema)
+		if err == nil {
+			return text, nil
+		}
+		lastErr = err
+
+		if ctx.Err() != nil {
+			break
+		}
+	}
+	return "", lastErr
+}
+
+func (s *AIService) callProvider(ctx context.Context, provider, prompt string, maxTokens int, pro bool, schema map[string]interface{}) (string, error) {
+	switch provider {
+	case providerClaude:
+		return s.callClaude(ctx, prompt, maxTokens, schema)
+	case providerGemini:
+		if pro {
+			return s.callGeminiPro(ctx, prompt, maxTokens, schema)
+		}
+		return s.callGemini(ctx, prompt, maxTokens, schema)
+	default:
+		return s.callChatGPT(ctx, prompt, maxTokens, schema)
+	}
+}
+
+// ProviderStatuses reports configuration, circuit state and rate limit of each provider
+func (s *AIService) ProviderStatuses() []models.ProviderStatus {
+	primary := s.primaryProvider()
+	statuses := []models.ProviderStatus{}
+	for _, provider := range []string{providerClaude, providerGemini, providerOpenAI} {
+		role := "unused"
+		if provider == primary {
+			role = "primary"
+		} else if provider == s.fallbackProvider {
+			role = "fallback"
+		}
+
+		state, failures, lastError := s.guards.breakers[provider].status()
+		statuses = append(statuses, models.ProviderStatus{
+			Provider:          provider,
+			Role:              role,
+			Configured:        s.providerConfigured(provider),
+			CircuitState:      string(state),
+			ConsecutiveErrors: failures,
+			LastError:         lastError,
+			RequestsPerMinute: s.guards.rpm[provider],
+		})
+	}
+	return statuses
+}
+
+// envInt reads a positive integer from the environment, falling back to def when the
+// variable is unset, malformed or not positive
+func envInt(name string, def int) int {
+	n := envCount(name, def)
+	if n == 0 {
+		fmt.Printf("Warning: %s must be positive, using %d\n", name, def)
+		return def
+	}
+	return n
+}
+
+// envCount is like envInt but accepts zero, for settings where zero turns a feature off
+func envCount(name string, def int) int {
+	v := os.Getenv(name)
+	if v == "" {
+		return def
+	}
+	n, err := strconv.Atoi(v)
+	if err != nil || n < 0 {
+		fmt.Printf("Warning: invalid %s %q, using %d\n", name, v, def)
+		return def
+	}
+	return n
+}
+
+// envDuration reads a positive duration such as "90s" from the environment, falling back
+// to def when the variable is unset, malformed or not positive
+func envDuration(name string, def time.Duration) time.Duration {
+	v := os.Getenv(name)
+	if v == "" {
+		return def
+	}
+	d, err := time.ParseDuration(v)
+	if err != nil || d <= 0 {
+		fmt.Printf("Warning: invalid %s %q, using %s\n", name, v, def)
+		return def
+	}
+	return d
+}
@@ -0,0 +1,234 @@
+package services
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+)
+
+func TestNewTokenBucket(t *testing.T) {
+	tests := []struct {
+		rpm          int
+		wantCapacity float64 // 0 for no bucket
+	}{
+		{0, 0},
+		{-5, 0},
+		{1, 1},
+		{8, 3},
+		{60, 15},
+		{600, 150},
+	}
+	for _, tt := range tests {
+		b := newTokenBucket(tt.rpm)
+		if tt.wantCapacity == 0 {
+			if b != nil {
+				t.Errorf("newTokenBucket(%d) = %+v, want nil", tt.rpm, b)
+			}
+			continue
+		}
+		if b == nil || b.capacity != tt.wantCapacity || b.tokens != tt.wantCapacity || b.rate != float64(tt.rpm)/60 {
+			t.Errorf("newTokenBucket(%d) = %+v, want capacity %v", tt.rpm, b, tt.wantCapacity)
+		}
+	}
+}
+
+func TestTokenBucketWait(t *testing.T) {
+	b := newTokenBucket(60) // 15 tokens, refilled at one a second
+	for i := 0; i < 15; i++ {
+		if err := b.Wait(context.Background()); err != nil {
+			t.Fatalf("wait %d: %v", i, err)
+		}
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), 20*time.Millisecond)
+	defer cancel()
+	if err := b.Wait(ctx); !errors.Is(err, context.DeadlineExceeded) {
+		t.Fatalf("empty bucket: err = %v, want a deadline error", err)
+	}
+
+	b.mu.Lock()
+	b.last = b.last.Add(-2 * time.Second)
+	b.mu.Unlock()
+	ctx, cancel = context.WithTimeout(context.Background(), 20*time.Millisecond)
+	defer cancel()
+	for i := 0; i < 2; i++ {
+		if err := b.Wait(ctx); err != nil {
+			t.Fatalf("refilled bucket, wait %d: %v", i, err)
+		}
+	}
+
+	var none *tokenBucket
+	if err := none.Wait(context.Background()); err != nil {
+		t.Fatalf("nil bucket: %v", err)
+	}
+}
+
+func TestCircuitBreakerTransitions(t *testing.T) {
+	transient := &ProviderError{Kind: ErrKindTransient, Message: "503"}
+	type step struct {
+		action    string // "allow", "success" or "failure"
+		err       error  // For failures; transient by default
+		wantAllow bool   // For allow
+		wantState breakerState
+	}
+	tests := []struct {
+		name     string
+		cooldown time.Duration
+		steps    []step
+	}{
+		{"opens at threshold", time.Hour, []step{
+			{action: "failure", wantState: breakerClosed},
+			{action: "allow", wantAllow: true, wantState: breakerClosed},
+			{action: "failure", wantState: breakerOpen},
+			{action: "allow", wantAllow: false, wantState: breakerOpen},
+		}},
+		{"success resets the count", time.Hour, []step{
+			{action: "failure", wantState: breakerClosed},
+			{action: "success", wantState: breakerClosed},
+			{action: "failure", wantState: breakerClosed},
+		}},
+		{"invalid requests ignored", time.Hour, []step{
+			{action: "failure", err: &ProviderError{Kind: ErrKindInvalid}, wantState: breakerClosed},
+			{action: "failure", err: &ProviderError{Kind: ErrKindInvalid}, wantState: breakerClosed},
+			{action: "failure", err: &ProviderError{Kind: ErrKindInvalid}, wantState: breakerClosed},
+		}},
+		{"auth opens at once", time.Hour, []step{
+			{action: "failure", err: &ProviderError{Kind: ErrKindAuth}, wantState: breakerOpen},
+		}},
+		{"quota opens at once", time.Hour, []step{
+			{action: "failure", err: &ProviderError{Kind: ErrKindQuotaExceeded}, wantState: breakerOpen},
+		}},
+		{"half-open trial succeeds", 0, []step{
+			{action: "failure", err: &ProviderError{Kind: ErrKindAuth}, wantState: breakerHalfOpen},
+			{action: "allow", wantAllow: true, wantState: breakerHalfOpen},
+			{action: "allow", wantAllow: false, wantState: breakerHalfOpen},
+			{action: "success", wantState: breakerClosed},
+			{action: "allow", wantAllow: true, wantState: breakerClosed},
+		}},
+		{"half-open trial fails", 0, []step{
+			{action: "failure", err: &ProviderError{Kind: ErrKindAuth}, wantState: breakerHalfOpen},
+			{action: "allow", wantAllow: true, wantState: breakerHalfOpen},
+			{action: "failure", wantState: breakerHalfOpen}, // Reopened, with a cooldown of zero
+			{action: "allow", wantAllow: true, wantState: breakerHalfOpen},
+		}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			b := newCircuitBreaker(2, tt.cooldown)
+			for i, s := range tt.steps {
+				switch s.action {
+				case "allow":
+					if got := b.Allow(); got != s.wantAllow {
+						t.Fatalf("step %d: Allow() = %v, want %v", i, got, s.wantAllow)
+					}
+				case "success":
+					b.RecordSuccess()
+				case "failure":
+					err := s.err
+					if err == nil {
+						err = transient
+					}
+					b.RecordFailure(err)
+				}
+				if state, _, _ := b.status(); state != s.wantState {
+					t.Fatalf("step %d (%s): state %s, want %s", i, s.action, state, s.wantState)
+				}
+			}
+		})
+	}
+}
+
+func TestBackoffDelay(t *testing.T) {
+	for attempt := 1; attempt <= 10; attempt++ {
+		ceiling := retryBaseDelay << (attempt - 1)
+		if ceiling > retryMaxDelay {
+			ceiling = retryMaxDelay
+		}
+		if got := backoffDelay(attempt, 0); got < ceiling/2 || got > ceiling {
+			t.Errorf("attempt %d: delay %s outside [%s, %s]", attempt, got, ceiling/2, ceiling)
+		}
+	}
+	if got := backoffDelay(1, 3*time.Second); got != 3*time.Second {
+		t.Errorf("Retry-After 3s: delay %s", got)
+	}
+	if got := backoffDelay(1, 2*maxRetryAfterWait); got >= 0 {
+		t.Errorf("long Retry-After: delay %s, want a negative delay to give up", got)
+	}
+}
+
+func TestDoProviderRequestRecordsBreakerOutcome(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path == "/fail" {
+			w.WriteHeader(http.StatusServiceUnavailable)
+			return
+		}
+		w.Write([]byte(`{}`))
+	}))
+	defer server.Close()
+
+	s := &AIService{client: server.Client(), guards: &providerGuards{
+		breakers: map[string]*circuitBreaker{providerGemini: newCircuitBreaker(1, time.Hour)},
+		limiters: map[string]*tokenBucket{},
+	}}
+	request := func(path string) func() (*http.Request, error) {
+		return func() (*http.Request, error) { return http.NewRequest("POST", server.URL+path, nil) }
+	}
+
+	if _, err := s.doProviderRequest(context.Background(), providerGemini, "m", request("/fail")); err == nil {
+		t.Fatal("expected an error from the failing endpoint")
+	}
+	if state, _, _ := s.guards.breakers[providerGemini].status(); state != breakerOpen {
+		t.Fatalf("state after failure = %s, want %s", state, breakerOpen)
+	}
+
+	sent := false
+	_, err := s.doProviderRequest(context.Background(), providerGemini, "m", func() (*http.Request, error) {
+		sent = true
+		return http.NewRequest("POST", server.URL, nil)
+	})
+	if ProviderErrorKindOf(err) != ErrKindCircuitOpen || sent {
+		t.Fatalf("open circuit: err = %v, sent = %v; want circuit_open without a request", err, sent)
+	}
+
+	s.guards.breakers[providerGemini] = newCircuitBreaker(1, 0)
+	s.guards.breakers[providerGemini].RecordFailure(errors.New("boom"))
+	if _, err := s.doProviderRequest(context.Background(), providerGemini, "m", request("/")); err != nil {
+		t.Fatalf("half-open trial: %v", err)
+	}
+	if state, failures, _ := s.guards.breakers[providerGemini].status(); state != breakerClosed || failures != 0 {
+		t.Fatalf("after successful trial: state %s, %d failures; want closed, 0", state, failures)
+	}
+}
+
+func TestEnvHelpers(t *testing.T) {
+	tests := []struct {
+		value     string
+		wantInt   int
+		wantCount int
+		wantDur   time.Duration
+	}{
+		{"", 5, 5, time.Minute},
+		{"3", 3, 3, time.Minute},
+		{"0", 5, 0, time.Minute},
+		{"-1", 5, 5, time.Minute},
+		{"abc", 5, 5, time.Minute},
+		{"90s", 5, 5, 90 * time.Second},
+		{"0s", 5, 5, time.Minute},
+		{"-5m", 5, 5, time.Minute},
+	}
+	for _, tt := range tests {
+		t.Setenv("SYNAPSE_TEST_SETTING", tt.value)
+		if got := envInt("SYNAPSE_TEST_SETTING", 5); got != tt.wantInt {
+			t.Errorf("envInt(%q) = %d, want %d", tt.value, got, tt.wantInt)
+		}
+		if got := envCount("SYNAPSE_TEST_SETTING", 5); got != tt.wantCount {
+			t.Errorf("envCount(%q) = %d, want %d", tt.value, got, tt.wantCount)
+		}
+		if got := envDuration("SYNAPSE_TEST_SETTING", time.Minute); got != tt.wantDur {
+			t.Errorf("envDuration(%q) = %s, want %s", tt.value, got, tt.wantDur)
+		}
+	}
+}
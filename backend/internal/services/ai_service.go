@@ -5,12 +5,12 @@ import (
 	"context"
 	"encoding/json"
 	"fmt"
-	"io"
 	"net/http"
 	"os"
 	"regexp"
 	"strings"
 	"synapse/internal/models"
+	"time"
 )
 
 type AIService struct {
@@ -20,9 +20,25 @@ type AIService struct {
 	claudeKey     string
 	claudeBaseURL string
 	client        *http.Client
+	cache         *LLMCache
+	usage         *UsageTracker
+	prompts       *PromptStore
+
+	// fallbackProvider receives text generation requests when the primary provider fails
+	fallbackProvider string
+	guards           *providerGuards
 }
 
-func NewAIService() *AIService {
+// completionTemperature is the sampling temperature used for every text completion
+const completionTemperature = 0.7
+
+// chatCompletionUsage is the token usage block of OpenAI-compatible responses (OpenAI, LiteLLM)
+type chatCompletionUsage struct {
+	PromptTokens     int `json:"prompt_tokens"`
+	CompletionTokens int `json:"completion_tokens"`
+}
+
+func NewAIService(cache *LLMCache, usage *UsageTracker, prompts *PromptStore) *AIService {
 	provider := os.Getenv("AI_PROVIDER")
 	if provider == "" {
 		provider = "claude" // Default to Claude
@@ -36,6 +52,12 @@ func NewAIService() *AIService {
 		claudeBaseURL = "https://litellm-339960399182.us-central1.run.app"
 	}
 
+	fallbackProvider := os.Getenv("AI_FALLBACK_PROVIDER")
+	if fallbackProvider == "" && provider == providerGemini && openaiKey != "" {
+		// Preserve the old behavior of falling back from Gemini to OpenAI when both keys exist
+		fallbackProvider = providerOpenAI
+	}
+
 	return &AIService{
 		provider:      provider,
 		geminiKey:     geminiKey,
@@ -43,52 +65,86 @@ func NewAIService() *AIService {
 		claudeKey:     claudeKey,
 		claudeBaseURL: claudeBaseURL,
 		client:        &http.Client{},
+		cache:         cache,
+		usage:         usage,
+		prompts:       prompts,
+
+		fallbackProvider: fallbackProvider,
+		guards:           newProviderGuards(),
 	}
 }
 
+// completionCacheKey returns the LLM cache key for a text completion
+func (s *AIService) completionCacheKey(provider, model, prompt string, maxTokens int, schema map[string]interface{}) string {
+	params := map[string]interface{}{
+		"max_tokens":  maxTokens,
+		"temperature": completionTemperature,
+	}
+	if schema != nil {
+		params["response_schema"] = schema
+	}
+	return s.cache.Key(provider, model, cacheKindCompletion, prompt, params)
+}
+
+// embeddingCacheKey returns the LLM cache key for an embedding
+func (s *AIService) embeddingCacheKey(provider, model, text string) string {
+	return s.cache.Key(provider, model, cacheKindEmbedding, text, nil)
+}
+
+// GenerateEmbedding embeds text with the primary provider. Embeddings never fail over:
+// vectors from different models aren't comparable, so mixing them would corrupt the collection.
 func (s *AIService) GenerateEmbedding(ctx context.Context, text string) ([]float32, error) {
-	// Use Claude/LiteLLM proxy for embeddings with gemini-embedding-001
-	if s.provider == "claude" && s.claudeKey != "" {
+	ctx = withAIOperation(ctx, OpEmbed)
+	switch s.primaryProvider() {
+	case providerClaude:
+		// Use Claude/LiteLLM proxy for embeddings with gemini-embedding-001
 		return s.generateEmbeddingClaude(ctx, text)
-	}
-	if s.provider == "gemini" {
+	case providerGemini:
 		return s.generateEmbeddingGemini(ctx, text)
+	default:
+		return s.generateEmbeddingOpenAI(ctx, text)
 	}
-	return s.generateEmbeddingOpenAI(ctx, text)
 }
 
 // generateEmbeddingClaude uses LiteLLM proxy with gemini-embedding-001 model
 func (s *AIService) generateEmbeddingClaude(ctx context.Context, text string) ([]float32, error) {
 	url := fmt.Sprintf("%s/v1/embeddings", s.claudeBaseURL)
 	
+	cacheKey := s.embeddingCacheKey("claude", "gemini-embedding-001", text)
+	if embedding, ok := s.cache.GetEmbedding(ctx, cacheKey); ok {
+		return embedding, nil
+	}
+	
 	payload := map[string]interface{}{
 		"input": text,
 		"model": "gemini-embedding-001",
 	}
 	
 	jsonData, _ := json.Marshal(payload)
-	req, _ := http.NewRequestWithContext(ctx, "POST", url, bytes.NewBuffer(jsonData))
-	req.Header.Set("Content-Type", "application/json")
-	req.Header.Set("Authorization", "Bearer "+s.claudeKey)
-	
-	resp, err := s.client.Do(req)
+	start := time.Now()
+	body, err := s.doProviderRequest(ctx, providerClaude, "gemini-embedding-001", func() (*http.Request, error) {
+		req, err := http.NewRequestWithContext(ctx, "POST", url, bytes.NewReader(jsonData))
+		if err != nil {
+			return nil, err
+		}
+		req.Header.Set("Content-Type", "application/json")
+		req.Header.Set("Authorization", "Bearer "+s.claudeKey)
+		return req, nil
+	})
 	if err != nil {
 		return nil, fmt.Errorf("failed to generate embedding: %w", err)
 	}
-	defer resp.Body.Close()
-	
-	if resp.StatusCode != http.StatusOK {
-		body, _ := io.ReadAll(resp.Body)
-		return nil, fmt.Errorf("Claude/LiteLLM API error: %s", string(body))
-	}
 	
 	var result struct {
 		Data []struct {
 			Embedding []float32 `json:"embedding"`
 		} `json:"data"`
+		Usage struct {
+			PromptTokens int `json:"prompt_tokens"`
+		} `json:"usage"`
 	}
 	
-	if err := json.NewDecoder(resp.Body).Decode(&result); err != nil {
+	if err := json.Unmarshal(body, &result); err != nil {
 		return nil, fmt.Errorf("failed to decode response: %w", err)
 	}
 	
@@ -96,12 +152,19 @@ func (s *AIService) generateEmbeddingClaude(ctx context.Context, text string) ([
 		return nil, fmt.Errorf("no embedding data returned")
 	}
 	
+	s.usage.Record(ctx, providerClaude, "gemini-embedding-001", result.Usage.PromptTokens, 0, time.Since(start), true)
+	s.cache.PutEmbedding(ctx, cacheKey, "claude", "gemini-embedding-001", result.Data[0].Embedding)
 	return result.Data[0].Embedding, nil
 }
 
 func (s *AIService) generateEmbeddingGemini(ctx context.Context, text string) ([]float32, error) {
 	// Gemini doesn't have a direct embeddings API, so we'll use text-embedding-004 model
-	url := fmt.Sprintf("https://generativelanguage.googleapis.com/v1beta/models/text-embedding-004:embedContent?key=%s", s.geminiKey)
+	url := "https://generativelanguage.googleapis.com/v1beta/models/text-embedding-004:embedContent"
+	
+	cacheKey := s.embeddingCacheKey("gemini", "text-embedding-004", text)
+	if embedding, ok := s.cache.GetEmbedding(ctx, cacheKey); ok {
+		return embedding, nil
+	}
 	
 	payload := map[string]interface{}{
 		"model": "models/text-embedding-004",
@@ -113,19 +176,19 @@ func (s *AIService) generateEmbeddingGemini(ctx context.Context, text string) ([
 	}
 	
 	jsonData, _ := json.Marshal(payload)
-	req, _ := http.NewRequestWithContext(ctx, "POST", url, bytes.NewBuffer(jsonData))
-	req.Header.Set("Content-Type", "application/json")
-	
-	resp, err := s.client.Do(req)
+	start := time.Now()
+	body, err := s.doProviderRequest(ctx, providerGemini, "text-embedding-004", func() (*http.Request, error) {
+		req, err := http.NewRequestWithContext(ctx, "POST", url, bytes.NewReader(jsonData))
+		if err != nil {
+			return nil, err
+		}
+		req.Header.Set("Content-Type", "application/json")
+		req.Header.Set("x-goog-api-key", s.geminiKey)
+		return req, nil
+	})
 	if err != nil {
 		return nil, fmt.Errorf("failed to generate embedding: %w", err)
 	}
-	defer resp.Body.Close()
-	
-	if resp.StatusCode != http.StatusOK {
-		body, _ := io.ReadAll(resp.Body)
-		return nil, fmt.Errorf("Gemini API error: %s", string(body))
-	}
 	
 	var result struct {
 		Embedding struct {
@@ -133,7 +196,7 @@ func (s *AIService) generateEmbeddingGemini(ctx context.Context, text string) ([
 		} `json:"embedding"`
 	}
 	
-	if err := json.NewDecoder(resp.Body).Decode(&result); err != nil {
+	if err := json.Unmarshal(body, &result); err != nil {
 		return nil, fmt.Errorf("failed to decode response: %w", err)
 	}
 	
@@ -141,50 +204,50 @@ func (s *AIService) generateEmbeddingGemini(ctx context.Context, text string) ([
 		return nil, fmt.Errorf("no embedding data returned")
 	}
 	
+	// embedContent doesn't report token usage
+	s.usage.Record(ctx, providerGemini, "text-embedding-004", estimateTokens(text), 0, time.Since(start), true)
+	s.cache.PutEmbedding(ctx, cacheKey, "gemini", "text-embedding-004", result.Embedding.Values)
 	return result.Embedding.Values, nil
 }
 
 func (s *AIService) generateEmbeddingOpenAI(ctx context.Context, text string) ([]float32, error) {
 	url := "https://api.openai.com/v1/embeddings"
 	
+	cacheKey := s.embeddingCacheKey("openai", "text-embedding-3-small", text)
+	if embedding, ok := s.cache.GetEmbedding(ctx, cacheKey); ok {
+		return embedding, nil
+	}
+	
 	payload := map[string]interface{}{
 		"input": text,
 		"model": "text-embedding-3-small",
 	}
 	
 	jsonData, _ := json.Marshal(payload)
-	req, _ := http.NewRequestWithContext(ctx, "POST", url, bytes.NewBuffer(jsonData))
-	req.Header.Set("Content-Type", "application/json")
-	req.Header.Set("Authorization", "Bearer "+s.openaiKey)
-	
-	resp, err := s.client.Do(req)
+	start := time.Now()
+	body, err := s.doProviderRequest(ctx, providerOpenAI, "text-embedding-3-small", func() (*http.Request, error) {
+		req, err := http.NewRequestWithContext(ctx, "POST", url, bytes.NewReader(jsonData))
+		if err != nil {
+			return nil, err
+		}
+		req.Header.Set("Content-Type", "application/json")
+		req.Header.Set("Authorization", "Bearer "+s.openaiKey)
+		return req, nil
+	})
 	if err != nil {
 		return nil, fmt.Errorf("failed to generate embedding: %w", err)
 	}
-	defer resp.Body.Close()
-	
-	if resp.StatusCode != http.StatusOK {
-		body, _ := io.ReadAll(resp.Body)
-		var apiError struct {
-			Error struct {
-				Message string `json:"message"`
-				Type    string `json:"type"`
-				Code    string `json:"code"`
-			} `json:"error"`
-		}
-		if err := json.Unmarshal(body, &apiError); err == nil && apiError.Error.Message != "" {
-			return nil, fmt.Errorf("OpenAI API error: %s (code: %s)", apiError.Error.Message, apiError.Error.Code)
-		}
-		return nil, fmt.Errorf("OpenAI API error: %s", string(body))
-	}
 	
 	var result struct {
 		Data []struct {
 			Embedding []float32 `json:"embedding"`
 		} `json:"data"`
+		Usage struct {
+			PromptTokens int `json:"prompt_tokens"`
+		} `json:"usage"`
 	}
 	
-	if err := json.NewDecoder(resp.Body).Decode(&result); err != nil {
+	if err := json.Unmarshal(body, &result); err != nil {
 		return nil, fmt.Errorf("failed to decode response: %w", err)
 	}
 	
@@ -192,47 +255,35 @@ func (s *AIService) generateEmbeddingOpenAI(ctx context.Context, text string) ([
 		return nil, fmt.Errorf("no embedding data returned")
 	}
 	
+	s.usage.Record(ctx, providerOpenAI, "text-embedding-3-small", result.Usage.PromptTokens, 0, time.Since(start), true)
+	s.cache.PutEmbedding(ctx, cacheKey, "openai", "text-embedding-3-small", result.Data[0].Embedding)
 	return result.Data[0].Embedding, nil
 }
 
 func (s *AIService) SummarizeContent(ctx context.Context, content string) (string, error) {
-	prompt := fmt.Sprintf(
-		"Summarize the following content in 2-3 concise sentences. Focus on the key points:\n\n%s",
-		content,
-	)
-	
-	if s.provider == "claude" && s.claudeKey != "" {
-		return s.callClaude(ctx, prompt, 150)
-	}
-	if s.provider == "gemini" {
-		return s.callGeminiPro(ctx, prompt, 150)
+	prompt, err := s.prompts.Render(PromptSummarize, contentPromptData{Content: content})
+	if err != nil {
+		return "", err
 	}
-	return s.callChatGPT(ctx, prompt, 150)
+	
+	return s.complete(withAIOperation(ctx, OpSummary), prompt, 150, true)
 }
 
-func (s *AIService) GenerateTags(ctx context.Context, content string) ([]string, error) {
+// GenerateTags extracts tags from content. knownTags are existing tags the AI is asked
+// to reuse when they fit, keeping the vocabulary small.
+func (s *AIService) GenerateTags(ctx context.Context, content string, knownTags []string) ([]string, error) {
 	// Truncate content if too long
 	truncated := content
 	if len(content) > 2000 {
 		truncated = content[:2000]
 	}
 	
-	prompt := fmt.Sprintf(
-		"Extract 3-5 relevant tags for this content. Return only comma-separated tags, no explanations, no numbering, just tags separated by commas:\n\n%s",
-		truncated,
-	)
-	
-	var response string
-	var err error
-	
-	if s.provider == "claude" && s.claudeKey != "" {
-		response, err = s.callClaude(ctx, prompt, 50)
-	} else if s.provider == "gemini" {
-		response, err = s.callGemini(ctx, prompt, 50)
-	} else {
-		response, err = s.callChatGPT(ctx, prompt, 50)
+	prompt, err := s.prompts.Render(PromptTags, tagsPromptData{Content: truncated, KnownTags: knownTags})
+	if err != nil {
+		return nil, err
 	}
 	
+	response, err := s.complete(withAIOperation(ctx, OpTags), prompt, 50, false)
 	if err != nil {
 		return nil, err
 	}
@@ -255,29 +306,18 @@ func (s *AIService) GenerateTags(ctx context.Context, content string) ([]string,
 // EnhanceSearchQuery uses Claude to understand and enhance search queries
 // Converts plain English into searchable terms with synonyms and related concepts
 func (s *AIService) EnhanceSearchQuery(ctx context.Context, query string) (string, error) {
-	prompt := fmt.Sprintf(`You are a search query enhancement assistant. Your goal is to help users find content even when they use plain English that doesn't match exact words in the content.
-
-Analyze the following search query and return an improved search query that will find relevant content using semantic understanding.
-
-Examples:
-- "things about AI" → "artificial intelligence machine learning neural networks AI"
-- "cooking ideas" → "recipes cooking food preparation ingredients"
-- "workout tips" → "exercise fitness training health workout"
-- "money saving" → "budget savings finance frugal economical"
-
-Your task:
-1. Understand the user's intent and what they're really looking for
-2. Expand with relevant synonyms, related terms, and alternative phrasings
-3. Include both formal and informal terms
-4. Keep the original meaning but add searchable keywords
-5. Return ONLY the enhanced query with expanded terms, nothing else
-
-Original query: "%s"
-
-Enhanced query:`, query)
+	// Query enhancement is non-essential: skip it once the monthly budget is spent
+	if s.usage.BudgetExceeded(ctx) {
+		return query, nil
+	}
+	
+	prompt, err := s.prompts.Render(PromptEnhanceQuery, enhanceQueryPromptData{Query: query})
+	if err != nil {
+		return query, nil
+	}
 	
 	if s.provider == "claude" && s.claudeKey != "" {
-		enhanced, err := s.callClaude(ctx, prompt, 150)
+		enhanced, err := s.complete(withAIOperation(ctx, OpEnhance), prompt, 150, false)
 		if err == nil && enhanced != "" {
 			return enhanced, nil
 		}
@@ -288,33 +328,31 @@ Enhanced query:`, query)
 
 // ReRankSearchResults uses Claude to re-rank search results by relevance
 func (s *AIService) ReRankSearchResults(ctx context.Context, query string, results []models.SearchResult, topK int) ([]models.SearchResult, error) {
-	if len(results) == 0 {
+	// Re-ranking is non-essential: skip it once the monthly budget is spent
+	if len(results) == 0 || s.usage.BudgetExceeded(ctx) {
 		return results, nil
 	}
 	
-	// Build context for Claude
-	var itemsContext strings.Builder
-	itemsContext.WriteString(fmt.Sprintf("Search query: %s\n\n", query))
-	itemsContext.WriteString("Search results to rank:\n")
-	
+	data := rerankPromptData{Query: query}
 	for i, result := range results {
 		if i >= 10 { // Limit to top 10 for Claude context
 			break
 		}
-		itemsContext.WriteString(fmt.Sprintf("%d. Title: %s\n   Summary: %s\n   Type: %s\n\n", 
-			i+1, result.Item.Title, result.Item.Summary, result.Item.Type))
+		data.Results = append(data.Results, rerankPromptResult{
+			Number:  i + 1,
+			Title:   result.Item.Title,
+			Summary: result.Item.Summary,
+			Type:    result.Item.Type,
+		})
 	}
 	
-	prompt := fmt.Sprintf(`You are a search result ranking assistant. Given a search query and a list of search results, rank them by relevance to the query.
-
-%s
-
-Return ONLY a comma-separated list of numbers (1, 2, 3, etc.) representing the order of relevance, with the most relevant first. For example: "3,1,5,2,4"
-
-Ranked order:`, itemsContext.String())
+	prompt, err := s.prompts.Render(PromptRerank, data)
+	if err != nil {
+		return results, nil
+	}
 	
 	if s.provider == "claude" && s.claudeKey != "" {
-		rankedOrder, err := s.callClaude(ctx, prompt, 50)
+		rankedOrder, err := s.complete(withAIOperation(ctx, OpRerank), prompt, 50, false)
 		if err != nil {
 			// If Claude fails, return original order
 			return results, nil
@@ -373,63 +411,41 @@ func parseRankedIndices(rankedOrder string, maxLen int) []int {
 	return indices
 }
 
-// CategorizeContent uses AI to automatically categorize content into sections
-func (s *AIService) CategorizeContent(ctx context.Context, title, content, itemType string) (string, error) {
+// CategorizeContent uses AI to automatically categorize content into one of categories.
+// Answers that name no known category (or alias) are rejected.
+func (s *AIService) CategorizeContent(ctx context.Context, title, content, itemType string, categories []models.Category) (string, error) {
 	// Truncate content if too long
 	truncated := content
 	if len(content) > 1500 {
 		truncated = content[:1500]
 	}
 	
-	prompt := fmt.Sprintf(
-		`Categorize this content into ONE of these specific sections:
-- Technology
-- Food & Recipes
-- Books & Reading
-- Videos & Entertainment
-- Shopping & Products
-- Articles & News
-- Notes & Ideas
-- Design & Inspiration
-- Travel
-- Health & Fitness
-- Education & Learning
-- Other
-
-Title: %s
-Type: %s
-Content: %s
-
-Return ONLY the category name, nothing else.`,
-		title, itemType, truncated,
-	)
-	
-	var response string
-	var err error
-	
-	if s.provider == "claude" && s.claudeKey != "" {
-		response, err = s.callClaude(ctx, prompt, 20)
-	} else if s.provider == "gemini" {
-		response, err = s.callGemini(ctx, prompt, 20)
-	} else {
-		response, err = s.callChatGPT(ctx, prompt, 20)
+	prompt, err := s.prompts.Render(PromptCategorize, categorizePromptData{Title: title, Type: itemType, Content: truncated, Categories: categories})
+	if err != nil {
+		return "", err
 	}
 	
+	response, err := s.complete(withAIOperation(ctx, OpCategory), prompt, 20, false)
 	if err != nil {
 		return "", err
 	}
 	
-	category := strings.TrimSpace(response)
+	answer := strings.TrimSpace(response)
 	// Clean up any extra text
-	if strings.Contains(category, "\n") {
-		category = strings.Split(category, "\n")[0]
+	if strings.Contains(answer, "\n") {
+		answer = strings.Split(answer, "\n")[0]
 	}
+	answer = strings.Trim(answer, " .\"'-*")
 	
-	return category, nil
+	category := findCategory(categories, answer)
+	if category == nil {
+		return "", fmt.Errorf("AI returned unknown category %q", answer)
+	}
+	return category.Name, nil
 }
 
 // GenerateSemanticSummary creates a concise semantic summary optimized for search
-// Uses Claude via LiteLLM proxy, falls back to Gemini/OpenAI if needed
+// Uses the primary provider (Gemini Pro models for Gemini), failing over to AI_FALLBACK_PROVIDER
 func (s *AIService) GenerateSemanticSummary(ctx context.Context, title, content string) (string, error) {
 	// Truncate content if too long
 	truncated := content
@@ -437,38 +453,16 @@ func (s *AIService) GenerateSemanticSummary(ctx context.Context, title, content
 		truncated = content[:3000]
 	}
 	
-	prompt := fmt.Sprintf(
-		`Create a concise semantic summary (2-3 sentences) of this content that captures key concepts, topics, and ideas. This summary will be used for search, so include important keywords and concepts:
-    
-    Title: %s
-    Content: %s
-    
-    Summary:`,
-		title, truncated,
-	)
-	
-	// Use Claude if available
-	if s.provider == "claude" && s.claudeKey != "" {
-		return s.callClaude(ctx, prompt, 200)
+	prompt, err := s.prompts.Render(PromptSemanticSummary, semanticSummaryPromptData{Title: title, Content: truncated})
+	if err != nil {
+		return "", err
 	}
 	
-	// Try Gemini first (if provider is gemini)
-	if s.provider == "gemini" {
-		summary, err := s.callGeminiPro(ctx, prompt, 200)
-		// If Gemini fails due to quota/rate limit and OpenAI is available, fallback to OpenAI
-		if err != nil && s.openaiKey != "" {
-			if strings.Contains(err.Error(), "quota") || strings.Contains(err.Error(), "429") || strings.Contains(err.Error(), "rate limit") || strings.Contains(err.Error(), "503") {
-				fmt.Printf("Gemini quota exceeded, falling back to OpenAI for summary generation\n")
-				return s.callChatGPT(ctx, prompt, 200)
-			}
-		}
-		return summary, err
-	}
-	return s.callChatGPT(ctx, prompt, 200)
+	return s.complete(withAIOperation(ctx, OpSummary), prompt, 200, true)
 }
 
 // SummarizeYouTubeVideo generates a short summary for a YouTube video
-// Uses Claude via LiteLLM proxy, falls back to Gemini/OpenAI if needed
+// Uses the primary provider (Gemini Pro models for Gemini), failing over to AI_FALLBACK_PROVIDER
 func (s *AIService) SummarizeYouTubeVideo(ctx context.Context, videoURL, title, description string) (string, error) {
 	// Truncate description if too long (keep it reasonable for the API)
 	truncatedDesc := description
@@ -476,38 +470,16 @@ func (s *AIService) SummarizeYouTubeVideo(ctx context.Context, videoURL, title,
 		truncatedDesc = description[:5000] + "..."
 	}
 	
-	prompt := fmt.Sprintf(
-		`Create a SHORT, concise summary (2-3 sentences maximum) of this YouTube video. Focus only on the main topic and key points. Be brief and informative.
-
-Video Title: %s
-Video Description: %s
-
-Provide a brief summary:`,
-		title, truncatedDesc,
-	)
-	
-	// Use Claude if available
-	if s.provider == "claude" && s.claudeKey != "" {
-		return s.callClaude(ctx, prompt, 150)
+	prompt, err := s.prompts.Render(PromptYouTubeSummary, youtubeSummaryPromptData{Title: title, Description: truncatedDesc})
+	if err != nil {
+		return "", err
 	}
 	
-	// Try Gemini first (if provider is gemini)
-	if s.provider == "gemini" {
-		summary, err := s.callGeminiPro(ctx, prompt, 150)
-		// If Gemini fails due to quota/rate limit and OpenAI is available, fallback to OpenAI
-		if err != nil && s.openaiKey != "" {
-			if strings.Contains(err.Error(), "quota") || strings.Contains(err.Error(), "429") || strings.Contains(err.Error(), "rate limit") || strings.Contains(err.Error(), "503") {
-				fmt.Printf("Gemini quota exceeded, falling back to OpenAI for summary generation\n")
-				return s.callChatGPT(ctx, prompt, 150)
-			}
-		}
-		return summary, err
-	}
-	return s.callChatGPT(ctx, prompt, 150)
+	return s.complete(withAIOperation(ctx, OpSummary), prompt, 150, true)
 }
 
 // callGeminiPro specifically uses Gemini 2.5 Pro for better quality summaries
-func (s *AIService) callGeminiPro(ctx context.Context, prompt string, maxTokens int) (string, error) {
+func (s *AIService) callGeminiPro(ctx context.Context, prompt string, maxTokens int, schema map[string]interface{}) (string, error) {
 	// Prioritize Gemini 2.5 Pro for summaries, with fallbacks
 	// Try v1 API first, then v1beta, with multiple model options
 	models := []struct {
@@ -522,10 +494,10 @@ func (s *AIService) callGeminiPro(ctx context.Context, prompt string, maxTokens
 		{"v1beta", "gemini-2.5-pro-preview-06-05"},
 	}
 	
-	return s.callGeminiWithModels(ctx, prompt, maxTokens, models)
+	return s.callGeminiWithModels(ctx, prompt, maxTokens, schema, models)
 }
 
-func (s *AIService) callGemini(ctx context.Context, prompt string, maxTokens int) (string, error) {
+func (s *AIService) callGemini(ctx context.Context, prompt string, maxTokens int, schema map[string]interface{}) (string, error) {
 	// Try multiple model names and API versions as fallback
 	// Updated to use Gemini 2.5 models which are currently available
 	models := []struct {
@@ -540,14 +512,23 @@ func (s *AIService) callGemini(ctx context.Context, prompt string, maxTokens int
 		{"v1beta", "gemini-1.5-pro-latest"},
 	}
 	
-	return s.callGeminiWithModels(ctx, prompt, maxTokens, models)
+	return s.callGeminiWithModels(ctx, prompt, maxTokens, schema, models)
 }
 
-func (s *AIService) callGeminiWithModels(ctx context.Context, prompt string, maxTokens int, models []struct {
+func (s *AIService) callGeminiWithModels(ctx context.Context, prompt string, maxTokens int, schema map[string]interface{}, models []struct {
 	apiVersion string
 	modelName  string
 }) (string, error) {
 	
+	generationConfig := map[string]interface{}{
+		"maxOutputTokens": maxTokens,
+		"temperature":     completionTemperature,
+	}
+	if schema != nil {
+		generationConfig["responseMimeType"] = "application/json"
+		generationConfig["responseSchema"] = geminiSchema(schema)
+	}
+	
 	payload := map[string]interface{}{
 		"contents": []map[string]interface{}{
 			{
@@ -556,109 +537,93 @@ func (s *AIService) callGeminiWithModels(ctx context.Context, prompt string, max
 				},
 			},
 		},
-		"generationConfig": map[string]interface{}{
-			"maxOutputTokens": maxTokens,
-			"temperature":     0.7,
-		},
+		"generationConfig": generationConfig,
 	}
 	
 	jsonData, _ := json.Marshal(payload)
 	
 	var lastErr error
 	for _, model := range models {
-		url := fmt.Sprintf("https://generativelanguage.googleapis.com/%s/models/%s:generateContent?key=%s", 
-			model.apiVersion, model.modelName, s.geminiKey)
-		
-		req, _ := http.NewRequestWithContext(ctx, "POST", url, bytes.NewBuffer(jsonData))
-		req.Header.Set("Content-Type", "application/json")
-		
-		resp, err := s.client.Do(req)
-		if err != nil {
-			lastErr = fmt.Errorf("failed to call Gemini API: %w", err)
-			continue
+		cacheKey := s.completionCacheKey("gemini", model.modelName, prompt, maxTokens, schema)
+		if text, ok := s.cache.GetCompletion(ctx, cacheKey); ok {
+			return text, nil
 		}
 		
-		body, _ := io.ReadAll(resp.Body)
-		resp.Body.Close()
+		url := fmt.Sprintf("https://generativelanguage.googleapis.com/%s/models/%s:generateContent",
+			model.apiVersion, model.modelName)
 		
-		if resp.StatusCode == http.StatusOK {
-			var result struct {
-				Candidates []struct {
-					Content struct {
-						Parts []struct {
-							Text string `json:"text"`
-						} `json:"parts"`
-					} `json:"content"`
-				} `json:"candidates"`
-				Error *struct {
-					Code    int    `json:"code"`
-					Message string `json:"message"`
-					Status  string `json:"status"`
-				} `json:"error"`
-			}
-			
-			if err := json.Unmarshal(body, &result); err != nil {
-				lastErr = fmt.Errorf("failed to decode response: %w", err)
-				continue
+		start := time.Now()
+		body, err := s.doProviderRequest(ctx, providerGemini, model.modelName, func() (*http.Request, error) {
+			req, err := http.NewRequestWithContext(ctx, "POST", url, bytes.NewReader(jsonData))
+			if err != nil {
+				return nil, err
 			}
-			
-			// Check for API errors in response
-			if result.Error != nil {
-				lastErr = fmt.Errorf("Gemini API error (model: %s, code: %d): %s", model.modelName, result.Error.Code, result.Error.Message)
-				// If it's a temporary error (503, 429), continue to next model
-				if result.Error.Code == 503 || result.Error.Code == 429 {
-					continue
-				}
-				// For other errors, also continue to try next model
-				continue
-			}
-			
-			if len(result.Candidates) == 0 {
-				lastErr = fmt.Errorf("no candidates in response from model %s", model.modelName)
-				continue
-			}
-			
-			// Check if we have parts with text
-			if len(result.Candidates[0].Content.Parts) > 0 {
-				text := result.Candidates[0].Content.Parts[0].Text
-				if text != "" {
-					return strings.TrimSpace(text), nil
-				}
-			}
-			
-			// If no text, return error
-			if len(result.Candidates) > 0 {
-				lastErr = fmt.Errorf("no text content in response from model %s", model.modelName)
-			} else {
-				lastErr = fmt.Errorf("empty response from model %s", model.modelName)
+			req.Header.Set("Content-Type", "application/json")
+			req.Header.Set("x-goog-api-key", s.geminiKey)
+			return req, nil
+		})
+		if err != nil {
+			lastErr = err
+			// Each Gemini model has its own quota, so only a bad key, an open circuit or a
+			// cancelled request ends the fallback chain
+			if kind := ProviderErrorKindOf(err); kind == ErrKindAuth || kind == ErrKindCircuitOpen || ctx.Err() != nil {
+				break
 			}
 			continue
 		}
 		
-		// Handle non-200 status codes
-		var apiError struct {
-			Error struct {
+		var result struct {
+			Candidates []struct {
+				Content struct {
+					Parts []struct {
+						Text string `json:"text"`
+					} `json:"parts"`
+				} `json:"content"`
+			} `json:"candidates"`
+			UsageMetadata struct {
+				PromptTokenCount     int `json:"promptTokenCount"`
+				CandidatesTokenCount int `json:"candidatesTokenCount"`
+			} `json:"usageMetadata"`
+			Error *struct {
 				Code    int    `json:"code"`
 				Message string `json:"message"`
 				Status  string `json:"status"`
 			} `json:"error"`
 		}
-		if err := json.Unmarshal(body, &apiError); err == nil && apiError.Error.Message != "" {
-			lastErr = fmt.Errorf("Gemini API error (model: %s, code: %d): %s", model.modelName, apiError.Error.Code, apiError.Error.Message)
-			// For temporary errors, continue to next model
-			if apiError.Error.Code == 503 || apiError.Error.Code == 429 {
-				continue
+		
+		if err := json.Unmarshal(body, &result); err != nil {
+			lastErr = &ProviderError{Provider: providerGemini, Model: model.modelName, Kind: ErrKindTransient, Message: "failed to decode response", Err: err}
+			continue
+		}
+		
+		// Check for API errors in response
+		if result.Error != nil {
+			lastErr = newPayloadProviderError(providerGemini, model.modelName, result.Error.Code, result.Error.Status, result.Error.Message)
+			s.guards.breakers[providerGemini].RecordFailure(lastErr)
+			s.usage.Record(ctx, providerGemini, model.modelName, 0, 0, time.Since(start), false)
+			continue
+		}
+		
+		// Tokens are billed even when the response has no usable text
+		s.usage.Record(ctx, providerGemini, model.modelName, result.UsageMetadata.PromptTokenCount, result.UsageMetadata.CandidatesTokenCount, time.Since(start), true)
+		
+		// Check if we have parts with text
+		if len(result.Candidates) > 0 && len(result.Candidates[0].Content.Parts) > 0 {
+			text := strings.TrimSpace(result.Candidates[0].Content.Parts[0].Text)
+			if text != "" {
+				s.cache.PutCompletion(ctx, cacheKey, "gemini", model.modelName, text)
+				return text, nil
 			}
-		} else {
-			lastErr = fmt.Errorf("Gemini API error (model: %s, status: %d): %s", model.modelName, resp.StatusCode, string(body))
 		}
+		
+		lastErr = &ProviderError{Provider: providerGemini, Model: model.modelName, Kind: ErrKindInvalid, Message: "no text content in response"}
 	}
 	
 	return "", fmt.Errorf("all Gemini models failed, last error: %w", lastErr)
 }
 
 // callClaude uses Claude API via LiteLLM proxy for text generation
-func (s *AIService) callClaude(ctx context.Context, prompt string, maxTokens int) (string, error) {
+func (s *AIService) callClaude(ctx context.Context, prompt string, maxTokens int, schema map[string]interface{}) (string, error) {
 	url := fmt.Sprintf("%s/v1/chat/completions", s.claudeBaseURL)
 	
 	// Try different Claude model names available via LiteLLM proxy
@@ -670,6 +635,11 @@ func (s *AIService) callClaude(ctx context.Context, prompt string, maxTokens int
 	
 	var lastErr error
 	for _, model := range models {
+		cacheKey := s.completionCacheKey("claude", model, prompt, maxTokens, schema)
+		if text, ok := s.cache.GetCompletion(ctx, cacheKey); ok {
+			return text, nil
+		}
+		
 		payload := map[string]interface{}{
 			"model": model,
 			"messages": []map[string]interface{}{
@@ -679,65 +649,69 @@ func (s *AIService) callClaude(ctx context.Context, prompt string, maxTokens int
 				},
 			},
 			"max_tokens": maxTokens,
-			"temperature": 0.7,
+			"temperature": completionTemperature,
+		}
+		if schema != nil {
+			// LiteLLM translates json_object into Anthropic's JSON mode; the schema itself is in the prompt
+			payload["response_format"] = map[string]interface{}{"type": "json_object"}
 		}
 		
 		jsonData, _ := json.Marshal(payload)
-		req, _ := http.NewRequestWithContext(ctx, "POST", url, bytes.NewBuffer(jsonData))
-		req.Header.Set("Content-Type", "application/json")
-		req.Header.Set("Authorization", "Bearer "+s.claudeKey)
-		
-		resp, err := s.client.Do(req)
+		start := time.Now()
+		body, err := s.doProviderRequest(ctx, providerClaude, model, func() (*http.Request, error) {
+			req, err := http.NewRequestWithContext(ctx, "POST", url, bytes.NewReader(jsonData))
+			if err != nil {
+				return nil, err
+			}
+			req.Header.Set("Content-Type", "application/json")
+			req.Header.Set("Authorization", "Bearer "+s.claudeKey)
+			return req, nil
+		})
 		if err != nil {
-			lastErr = fmt.Errorf("failed to call Claude API: %w", err)
+			lastErr = err
+			// A bad token (or an open circuit) fails every model the same way
+			if kind := ProviderErrorKindOf(err); kind == ErrKindAuth || kind == ErrKindCircuitOpen || ctx.Err() != nil {
+				break
+			}
 			continue
 		}
-		defer resp.Body.Close()
 		
-		if resp.StatusCode == http.StatusOK {
-			var result struct {
-				Choices []struct {
-					Message struct {
-						Content string `json:"content"`
-					} `json:"message"`
-				} `json:"choices"`
-			}
-			
-			if err := json.NewDecoder(resp.Body).Decode(&result); err != nil {
-				lastErr = fmt.Errorf("failed to decode response: %w", err)
-				continue
-			}
-			
-			if len(result.Choices) == 0 {
-				lastErr = fmt.Errorf("no response from Claude")
-				continue
-			}
-			
-			return strings.TrimSpace(result.Choices[0].Message.Content), nil
+		var result struct {
+			Choices []struct {
+				Message struct {
+					Content string `json:"content"`
+				} `json:"message"`
+			} `json:"choices"`
+			Usage chatCompletionUsage `json:"usage"`
 		}
 		
-		body, _ := io.ReadAll(resp.Body)
-		var apiError struct {
-			Error struct {
-				Message string `json:"message"`
-				Type    string `json:"type"`
-				Code    string `json:"code"`
-			} `json:"error"`
+		if err := json.Unmarshal(body, &result); err != nil {
+			lastErr = &ProviderError{Provider: providerClaude, Model: model, Kind: ErrKindTransient, Message: "failed to decode response", Err: err}
+			continue
 		}
-		if err := json.Unmarshal(body, &apiError); err == nil && apiError.Error.Message != "" {
-			lastErr = fmt.Errorf("Claude API error (model: %s): %s (code: %s)", model, apiError.Error.Message, apiError.Error.Code)
-			// Continue to next model if this one fails
+		s.usage.Record(ctx, providerClaude, model, result.Usage.PromptTokens, result.Usage.CompletionTokens, time.Since(start), true)
+		
+		if len(result.Choices) == 0 {
+			lastErr = &ProviderError{Provider: providerClaude, Model: model, Kind: ErrKindInvalid, Message: "no response from Claude"}
 			continue
 		}
-		lastErr = fmt.Errorf("Claude API error (model: %s): %s", model, string(body))
+		
+		text := strings.TrimSpace(result.Choices[0].Message.Content)
+		s.cache.PutCompletion(ctx, cacheKey, "claude", model, text)
+		return text, nil
 	}
 	
 	return "", fmt.Errorf("all Claude models failed, last error: %w", lastErr)
 }
 
-func (s *AIService) callChatGPT(ctx context.Context, prompt string, maxTokens int) (string, error) {
+func (s *AIService) callChatGPT(ctx context.Context, prompt string, maxTokens int, schema map[string]interface{}) (string, error) {
 	url := "https://api.openai.com/v1/chat/completions"
 	
+	cacheKey := s.completionCacheKey("openai", "gpt-4o-mini", prompt, maxTokens, schema)
+	if text, ok := s.cache.GetCompletion(ctx, cacheKey); ok {
+		return text, nil
+	}
+	
 	payload := map[string]interface{}{
 		"model": "gpt-4o-mini",
 		"messages": []map[string]string{
@@ -747,33 +721,31 @@ func (s *AIService) callChatGPT(ctx context.Context, prompt string, maxTokens in
 			},
 		},
 		"max_tokens": maxTokens,
-		"temperature": 0.7,
+		"temperature": completionTemperature,
+	}
+	if schema != nil {
+		payload["response_format"] = map[string]interface{}{
+			"type": "json_schema",
+			"json_schema": map[string]interface{}{
+				"name":   "response",
+				"schema": schema,
+			},
+		}
 	}
 	
 	jsonData, _ := json.Marshal(payload)
-	req, _ := http.NewRequestWithContext(ctx, "POST", url, bytes.NewBuffer(jsonData))
-	req.Header.Set("Content-Type", "application/json")
-	req.Header.Set("Authorization", "Bearer "+s.openaiKey)
-	
-	resp, err := s.client.Do(req)
-	if err != nil {
-		return "", fmt.Errorf("failed to call OpenAI API: %w", err)
-	}
-	defer resp.Body.Close()
-	
-	if resp.StatusCode != http.StatusOK {
-		body, _ := io.ReadAll(resp.Body)
-		var apiError struct {
-			Error struct {
-				Message string `json:"message"`
-				Type    string `json:"type"`
-				Code    string `json:"code"`
-			} `json:"error"`
-		}
-		if err := json.Unmarshal(body, &apiError); err == nil && apiError.Error.Message != "" {
-			return "", fmt.Errorf("OpenAI API error: %s (code: %s)", apiError.Error.Message, apiError.Error.Code)
+	start := time.Now()
+	body, err := s.doProviderRequest(ctx, providerOpenAI, "gpt-4o-mini", func() (*http.Request, error) {
+		req, err := http.NewRequestWithContext(ctx, "POST", url, bytes.NewReader(jsonData))
+		if err != nil {
+			return nil, err
 		}
-		return "", fmt.Errorf("OpenAI API error: %s", string(body))
+		req.Header.Set("Content-Type", "application/json")
+		req.Header.Set("Authorization", "Bearer "+s.openaiKey)
+		return req, nil
+	})
+	if err != nil {
+		return "", err
 	}
 	
 	var result struct {
@@ -782,15 +754,19 @@ func (s *AIService) callChatGPT(ctx context.Context, prompt string, maxTokens in
 				Content string `json:"content"`
 			} `json:"message"`
 		} `json:"choices"`
+		Usage chatCompletionUsage `json:"usage"`
 	}
 	
-	if err := json.NewDecoder(resp.Body).Decode(&result); err != nil {
-		return "", fmt.Errorf("failed to decode response: %w", err)
+	if err := json.Unmarshal(body, &result); err != nil {
+		return "", &ProviderError{Provider: providerOpenAI, Model: "gpt-4o-mini", Kind: ErrKindTransient, Message: "failed to decode response", Err: err}
 	}
+	s.usage.Record(ctx, providerOpenAI, "gpt-4o-mini", result.Usage.PromptTokens, result.Usage.CompletionTokens, time.Since(start), true)
 	
 	if len(result.Choices) == 0 {
-		return "", fmt.Errorf("no response from OpenAI")
+		return "", &ProviderError{Provider: providerOpenAI, Model: "gpt-4o-mini", Kind: ErrKindInvalid, Message: "no response from OpenAI"}
 	}
 	
-	return strings.TrimSpace(result.Choices[0].Message.Content), nil
+	text := strings.TrimSpace(result.Choices[0].Message.Content)
+	s.cache.PutCompletion(ctx, cacheKey, "openai", "gpt-4o-mini", text)
+	return text, nil
 }
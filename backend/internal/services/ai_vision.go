@@ -0,0 +1,295 @@
+package services
+
+import (
+	"bytes"
+	"context"
+	"encoding/base64"
+	"encoding/json"
+	"fmt"
+	"math"
+	"net/http"
+	"strings"
+	"synapse/internal/models"
+	"synapse/internal/storage"
+	"time"
+)
+
+// Vision-capable model of each provider
+var visionModels = map[string]string{
+	providerClaude: "claude-sonnet-4-5-20250929",
+	providerGemini: "gemini-2.5-flash",
+	providerOpenAI: "gpt-4o-mini",
+}
+
+// visionAvailable reports whether the primary provider has credentials for image prompts
+func (s *AIService) visionAvailable() bool {
+	return s.providerConfigured(s.primaryProvider())
+}
+
+// completeWithImage is like completeJSON (or complete, when schema is nil) for a prompt
+// about an image. It goes to the same providers with the same failover.
+func (s *AIService) completeWithImage(ctx context.Context, prompt string, image []byte, mimeType string, maxTokens int, schema map[string]interface{}) (string, error) {
+	if mimeType == "" {
+		mimeType = "image/jpeg"
+	}
+	var lastErr error
+	for i, provider := range s.textProviders() {
+		if i > 0 {
+			fmt.Printf("Falling back to %s after error: %v\n", provider, lastErr)
+		}
+
+		text, err := s.callVisionProvider(ctx, provider, prompt, image, mimeType, maxTokens, schema)
+		if err == nil {
+			return text, nil
+		}
+		lastErr = err
+
+		if ctx.Err() != nil {
+			break
+		}
+	}
+	return "", lastErr
+}
+
+func (s *AIService) callVisionProvider(ctx context.Context, provider, prompt string, image []byte, mimeType string, maxTokens int, schema map[string]interface{}) (string, error) {
+	model := visionModels[provider]
+	// The image is part of the prompt; its hash keeps the cache key small
+	params := map[string]interface{}{
+		"max_tokens":  maxTokens,
+		"temperature": completionTemperature,
+		"image":       storage.Hash(image),
+	}
+	if schema != nil {
+		params["response_schema"] = schema
+	}
+	cacheKey := s.cache.Key(provider, model, cacheKindCompletion, prompt, params)
+	if text, ok := s.cache.GetCompletion(ctx, cacheKey); ok {
+		return text, nil
+	}
+
+	var text string
+	var err error
+	switch provider {
+	case providerGemini:
+		text, err = s.callGeminiVision(ctx, model, prompt, image, mimeType, maxTokens, schema)
+	case providerClaude:
+		text, err = s.callChatVision(ctx, provider, model, s.claudeBaseURL+"/v1/chat/completions", s.claudeKey, prompt, image, mimeType, maxTokens, schema)
+	default:
+		text, err = s.callChatVision(ctx, provider, model, "https://api.openai.com/v1/chat/completions", s.openaiKey, prompt, image, mimeType, maxTokens, schema)
+	}
+	if err != nil {
+		return "", err
+	}
+	s.cache.PutCompletion(ctx, cacheKey, provider, model, text)
+	return text, nil
+}
+
+// callChatVision sends an image prompt to an OpenAI-compatible chat completions API
+// (OpenAI, or Claude through the LiteLLM proxy)
+func (s *AIService) callChatVision(ctx context.Context, provider, model, url, key, prompt string, image []byte, mimeType string, maxTokens int, schema map[string]interface{}) (string, error) {
+	dataURL := "data:" + mimeType + ";base64," + base64.StdEncoding.EncodeToString(image)
+	payload := map[string]interface{}{
+		"model": model,
+		"messages": []map[string]interface{}{
+			{
+				"role": "user",
+				"content": []map[string]interface{}{
+					{"type": "text", "text": prompt},
+					{"type": "image_url", "image_url": map[string]string{"url": dataURL}},
+				},
+			},
+		},
+		"max_tokens":  maxTokens,
+		"temperature": completionTemperature,
+	}
+	if schema != nil {
+		// Same JSON modes as callClaude and callChatGPT
+		if provider == providerOpenAI {
+			payload["response_format"] = map[string]interface{}{
+				"type":        "json_schema",
+				"json_schema": map[string]interface{}{"name": "response", "schema": schema},
+			}
+		} else {
+			payload["response_format"] = map[string]interface{}{"type": "json_object"}
+		}
+	}
+
+	jsonData, _ := json.Marshal(payload)
+	start := time.Now()
+	body, err := s.doProviderRequest(ctx, provider, model, func() (*http.Request, error) {
+		req, err := http.NewRequestWithContext(ctx, "POST", url, bytes.NewReader(jsonData))
+		if err != nil {
+			return nil, err
+		}
+		req.Header.Set("Content-Type", "application/json")
+		req.Header.Set("Authorization", "Bearer "+key)
+		return req, nil
+	})
+	if err != nil {
+		return "", err
+	}
+
+	var result struct {
+		Choices []struct {
+			Message struct {
+				Content string `json:"content"`
+			} `json:"message"`
+		} `json:"choices"`
+		Usage chatCompletionUsage `json:"usage"`
+	}
+	if err := json.Unmarshal(body, &result); err != nil {
+		return "", &ProviderError{Provider: provider, Model: model, Kind: ErrKindTransient, Message: "failed to decode response", Err: err}
+	}
+	s.usage.Record(ctx, provider, model, result.Usage.PromptTokens, result.Usage.CompletionTokens, time.Since(start), true)
+
+	if len(result.Choices) == 0 {
+		return "", &ProviderError{Provider: provider, Model: model, Kind: ErrKindInvalid, Message: "no response from vision model"}
+	}
+	return strings.TrimSpace(result.Choices[0].Message.Content), nil
+}
+
+// callGeminiVision sends an image prompt to Gemini as inline data
+func (s *AIService) callGeminiVision(ctx context.Context, model, prompt string, image []byte, mimeType string, maxTokens int, schema map[string]interface{}) (string, error) {
+	generationConfig := map[string]interface{}{
+		"maxOutputTokens": maxTokens,
+		"temperature":     completionTemperature,
+	}
+	if schema != nil {
+		generationConfig["responseMimeType"] = "application/json"
+		generationConfig["responseSchema"] = geminiSchema(schema)
+	}
+	payload := map[string]interface{}{
+		"contents": []map[string]interface{}{
+			{
+				"parts": []map[string]interface{}{
+					{"text": prompt},
+					{"inline_data": map[string]string{"mime_type": mimeType, "data": base64.StdEncoding.EncodeToString(image)}},
+				},
+			},
+		},
+		"generationConfig": generationConfig,
+	}
+
+	jsonData, _ := json.Marshal(payload)
+	url := fmt.Sprintf("https://generativelanguage.googleapis.com/v1beta/models/%s:generateContent", model)
+	start := time.Now()
+	body, err := s.doProviderRequest(ctx, providerGemini, model, func() (*http.Request, error) {
+		req, err := http.NewRequestWithContext(ctx, "POST", url, bytes.NewReader(jsonData))
+		if err != nil {
+			return nil, err
+		}
+		req.Header.Set("Content-Type", "application/json")
+		req.Header.Set("x-goog-api-key", s.geminiKey)
+		return req, nil
+	})
+	if err != nil {
+		return "", err
+	}
+
+	var result struct {
+		Candidates []struct {
+			Content struct {
+				Parts []struct {
+					Text string `json:"text"`
+				} `json:"parts"`
+			} `json:"content"`
+		} `json:"candidates"`
+		UsageMetadata struct {
+			PromptTokenCount     int `json:"promptTokenCount"`
+			CandidatesTokenCount int `json:"candidatesTokenCount"`
+		} `json:"usageMetadata"`
+		Error *struct {
+			Code    int    `json:"code"`
+			Message string `json:"message"`
+			Status  string `json:"status"`
+		} `json:"error"`
+	}
+	if err := json.Unmarshal(body, &result); err != nil {
+		return "", &ProviderError{Provider: providerGemini, Model: model, Kind: ErrKindTransient, Message: "failed to decode response", Err: err}
+	}
+	if result.Error != nil {
+		s.usage.Record(ctx, providerGemini, model, 0, 0, time.Since(start), false)
+		err := newPayloadProviderError(providerGemini, model, result.Error.Code, result.Error.Status, result.Error.Message)
+		s.guards.breakers[providerGemini].RecordFailure(err)
+		return "", err
+	}
+	s.usage.Record(ctx, providerGemini, model, result.UsageMetadata.PromptTokenCount, result.UsageMetadata.CandidatesTokenCount, time.Since(start), true)
+
+	if len(result.Candidates) == 0 || len(result.Candidates[0].Content.Parts) == 0 {
+		return "", &ProviderError{Provider: providerGemini, Model: model, Kind: ErrKindInvalid, Message: "no text content in response"}
+	}
+	return strings.TrimSpace(result.Candidates[0].Content.Parts[0].Text), nil
+}
+
+// ocrMaxTokens bounds the transcription of one image
+const ocrMaxTokens = 2000
+
+// ocrSchema is the JSON schema of the OCR response
+var ocrSchema = map[string]interface{}{
+	"type": "object",
+	"properties": map[string]interface{}{
+		"blocks": map[string]interface{}{
+			"type": "array",
+			"items": map[string]interface{}{
+				"type": "object",
+				"properties": map[string]interface{}{
+					"text":       map[string]interface{}{"type": "string"},
+					"confidence": map[string]interface{}{"type": "number"},
+				},
+				"required": []string{"text", "confidence"},
+			},
+		},
+	},
+	"required": []string{"blocks"},
+}
+
+// ReadImageText transcribes the text in an image with the provider's vision model.
+// languages are hints of the languages the text is in, e.g. "en".
+func (s *AIService) ReadImageText(ctx context.Context, image []byte, mimeType string, languages []string) ([]models.OCRBlock, error) {
+	prompt, err := s.prompts.Render(PromptOCR, ocrPromptData{Languages: languages})
+	if err != nil {
+		return nil, err
+	}
+
+	response, err := s.completeWithImage(withAIOperation(ctx, OpOCR), prompt, image, mimeType, ocrMaxTokens, ocrSchema)
+	if err != nil {
+		return nil, err
+	}
+
+	// Some models wrap JSON in a markdown code fence even in JSON mode
+	response = strings.TrimSpace(response)
+	response = strings.TrimPrefix(response, "```json")
+	response = strings.TrimPrefix(response, "```")
+	response = strings.TrimSuffix(response, "```")
+
+	var raw struct {
+		Blocks []models.OCRBlock `json:"blocks"`
+	}
+	if err := json.Unmarshal([]byte(strings.TrimSpace(response)), &raw); err != nil {
+		return nil, fmt.Errorf("invalid OCR JSON: %w", err)
+	}
+	blocks := []models.OCRBlock{}
+	for _, block := range raw.Blocks {
+		block.Text = strings.TrimSpace(block.Text)
+		if block.Text == "" {
+			continue
+		}
+		block.Confidence = math.Max(0, math.Min(1, block.Confidence))
+		block.Box = nil
+		blocks = append(blocks, block)
+	}
+	return blocks, nil
+}
+
+// captionMaxTokens bounds an image description
+const captionMaxTokens = 300
+
+// CaptionImage describes an image (objects, scene, visible text) with the provider's
+// vision model, so the image can be found by what it shows
+func (s *AIService) CaptionImage(ctx context.Context, image []byte, mimeType string) (string, error) {
+	prompt, err := s.prompts.Render(PromptCaption, struct{}{})
+	if err != nil {
+		return "", err
+	}
+	return s.completeWithImage(withAIOperation(ctx, OpCaption), prompt, image, mimeType, captionMaxTokens, nil)
+}
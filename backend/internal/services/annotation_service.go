@@ -0,0 +1,174 @@
+package services
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"strings"
+	"synapse/internal/models"
+	"synapse/internal/repository"
+	"unicode/utf8"
+
+	"github.com/google/uuid"
+	"github.com/jackc/pgx/v5"
+)
+
+var (
+	ErrAnnotationNotFound = errors.New("annotation not found")
+	ErrInvalidAnnotation  = errors.New("invalid annotation")
+)
+
+// AnnotationService manages highlights and notes on items. Their text is part of the
+// item's embedding, so the item is reindexed whenever they change.
+type AnnotationService struct {
+	repo     *repository.AnnotationRepository
+	itemRepo *repository.ItemRepository
+	indexer  *Indexer
+}
+
+func NewAnnotationService(repo *repository.AnnotationRepository, itemRepo *repository.ItemRepository, indexer *Indexer) *AnnotationService {
+	return &AnnotationService{repo: repo, itemRepo: itemRepo, indexer: indexer}
+}
+
+func (s *AnnotationService) ListForItem(ctx context.Context, itemID uuid.UUID) ([]models.Annotation, error) {
+	if _, err := s.item(ctx, itemID); err != nil {
+		return nil, err
+	}
+	return s.repo.ListForItem(ctx, itemID)
+}
+
+// Recent returns the latest highlights and notes across items
+func (s *AnnotationService) Recent(ctx context.Context, limit int) ([]models.Annotation, error) {
+	if limit <= 0 || limit > 200 {
+		limit = 50
+	}
+	return s.repo.Recent(ctx, limit)
+}
+
+func (s *AnnotationService) Create(ctx context.Context, itemID uuid.UUID, req *models.AnnotationRequest) (*models.Annotation, error) {
+	item, err := s.item(ctx, itemID)
+	if err != nil {
+		return nil, err
+	}
+
+	annotation := &models.Annotation{ItemID: itemID}
+	if err := applyAnnotationRequest(annotation, req, itemText(item)); err != nil {
+		return nil, err
+	}
+	if err := s.repo.Create(ctx, annotation); err != nil {
+		return nil, err
+	}
+	s.indexer.ReindexAsync(itemID)
+	return annotation, nil
+}
+
+func (s *AnnotationService) Update(ctx context.Context, id uuid.UUID, req *models.AnnotationRequest) (*models.Annotation, error) {
+	annotation, err := s.get(ctx, id)
+	if err != nil {
+		return nil, err
+	}
+	item, err := s.item(ctx, annotation.ItemID)
+	if err != nil {
+		return nil, err
+	}
+
+	if err := applyAnnotationRequest(annotation, req, itemText(item)); err != nil {
+		return nil, err
+	}
+	if err := s.repo.Update(ctx, annotation); err != nil {
+		return nil, err
+	}
+	s.indexer.ReindexAsync(annotation.ItemID)
+	return annotation, nil
+}
+
+func (s *AnnotationService) Delete(ctx context.Context, id uuid.UUID) error {
+	annotation, err := s.get(ctx, id)
+	if err != nil {
+		return err
+	}
+	if err := s.repo.Delete(ctx, id); err != nil {
+		return err
+	}
+	s.indexer.ReindexAsync(annotation.ItemID)
+	return nil
+}
+
+func (s *AnnotationService) get(ctx context.Context, id uuid.UUID) (*models.Annotation, error) {
+	annotation, err := s.repo.GetByID(ctx, id)
+	if errors.Is(err, pgx.ErrNoRows) {
+		return nil, ErrAnnotationNotFound
+	}
+	return annotation, err
+}
+
+func (s *AnnotationService) item(ctx context.Context, id uuid.UUID) (*models.Item, error) {
+	item, err := s.itemRepo.GetByID(ctx, id)
+	if errors.Is(err, pgx.ErrNoRows) {
+		return nil, ErrItemNotFound
+	}
+	return item, err
+}
+
+// applyAnnotationRequest validates req against the item's plain-text content and copies it into
+// annotation. A range without a quote takes the quote from the content, and a quote
+// without a range is located in the content when it occurs there.
+func applyAnnotationRequest(annotation *models.Annotation, req *models.AnnotationRequest, content string) error {
+	quote := strings.TrimSpace(req.Quote)
+	note := strings.TrimSpace(req.Note)
+
+	color := strings.ToLower(strings.TrimSpace(req.Color))
+	if color == "" {
+		color = "yellow"
+	}
+	if !containsString(models.AnnotationColors, color) {
+		return fmt.Errorf("%w: color must be one of %s", ErrInvalidAnnotation, strings.Join(models.AnnotationColors, ", "))
+	}
+
+	start, end := req.Start, req.End
+	if (start == nil) != (end == nil) {
+		return fmt.Errorf("%w: start and end must be given together", ErrInvalidAnnotation)
+	}
+	if start != nil {
+		length := utf8.RuneCountInString(content)
+		if *start < 0 || *end <= *start || *end > length {
+			return fmt.Errorf("%w: range %d-%d is outside the content (%d characters)", ErrInvalidAnnotation, *start, *end, length)
+		}
+		if quote == "" {
+			quote = string([]rune(content)[*start:*end])
+		}
+	} else if quote != "" {
+		start, end = locateQuote(content, req.Prefix, quote, req.Suffix)
+	}
+
+	if quote == "" && note == "" {
+		return fmt.Errorf("%w: a quote, a range or a note is required", ErrInvalidAnnotation)
+	}
+
+	annotation.Quote = quote
+	annotation.Prefix = req.Prefix
+	annotation.Suffix = req.Suffix
+	annotation.Start = start
+	annotation.End = end
+	annotation.Note = note
+	annotation.Color = color
+	return nil
+}
+
+// locateQuote returns the rune range of quote in content, preferring the occurrence
+// surrounded by prefix and suffix, or nils if it doesn't occur
+func locateQuote(content, prefix, quote, suffix string) (*int, *int) {
+	at := -1
+	if i := strings.Index(content, prefix+quote+suffix); i >= 0 && (prefix != "" || suffix != "") {
+		at = i + len(prefix)
+	} else if i := strings.Index(content, quote); i >= 0 {
+		at = i
+	}
+	if at < 0 {
+		return nil, nil
+	}
+
+	start := utf8.RuneCountInString(content[:at])
+	end := start + utf8.RuneCountInString(quote)
+	return &start, &end
+}
@@ -0,0 +1,32 @@
+package services
+
+import "testing"
+
+func TestLocateQuote(t *testing.T) {
+	tests := []struct {
+		name                           string
+		content, prefix, quote, suffix string
+		wantStart, wantEnd             int // -1 when the quote isn't found
+	}{
+		{"first occurrence", "one two one two", "", "two", "", 4, 7},
+		{"prefix picks occurrence", "one two one two", "one ", "two", "", 4, 7},
+		{"context picks later occurrence", "a cat. the cat sat", "the ", "cat", " sat", 11, 14},
+		{"stale context falls back", "a cat. the cat sat", "my ", "cat", "", 2, 5},
+		{"runes not bytes", "héllo wörld", "", "wörld", "", 6, 11},
+		{"missing", "hello", "", "bye", "", -1, -1},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			start, end := locateQuote(tt.content, tt.prefix, tt.quote, tt.suffix)
+			if tt.wantStart < 0 {
+				if start != nil || end != nil {
+					t.Fatalf("got range %d-%d, want none", *start, *end)
+				}
+				return
+			}
+			if start == nil || end == nil || *start != tt.wantStart || *end != tt.wantEnd {
+				t.Fatalf("got %v-%v, want %d-%d", start, end, tt.wantStart, tt.wantEnd)
+			}
+		})
+	}
+}
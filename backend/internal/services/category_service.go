@@ -0,0 +1,380 @@
+package services
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"strings"
+	"synapse/internal/models"
+	"synapse/internal/repository"
+	"sync"
+
+	"github.com/google/uuid"
+	"github.com/jackc/pgx/v5"
+	"github.com/jackc/pgx/v5/pgconn"
+)
+
+// FallbackCategory receives items nothing else fits. It can't be deleted or merged away.
+const FallbackCategory = "Other"
+
+var (
+	ErrCategoryNotFound  = errors.New("category not found")
+	ErrCategoryExists    = errors.New("a category with this name already exists")
+	ErrProtectedCategory = errors.New("the Other category cannot be renamed, deleted or merged")
+	ErrInvalidCategory   = errors.New("invalid category")
+	ErrCategoryInUse     = errors.New("category is used by smart collections")
+)
+
+// typeCategories maps item types to the category used when AI categorization fails
+var typeCategories = map[string]string{
+	"video":      "Videos & Entertainment",
+	"book":       "Books & Reading",
+	"recipe":     "Food & Recipes",
+	"amazon":     "Shopping & Products",
+	"blog":       "Articles & News",
+	"url":        "Articles & News",
+	"text":       "Notes & Ideas",
+	"image":      "Design & Inspiration",
+	"screenshot": "Notes & Ideas",
+}
+
+// CategoryService manages the category taxonomy and keeps an in-memory copy of it for
+// prompts, query parsing and validation
+type CategoryService struct {
+	repo      *repository.CategoryRepository
+	itemRepo  *repository.ItemRepository
+	aiService *AIService
+
+	mu         sync.RWMutex
+	categories []models.Category
+
+	recategorizeMu sync.Mutex
+}
+
+func NewCategoryService(repo *repository.CategoryRepository, itemRepo *repository.ItemRepository, aiService *AIService) *CategoryService {
+	return &CategoryService{
+		repo:      repo,
+		itemRepo:  itemRepo,
+		aiService: aiService,
+	}
+}
+
+// List returns every category with its item count
+func (s *CategoryService) List(ctx context.Context) ([]models.Category, error) {
+	categories, err := s.repo.List(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	s.mu.Lock()
+	s.categories = categories
+	s.mu.Unlock()
+	return categories, nil
+}
+
+// Categories returns the cached taxonomy, loading it on first use
+func (s *CategoryService) Categories(ctx context.Context) []models.Category {
+	s.mu.RLock()
+	categories := s.categories
+	s.mu.RUnlock()
+	if categories != nil {
+		return categories
+	}
+
+	categories, err := s.List(ctx)
+	if err != nil {
+		fmt.Printf("Warning: Failed to load categories: %v\n", err)
+		return []models.Category{{Name: FallbackCategory}}
+	}
+	return categories
+}
+
+// Resolve returns the canonical name of the category called name (or having name as an
+// alias), or "" if there is none
+func (s *CategoryService) Resolve(ctx context.Context, name string) string {
+	if c := findCategory(s.Categories(ctx), name); c != nil {
+		return c.Name
+	}
+	return ""
+}
+
+// DefaultFor returns the category used for an item when AI categorization fails
+func (s *CategoryService) DefaultFor(ctx context.Context, itemType string) string {
+	if name := s.Resolve(ctx, typeCategories[itemType]); name != "" {
+		return name
+	}
+	return FallbackCategory
+}
+
+// ImageKeyword returns the cover image search term of the category
+func (s *CategoryService) ImageKeyword(ctx context.Context, name string) string {
+	c := findCategory(s.Categories(ctx), name)
+	if c == nil {
+		return ""
+	}
+	if c.ImageKeyword != "" {
+		return c.ImageKeyword
+	}
+	return strings.ToLower(c.Name)
+}
+
+// Icon returns the icon of the category, or "" if there is no such category
+func (s *CategoryService) Icon(ctx context.Context, name string) string {
+	if c := findCategory(s.Categories(ctx), name); c != nil {
+		return c.Icon
+	}
+	return ""
+}
+
+func (s *CategoryService) Get(ctx context.Context, id uuid.UUID) (*models.Category, error) {
+	return s.get(ctx, id)
+}
+
+func (s *CategoryService) get(ctx context.Context, id uuid.UUID) (*models.Category, error) {
+	category, err := s.repo.GetByID(ctx, id)
+	if errors.Is(err, pgx.ErrNoRows) {
+		return nil, ErrCategoryNotFound
+	}
+	return category, err
+}
+
+// Create adds a category. Uncategorized items and items filed under Other are
+// recategorized in the background since the new category may suit them better.
+func (s *CategoryService) Create(ctx context.Context, req *models.CategoryRequest) (*models.Category, error) {
+	category := &models.Category{}
+	if err := s.apply(ctx, category, req); err != nil {
+		return nil, err
+	}
+
+	if err := s.repo.Create(ctx, category); err != nil {
+		return nil, categoryWriteError(err)
+	}
+	s.invalidate()
+
+	if ids, err := s.repo.ItemIDsInCategories(ctx, []string{FallbackCategory}); err == nil {
+		go s.recategorize(ids)
+	}
+	return category, nil
+}
+
+// Update changes a category. Renaming moves its items to the new name.
+func (s *CategoryService) Update(ctx context.Context, id uuid.UUID, req *models.CategoryRequest) (*models.Category, error) {
+	category, err := s.get(ctx, id)
+	if err != nil {
+		return nil, err
+	}
+	oldName := category.Name
+	if oldName == FallbackCategory && req.Name != FallbackCategory {
+		return nil, ErrProtectedCategory
+	}
+
+	if err := s.apply(ctx, category, req); err != nil {
+		return nil, err
+	}
+
+	if err := s.repo.Update(ctx, oldName, category); err != nil {
+		return nil, categoryWriteError(err)
+	}
+	s.invalidate()
+	return category, nil
+}
+
+// Delete removes a category and recategorizes its items in the background. Categories
+// that smart collections filter on can't be deleted.
+func (s *CategoryService) Delete(ctx context.Context, id uuid.UUID) error {
+	category, err := s.get(ctx, id)
+	if err != nil {
+		return err
+	}
+	if category.Name == FallbackCategory {
+		return ErrProtectedCategory
+	}
+
+	ids, usedBy, err := s.repo.Delete(ctx, category)
+	if err != nil {
+		return err
+	}
+	if len(usedBy) > 0 {
+		// Dropping the condition would widen those collections, possibly to every item
+		return fmt.Errorf("%w: %s; change their filters or merge the category instead", ErrCategoryInUse, strings.Join(usedBy, ", "))
+	}
+	s.invalidate()
+
+	go s.recategorize(ids)
+	return nil
+}
+
+// Merge moves the items of category id into category into and deletes id
+func (s *CategoryService) Merge(ctx context.Context, id, into uuid.UUID) (*models.Category, error) {
+	if id == into {
+		return nil, fmt.Errorf("%w: cannot merge a category into itself", ErrInvalidCategory)
+	}
+
+	from, err := s.get(ctx, id)
+	if err != nil {
+		return nil, err
+	}
+	if from.Name == FallbackCategory {
+		return nil, ErrProtectedCategory
+	}
+	target, err := s.get(ctx, into)
+	if err != nil {
+		return nil, err
+	}
+	// A direct subcategory moves up to from's place; a deeper one would form a cycle
+	// once from's subcategories move under it
+	ancestors, err := s.ancestorIDs(ctx, target.ID)
+	if err != nil {
+		return nil, err
+	}
+	if len(ancestors) > 1 && containsUUID(ancestors[1:], from.ID) {
+		return nil, fmt.Errorf("%w: cannot merge a category into one of its nested subcategories", ErrInvalidCategory)
+	}
+
+	if err := s.repo.Merge(ctx, from, target); err != nil {
+		return nil, err
+	}
+	s.invalidate()
+
+	target.ItemCount += from.ItemCount
+	return target, nil
+}
+
+// apply validates req and copies it onto category
+func (s *CategoryService) apply(ctx context.Context, category *models.Category, req *models.CategoryRequest) error {
+	name := strings.TrimSpace(req.Name)
+	if name == "" {
+		return fmt.Errorf("%w: name is required", ErrInvalidCategory)
+	}
+
+	if req.ParentID != nil {
+		if category.ID != uuid.Nil && *req.ParentID == category.ID {
+			return fmt.Errorf("%w: a category cannot be its own parent", ErrInvalidCategory)
+		}
+		if _, err := s.get(ctx, *req.ParentID); err != nil {
+			return fmt.Errorf("%w: parent category not found", ErrInvalidCategory)
+		}
+		if category.ID != uuid.Nil {
+			ancestors, err := s.ancestorIDs(ctx, *req.ParentID)
+			if err != nil {
+				return err
+			}
+			if containsUUID(ancestors, category.ID) {
+				return fmt.Errorf("%w: a category cannot be moved under its own subcategory", ErrInvalidCategory)
+			}
+		}
+	}
+
+	aliases := []string{}
+	for _, alias := range req.Aliases {
+		alias = strings.ToLower(strings.TrimSpace(alias))
+		if alias != "" && !containsString(aliases, alias) {
+			aliases = append(aliases, alias)
+		}
+	}
+
+	category.Name = name
+	category.Description = strings.TrimSpace(req.Description)
+	category.Aliases = aliases
+	category.Icon = req.Icon
+	category.ImageKeyword = strings.TrimSpace(req.ImageKeyword)
+	category.ParentID = req.ParentID
+	return nil
+}
+
+// ancestorIDs returns the IDs of the categories above category id, nearest first
+func (s *CategoryService) ancestorIDs(ctx context.Context, id uuid.UUID) ([]uuid.UUID, error) {
+	categories, err := s.repo.List(ctx)
+	if err != nil {
+		return nil, err
+	}
+	parents := map[uuid.UUID]*uuid.UUID{}
+	for _, category := range categories {
+		parents[category.ID] = category.ParentID
+	}
+
+	var ancestors []uuid.UUID
+	for parent := parents[id]; parent != nil && !containsUUID(ancestors, *parent); parent = parents[*parent] {
+		ancestors = append(ancestors, *parent)
+	}
+	return ancestors, nil
+}
+
+func (s *CategoryService) invalidate() {
+	s.mu.Lock()
+	s.categories = nil
+	s.mu.Unlock()
+}
+
+// recategorize asks the AI to file each item under the current taxonomy
+func (s *CategoryService) recategorize(ids []uuid.UUID) {
+	if len(ids) == 0 {
+		return
+	}
+
+	// One pass at a time; the AI provider's rate limiter paces the calls
+	s.recategorizeMu.Lock()
+	defer s.recategorizeMu.Unlock()
+
+	ctx := context.Background()
+	categories := s.Categories(ctx)
+	updated := 0
+	for _, id := range ids {
+		item, err := s.itemRepo.GetByID(ctx, id)
+		if err != nil {
+			continue
+		}
+
+		category, err := s.aiService.CategorizeContent(ctx, item.Title, itemText(item), item.Type, categories)
+		if err != nil {
+			category = s.DefaultFor(ctx, item.Type)
+		}
+		if category == item.Category {
+			continue
+		}
+
+		if err := s.itemRepo.UpdateCategory(ctx, id, category); err != nil {
+			fmt.Printf("Warning: Failed to recategorize item %s: %v\n", id, err)
+			continue
+		}
+		updated++
+	}
+	fmt.Printf("Recategorized %d of %d items\n", updated, len(ids))
+}
+
+func categoryWriteError(err error) error {
+	var pgErr *pgconn.PgError
+	if errors.As(err, &pgErr) && pgErr.Code == "23505" {
+		return ErrCategoryExists
+	}
+	return err
+}
+
+// findCategory returns the category named name, or else the one with alias name, ignoring case
+func findCategory(categories []models.Category, name string) *models.Category {
+	name = strings.TrimSpace(name)
+	if name == "" {
+		return nil
+	}
+	for i := range categories {
+		if strings.EqualFold(categories[i].Name, name) {
+			return &categories[i]
+		}
+	}
+	lower := strings.ToLower(name)
+	for i := range categories {
+		if containsString(categories[i].Aliases, lower) {
+			return &categories[i]
+		}
+	}
+	return nil
+}
+
+// categoryNames returns the names of categories
+func categoryNames(categories []models.Category) []string {
+	names := make([]string, len(categories))
+	for i, c := range categories {
+		names[i] = c.Name
+	}
+	return names
+}
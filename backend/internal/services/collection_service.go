@@ -0,0 +1,341 @@
+package services
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"regexp"
+	"strconv"
+	"strings"
+	"synapse/internal/models"
+	"synapse/internal/repository"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/jackc/pgx/v5"
+	"github.com/jackc/pgx/v5/pgconn"
+)
+
+var (
+	ErrCollectionNotFound = errors.New("collection not found")
+	ErrInvalidCollection  = errors.New("invalid collection")
+)
+
+// smartCollectionLimit caps the number of items a smart collection evaluates to
+const smartCollectionLimit = 500
+
+// sinceRe matches the relative window of a smart filter, e.g. "3m" for the last 3 months
+var sinceRe = regexp.MustCompile(`^(\d+)([dwmy])$`)
+
+// CollectionService manages user-created collections and their item order. Smart
+// collections have no stored members; their filter is run through ItemRepository on read.
+type CollectionService struct {
+	repo            *repository.CollectionRepository
+	itemRepo        *repository.ItemRepository
+	categoryService *CategoryService
+	tagService      *TagService
+}
+
+func NewCollectionService(repo *repository.CollectionRepository, itemRepo *repository.ItemRepository, categoryService *CategoryService, tagService *TagService) *CollectionService {
+	return &CollectionService{
+		repo:            repo,
+		itemRepo:        itemRepo,
+		categoryService: categoryService,
+		tagService:      tagService,
+	}
+}
+
+// List returns every collection. Smart collections only get their item count, which
+// is counted without loading their items; Get also finds their cover.
+func (s *CollectionService) List(ctx context.Context) ([]models.Collection, error) {
+	collections, err := s.repo.List(ctx)
+	if err != nil {
+		return nil, err
+	}
+	for i := range collections {
+		if collections[i].Filter == nil {
+			continue
+		}
+		filters, err := s.queryFilters(ctx, collections[i].Filter)
+		if err != nil {
+			return nil, err
+		}
+		count, err := s.itemRepo.CountItems(ctx, filters)
+		if err != nil {
+			return nil, err
+		}
+		collections[i].ItemCount = min(count, smartCollectionLimit)
+	}
+	return collections, nil
+}
+
+func (s *CollectionService) Get(ctx context.Context, id uuid.UUID) (*models.Collection, error) {
+	collection, err := s.repo.GetByID(ctx, id)
+	if errors.Is(err, pgx.ErrNoRows) {
+		return nil, ErrCollectionNotFound
+	}
+	if err != nil {
+		return nil, err
+	}
+	return collection, s.fillSmart(ctx, collection)
+}
+
+func (s *CollectionService) Create(ctx context.Context, req *models.CollectionRequest) (*models.Collection, error) {
+	collection := &models.Collection{}
+	if err := s.apply(ctx, collection, req); err != nil {
+		return nil, err
+	}
+	if err := s.repo.Create(ctx, collection); err != nil {
+		return nil, err
+	}
+	return s.Get(ctx, collection.ID)
+}
+
+func (s *CollectionService) Update(ctx context.Context, id uuid.UUID, req *models.CollectionRequest) (*models.Collection, error) {
+	collection, err := s.Get(ctx, id)
+	if err != nil {
+		return nil, err
+	}
+	if err := s.apply(ctx, collection, req); err != nil {
+		return nil, err
+	}
+	if err := s.repo.Update(ctx, collection); err != nil {
+		return nil, err
+	}
+	return s.Get(ctx, id)
+}
+
+// Delete removes a collection. Its items are kept.
+func (s *CollectionService) Delete(ctx context.Context, id uuid.UUID) error {
+	if _, err := s.Get(ctx, id); err != nil {
+		return err
+	}
+	return s.repo.Delete(ctx, id)
+}
+
+// Items returns the items of a collection in order. Smart collections list their
+// matching items, newest first.
+func (s *CollectionService) Items(ctx context.Context, id uuid.UUID) ([]models.Item, error) {
+	collection, err := s.repo.GetByID(ctx, id)
+	if errors.Is(err, pgx.ErrNoRows) {
+		return nil, ErrCollectionNotFound
+	}
+	if err != nil {
+		return nil, err
+	}
+	if collection.Filter != nil {
+		return s.evaluate(ctx, collection.Filter)
+	}
+	return s.repo.Items(ctx, id)
+}
+
+// ItemIDs returns the set of items in a collection, for collection-scoped queries
+func (s *CollectionService) ItemIDs(ctx context.Context, id uuid.UUID) (map[uuid.UUID]bool, error) {
+	items, err := s.Items(ctx, id)
+	if err != nil {
+		return nil, err
+	}
+	set := make(map[uuid.UUID]bool, len(items))
+	for _, item := range items {
+		set[item.ID] = true
+	}
+	return set, nil
+}
+
+// AddItems appends items to a collection and returns how many were not in it yet
+func (s *CollectionService) AddItems(ctx context.Context, id uuid.UUID, itemIDs []uuid.UUID) (int64, error) {
+	if len(itemIDs) == 0 {
+		return 0, fmt.Errorf("%w: item_ids is required", ErrInvalidCollection)
+	}
+	if _, err := s.static(ctx, id); err != nil {
+		return 0, err
+	}
+
+	added, err := s.repo.AddItems(ctx, id, itemIDs)
+	var pgErr *pgconn.PgError
+	if errors.As(err, &pgErr) && pgErr.Code == "23503" {
+		return 0, fmt.Errorf("%w: unknown item", ErrInvalidCollection)
+	}
+	return added, err
+}
+
+func (s *CollectionService) RemoveItem(ctx context.Context, id, itemID uuid.UUID) error {
+	if _, err := s.static(ctx, id); err != nil {
+		return err
+	}
+	removed, err := s.repo.RemoveItem(ctx, id, itemID)
+	if err != nil {
+		return err
+	}
+	if !removed {
+		return fmt.Errorf("%w: item is not in the collection", ErrCollectionNotFound)
+	}
+	return nil
+}
+
+// Reorder moves the given items to the front of the collection in the order given.
+// Items left out keep their relative order after them.
+func (s *CollectionService) Reorder(ctx context.Context, id uuid.UUID, itemIDs []uuid.UUID) ([]models.Item, error) {
+	if _, err := s.static(ctx, id); err != nil {
+		return nil, err
+	}
+	existing, err := s.repo.ItemIDs(ctx, id)
+	if err != nil {
+		return nil, err
+	}
+	current := make(map[uuid.UUID]bool, len(existing))
+	for _, itemID := range existing {
+		current[itemID] = true
+	}
+
+	order := []uuid.UUID{}
+	placed := map[uuid.UUID]bool{}
+	for _, itemID := range itemIDs {
+		if !current[itemID] {
+			return nil, fmt.Errorf("%w: item %s is not in the collection", ErrInvalidCollection, itemID)
+		}
+		if !placed[itemID] {
+			order = append(order, itemID)
+			placed[itemID] = true
+		}
+	}
+	for _, itemID := range existing {
+		if !placed[itemID] {
+			order = append(order, itemID)
+		}
+	}
+
+	if err := s.repo.SetOrder(ctx, id, order); err != nil {
+		return nil, err
+	}
+	return s.repo.Items(ctx, id)
+}
+
+// static returns collection id, or an error if it is a smart collection, whose members
+// can't be edited by hand
+func (s *CollectionService) static(ctx context.Context, id uuid.UUID) (*models.Collection, error) {
+	collection, err := s.repo.GetByID(ctx, id)
+	if errors.Is(err, pgx.ErrNoRows) {
+		return nil, ErrCollectionNotFound
+	}
+	if err != nil {
+		return nil, err
+	}
+	if collection.Filter != nil {
+		return nil, fmt.Errorf("%w: the items of a smart collection are set by its filter", ErrInvalidCollection)
+	}
+	return collection, nil
+}
+
+// fillSmart sets the item count and cover of a smart collection from its current items
+func (s *CollectionService) fillSmart(ctx context.Context, collection *models.Collection) error {
+	if collection.Filter == nil {
+		return nil
+	}
+	items, err := s.evaluate(ctx, collection.Filter)
+	if err != nil {
+		return err
+	}
+
+	collection.ItemCount = len(items)
+	if collection.CoverImageURL == "" {
+		for _, item := range items {
+			if item.ImageURL != "" {
+				collection.Cover = item.ImageURL
+				break
+			}
+		}
+	}
+	return nil
+}
+
+// evaluate returns the items matching a smart filter, newest first
+func (s *CollectionService) evaluate(ctx context.Context, filter *models.SmartFilter) ([]models.Item, error) {
+	filters, err := s.queryFilters(ctx, filter)
+	if err != nil {
+		return nil, err
+	}
+	return s.itemRepo.SearchItems(ctx, filters, smartCollectionLimit)
+}
+
+// queryFilters converts a smart filter to search filters
+func (s *CollectionService) queryFilters(ctx context.Context, filter *models.SmartFilter) (*models.QueryFilters, error) {
+	filters := &models.QueryFilters{
+		SearchTerms: filter.Text,
+		AllTerms:    true,
+		Type:        filter.Type,
+		StrictType:  true,
+		Source:      filter.Category,
+		Tags:        s.tagService.Expand(ctx, filter.Tags),
+		DateFrom:    filter.From,
+		DateTo:      filter.To,
+	}
+	if filter.Since != "" {
+		from, err := sinceTime(filter.Since, time.Now())
+		if err != nil {
+			return nil, err
+		}
+		filters.DateFrom = &from
+	}
+	return filters, nil
+}
+
+// apply validates req and copies it onto collection
+func (s *CollectionService) apply(ctx context.Context, collection *models.Collection, req *models.CollectionRequest) error {
+	name := strings.TrimSpace(req.Name)
+	if name == "" {
+		return fmt.Errorf("%w: name is required", ErrInvalidCollection)
+	}
+
+	var filter *models.SmartFilter
+	if req.Filter != nil {
+		filter = &models.SmartFilter{
+			Text:  strings.TrimSpace(req.Filter.Text),
+			Type:  strings.ToLower(strings.TrimSpace(req.Filter.Type)),
+			Tags:  s.tagService.Normalize(ctx, req.Filter.Tags),
+			Since: strings.ToLower(strings.TrimSpace(req.Filter.Since)),
+			From:  req.Filter.From,
+			To:    req.Filter.To,
+		}
+		if req.Filter.Category != "" {
+			filter.Category = s.categoryService.Resolve(ctx, req.Filter.Category)
+			if filter.Category == "" {
+				return fmt.Errorf("%w: unknown category %q", ErrInvalidCollection, req.Filter.Category)
+			}
+		}
+		if filter.Since != "" {
+			if _, err := sinceTime(filter.Since, time.Now()); err != nil {
+				return err
+			}
+		}
+		if filter.Text == "" && filter.Type == "" && filter.Category == "" && len(filter.Tags) == 0 &&
+			filter.Since == "" && filter.From == nil && filter.To == nil {
+			return fmt.Errorf("%w: a smart collection filter needs at least one condition", ErrInvalidCollection)
+		}
+	}
+
+	collection.Name = name
+	collection.Description = strings.TrimSpace(req.Description)
+	collection.CoverImageURL = strings.TrimSpace(req.CoverImageURL)
+	collection.Position = req.Position
+	collection.Filter = filter
+	return nil
+}
+
+// sinceTime returns the start of a relative window such as "3m" (the last 3 months)
+func sinceTime(since string, now time.Time) (time.Time, error) {
+	m := sinceRe.FindStringSubmatch(since)
+	if m == nil {
+		return time.Time{}, fmt.Errorf("%w: since must look like 14d, 2w, 3m or 1y", ErrInvalidCollection)
+	}
+	n, _ := strconv.Atoi(m[1])
+	switch m[2] {
+	case "d":
+		return now.AddDate(0, 0, -n), nil
+	case "w":
+		return now.AddDate(0, 0, -7*n), nil
+	case "m":
+		return now.AddDate(0, -n, 0), nil
+	}
+	return now.AddDate(-n, 0, 0), nil
+}
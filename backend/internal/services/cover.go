@@ -0,0 +1,122 @@
+package services
+
+import (
+	"fmt"
+	"hash/fnv"
+	"strings"
+	"unicode/utf8"
+)
+
+// Size of generated covers, close to the aspect of item cards
+const (
+	coverWidth  = 800
+	coverHeight = 500
+)
+
+// coverPalettes are the gradients covers are drawn with; a category always gets the
+// same one
+var coverPalettes = [][2]string{
+	{"#4f46e5", "#7c3aed"}, // Indigo to violet
+	{"#0ea5e9", "#2563eb"}, // Sky to blue
+	{"#059669", "#0d9488"}, // Emerald to teal
+	{"#ea580c", "#dc2626"}, // Orange to red
+	{"#db2777", "#9333ea"}, // Pink to purple
+	{"#ca8a04", "#ea580c"}, // Amber to orange
+	{"#0f766e", "#155e75"}, // Teal to cyan
+	{"#be123c", "#7c2d12"}, // Rose to brown
+	{"#4338ca", "#1e3a8a"}, // Indigo to navy
+	{"#65a30d", "#15803d"}, // Lime to green
+	{"#475569", "#1e293b"}, // Slate
+	{"#c026d3", "#6d28d9"}, // Fuchsia to violet
+}
+
+// RenderCover draws an SVG cover for an item without an image: the category's colors
+// and icon with the title on top. The same input always gives the same bytes, so
+// identical covers are stored once.
+func RenderCover(title, category, icon string) []byte {
+	palette := coverPalettes[coverHash(category)%uint32(len(coverPalettes))]
+	if category == "" {
+		palette = coverPalettes[coverHash(title)%uint32(len(coverPalettes))]
+	}
+	// Soft circles in the background vary with the title
+	seed := coverHash(title)
+	circles := ""
+	for i := 0; i < 3; i++ {
+		cx := 200 + int(seed>>(i*8)&0xff)*600/255
+		cy := int(seed>>(i*8+4)&0xff) * coverHeight / 255
+		r := 120 + i*70
+		circles += fmt.Sprintf(`<circle cx="%d" cy="%d" r="%d" fill="#ffffff" fill-opacity="0.07"/>`, cx, cy, r)
+	}
+
+	lines := wrapTitle(title, 26, 3)
+	titleTop := coverHeight/2 - (len(lines)-1)*28
+	var text strings.Builder
+	for i, line := range lines {
+		fmt.Fprintf(&text, `<tspan x="60" y="%d">%s</tspan>`, titleTop+i*56, xmlEscape(line))
+	}
+
+	var b strings.Builder
+	fmt.Fprintf(&b, `<svg xmlns="http://www.w3.org/2000/svg" width="%d" height="%d" viewBox="0 0 %d %d">`, coverWidth, coverHeight, coverWidth, coverHeight)
+	fmt.Fprintf(&b, `<defs><linearGradient id="bg" x1="0" y1="0" x2="1" y2="1"><stop offset="0" stop-color="%s"/><stop offset="1" stop-color="%s"/></linearGradient></defs>`, palette[0], palette[1])
+	fmt.Fprintf(&b, `<rect width="%d" height="%d" fill="url(#bg)"/>`, coverWidth, coverHeight)
+	b.WriteString(circles)
+	if icon != "" {
+		fmt.Fprintf(&b, `<text x="60" y="110" font-size="64">%s</text>`, xmlEscape(icon))
+	}
+	fmt.Fprintf(&b, `<text font-family="system-ui, -apple-system, 'Segoe UI', Roboto, sans-serif" font-size="46" font-weight="700" fill="#ffffff">%s</text>`, text.String())
+	if category != "" {
+		fmt.Fprintf(&b, `<text x="60" y="%d" font-family="system-ui, -apple-system, 'Segoe UI', Roboto, sans-serif" font-size="24" fill="#ffffff" fill-opacity="0.8">%s</text>`, coverHeight-50, xmlEscape(category))
+	}
+	b.WriteString(`</svg>`)
+	return []byte(b.String())
+}
+
+// wrapTitle breaks a title into at most maxLines lines of about width characters,
+// ending with an ellipsis if it doesn't fit
+func wrapTitle(title string, width, maxLines int) []string {
+	words := strings.Fields(title)
+	if len(words) == 0 {
+		return []string{"Untitled"}
+	}
+
+	var lines []string
+	line := ""
+	for i, word := range words {
+		if utf8.RuneCountInString(word) > width {
+			word = truncateRunes(word, width-1) + "…"
+		}
+		if line != "" && utf8.RuneCountInString(line)+1+utf8.RuneCountInString(word) > width {
+			lines = append(lines, line)
+			line = ""
+			if len(lines) == maxLines {
+				last := lines[maxLines-1]
+				if utf8.RuneCountInString(last) >= width {
+					last = truncateRunes(last, width-1)
+				}
+				lines[maxLines-1] = last + "…"
+				return lines
+			}
+		}
+		if line == "" {
+			line = word
+		} else {
+			line += " " + word
+		}
+		if i == len(words)-1 {
+			lines = append(lines, line)
+		}
+	}
+	return lines
+}
+
+func coverHash(s string) uint32 {
+	h := fnv.New32a()
+	h.Write([]byte(strings.ToLower(s)))
+	return h.Sum32()
+}
+
+var xmlEscaper = strings.NewReplacer("&", "&amp;", "<", "&lt;", ">", "&gt;", `"`, "&quot;", "'", "&apos;")
+
+func xmlEscape(s string) string {
+	return xmlEscaper.Replace(s)
+}
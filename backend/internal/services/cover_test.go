@@ -0,0 +1,30 @@
+package services
+
+import (
+	"reflect"
+	"testing"
+)
+
+func TestWrapTitle(t *testing.T) {
+	tests := []struct {
+		name            string
+		title           string
+		width, maxLines int
+		want            []string
+	}{
+		{"fits", "Short title", 20, 3, []string{"Short title"}},
+		{"empty", "  ", 20, 3, []string{"Untitled"}},
+		{"wraps", "The quick brown fox", 10, 3, []string{"The quick", "brown fox"}},
+		{"ellipsis", "The quick brown fox jumps", 10, 2, []string{"The quick", "brown fox…"}},
+		{"full last line", "aaaa bbbb cccc", 4, 2, []string{"aaaa", "bbb…"}},
+		{"long word", "Supercalifragilistic words", 8, 3, []string{"Superca…", "words"}},
+		{"runes", "héllo wörld", 5, 2, []string{"héllo", "wörld"}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := wrapTitle(tt.title, tt.width, tt.maxLines); !reflect.DeepEqual(got, tt.want) {
+				t.Fatalf("wrapTitle(%q, %d, %d) = %q, want %q", tt.title, tt.width, tt.maxLines, got, tt.want)
+			}
+		})
+	}
+}
@@ -0,0 +1,295 @@
+package services
+
+import (
+	"archive/zip"
+	"bytes"
+	"encoding/xml"
+	"errors"
+	"fmt"
+	"io"
+	"path"
+	"strings"
+)
+
+// maxArchiveEntrySize bounds how much of one file inside a DOCX or EPUB is read, and
+// maxArchiveSize how much of all of them together, so a small archive can't expand
+// without limit
+const (
+	maxArchiveEntrySize = 50 << 20
+	maxArchiveSize      = 200 << 20
+)
+
+var errArchiveTooLarge = errors.New("archive expands to more than the size limit")
+
+// documentText is the text and metadata extracted from an uploaded document. Content
+// is Markdown.
+type documentText struct {
+	title    string
+	author   string
+	language string
+	content  string
+}
+
+// ExtractDOCX converts a Word document to Markdown: heading styles become headings,
+// numbered and bulleted paragraphs list items, and bold and italic runs emphasis. The
+// title and author come from the document properties.
+func ExtractDOCX(data []byte) (*documentText, error) {
+	archive, err := zip.NewReader(bytes.NewReader(data), int64(len(data)))
+	if err != nil {
+		return nil, fmt.Errorf("invalid DOCX: %w", err)
+	}
+	budget := int64(maxArchiveSize)
+	body, err := readZipFile(archive, "word/document.xml", &budget)
+	if err != nil {
+		return nil, fmt.Errorf("invalid DOCX: %w", err)
+	}
+
+	doc := &documentText{}
+	if core, err := readZipFile(archive, "docProps/core.xml", &budget); err == nil {
+		var props struct {
+			Title   string `xml:"title"`
+			Creator string `xml:"creator"`
+		}
+		if xml.Unmarshal(core, &props) == nil {
+			doc.title = strings.TrimSpace(props.Title)
+			doc.author = strings.TrimSpace(props.Creator)
+		}
+	}
+
+	var paragraphs []string
+	var paragraph strings.Builder
+	var prefix string
+	var bold, italic bool
+	decoder := xml.NewDecoder(bytes.NewReader(body))
+	for {
+		token, err := decoder.Token()
+		if err == io.EOF {
+			break
+		}
+		if err != nil {
+			return nil, fmt.Errorf("invalid DOCX: %w", err)
+		}
+
+		switch t := token.(type) {
+		case xml.StartElement:
+			switch t.Name.Local {
+			case "p":
+				paragraph.Reset()
+				prefix = ""
+			case "pStyle":
+				prefix = docxStylePrefix(xmlAttr(t, "val"), prefix)
+			case "numPr":
+				if prefix == "" {
+					prefix = "- "
+				}
+			case "r":
+				bold, italic = false, false
+			case "b":
+				bold = xmlAttr(t, "val") != "false" && xmlAttr(t, "val") != "0"
+			case "i":
+				italic = xmlAttr(t, "val") != "false" && xmlAttr(t, "val") != "0"
+			case "t":
+				var text string
+				if err := decoder.DecodeElement(&text, &t); err != nil {
+					return nil, fmt.Errorf("invalid DOCX: %w", err)
+				}
+				text = markdownEscaper.Replace(text)
+				if bold {
+					text = wrapInline(text, "**")
+				}
+				if italic {
+					text = wrapInline(text, "_")
+				}
+				paragraph.WriteString(text)
+			case "tab":
+				paragraph.WriteString(" ")
+			case "br", "cr":
+				paragraph.WriteString("  \n")
+			}
+		case xml.EndElement:
+			if t.Name.Local == "p" {
+				if text := strings.TrimSpace(paragraph.String()); text != "" {
+					if prefix == "" {
+						text = escapeLineStart(text)
+					}
+					paragraphs = append(paragraphs, prefix+text)
+				}
+			}
+		}
+	}
+	doc.content = joinMarkdownParagraphs(paragraphs)
+	return doc, nil
+}
+
+// docxStylePrefix maps a paragraph style to its Markdown prefix
+func docxStylePrefix(style, current string) string {
+	lower := strings.ToLower(style)
+	switch {
+	case lower == "title":
+		return "# "
+	case strings.HasPrefix(lower, "heading"):
+		level := strings.TrimPrefix(lower, "heading")
+		if len(level) == 1 && level[0] >= '1' && level[0] <= '6' {
+			return strings.Repeat("#", int(level[0]-'0')) + " "
+		}
+	case strings.Contains(lower, "list"):
+		return "- "
+	case strings.Contains(lower, "quote"):
+		return "> "
+	}
+	return current
+}
+
+// joinMarkdownParagraphs separates paragraphs with blank lines, keeping consecutive list
+// items together
+func joinMarkdownParagraphs(paragraphs []string) string {
+	var b strings.Builder
+	for i, paragraph := range paragraphs {
+		if i > 0 {
+			if strings.HasPrefix(paragraph, "- ") && strings.HasPrefix(paragraphs[i-1], "- ") {
+				b.WriteString("\n")
+			} else {
+				b.WriteString("\n\n")
+			}
+		}
+		b.WriteString(paragraph)
+	}
+	return b.String()
+}
+
+// ExtractEPUB converts an EPUB's chapters to Markdown in reading order, with the title,
+// author and language from its package metadata
+func ExtractEPUB(data []byte) (*documentText, error) {
+	archive, err := zip.NewReader(bytes.NewReader(data), int64(len(data)))
+	if err != nil {
+		return nil, fmt.Errorf("invalid EPUB: %w", err)
+	}
+	budget := int64(maxArchiveSize)
+
+	containerXML, err := readZipFile(archive, "META-INF/container.xml", &budget)
+	if err != nil {
+		return nil, fmt.Errorf("invalid EPUB: %w", err)
+	}
+	var container struct {
+		Rootfiles []struct {
+			FullPath string `xml:"full-path,attr"`
+		} `xml:"rootfiles>rootfile"`
+	}
+	if err := xml.Unmarshal(containerXML, &container); err != nil || len(container.Rootfiles) == 0 {
+		return nil, errors.New("invalid EPUB: no package document")
+	}
+
+	packagePath := container.Rootfiles[0].FullPath
+	packageXML, err := readZipFile(archive, packagePath, &budget)
+	if err != nil {
+		return nil, fmt.Errorf("invalid EPUB: %w", err)
+	}
+	var pkg struct {
+		Title    []string `xml:"metadata>title"`
+		Creator  []string `xml:"metadata>creator"`
+		Language []string `xml:"metadata>language"`
+		Manifest []struct {
+			ID        string `xml:"id,attr"`
+			Href      string `xml:"href,attr"`
+			MediaType string `xml:"media-type,attr"`
+		} `xml:"manifest>item"`
+		Spine []struct {
+			IDRef string `xml:"idref,attr"`
+		} `xml:"spine>itemref"`
+	}
+	if err := xml.Unmarshal(packageXML, &pkg); err != nil {
+		return nil, fmt.Errorf("invalid EPUB: %w", err)
+	}
+
+	doc := &documentText{}
+	if len(pkg.Title) > 0 {
+		doc.title = strings.TrimSpace(pkg.Title[0])
+	}
+	if len(pkg.Creator) > 0 {
+		doc.author = strings.TrimSpace(pkg.Creator[0])
+	}
+	if len(pkg.Language) > 0 {
+		doc.language = normalizeLanguage(pkg.Language[0])
+	}
+
+	hrefs := make(map[string]string, len(pkg.Manifest))
+	for _, item := range pkg.Manifest {
+		if strings.Contains(item.MediaType, "html") {
+			hrefs[item.ID] = item.Href
+		}
+	}
+	var chapters []string
+	for _, ref := range pkg.Spine {
+		href, ok := hrefs[ref.IDRef]
+		if !ok {
+			continue
+		}
+		chapter, err := readZipFile(archive, path.Join(path.Dir(packagePath), href), &budget)
+		if errors.Is(err, errArchiveTooLarge) {
+			// The chapters read so far are kept
+			break
+		}
+		if err != nil {
+			continue
+		}
+		// Links inside the book don't lead anywhere once it's converted
+		md, err := HTMLToMarkdown(string(chapter), "")
+		if err == nil && md != "" {
+			chapters = append(chapters, md)
+		}
+	}
+	doc.content = strings.Join(chapters, "\n\n---\n\n")
+	return doc, nil
+}
+
+// readZipFile reads one file of an archive, up to maxArchiveEntrySize, and takes its
+// size from budget, the bytes left to read from the archive
+func readZipFile(archive *zip.Reader, name string, budget *int64) ([]byte, error) {
+	for _, file := range archive.File {
+		if file.Name != name {
+			continue
+		}
+		r, err := file.Open()
+		if err != nil {
+			return nil, err
+		}
+		defer r.Close()
+		limit := min(int64(maxArchiveEntrySize), *budget)
+		data, err := io.ReadAll(io.LimitReader(r, limit+1))
+		if err != nil {
+			return nil, err
+		}
+		if int64(len(data)) > limit {
+			if limit < maxArchiveEntrySize {
+				return nil, errArchiveTooLarge
+			}
+			return nil, fmt.Errorf("%s is too large", name)
+		}
+		*budget -= int64(len(data))
+		return data, nil
+	}
+	return nil, fmt.Errorf("%s not found", name)
+}
+
+// zipHasFile reports whether an archive contains a file
+func zipHasFile(data []byte, name string) bool {
+	archive, err := zip.NewReader(bytes.NewReader(data), int64(len(data)))
+	if err != nil {
+		return false
+	}
+	for _, file := range archive.File {
+		if file.Name == name {
+			return true
+		}
+	}
+	return false
+}
+
+func xmlAttr(element xml.StartElement, local string) string {
+	for _, a := range element.Attr {
+		if a.Name.Local == local {
+			return a.Value
+		}
+	}
+	return ""
+}
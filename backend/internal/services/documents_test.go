@@ -0,0 +1,87 @@
+package services
+
+import (
+	"archive/zip"
+	"bytes"
+	"errors"
+	"testing"
+)
+
+func testArchive(t *testing.T, files map[string][]byte) *zip.Reader {
+	t.Helper()
+	var buf bytes.Buffer
+	w := zip.NewWriter(&buf)
+	for name, data := range files {
+		f, err := w.Create(name)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if _, err := f.Write(data); err != nil {
+			t.Fatal(err)
+		}
+	}
+	if err := w.Close(); err != nil {
+		t.Fatal(err)
+	}
+	archive, err := zip.NewReader(bytes.NewReader(buf.Bytes()), int64(buf.Len()))
+	if err != nil {
+		t.Fatal(err)
+	}
+	return archive
+}
+
+func TestReadZipFileBudget(t *testing.T) {
+	archive := testArchive(t, map[string][]byte{
+		"a.xml": bytes.Repeat([]byte("a"), 10),
+		"b.xml": bytes.Repeat([]byte("b"), 10),
+	})
+
+	tests := []struct {
+		name       string
+		budget     int64
+		reads      []string
+		wantErr    error // Of the last read; nil for success
+		wantBudget int64
+	}{
+		{"within budget", 100, []string{"a.xml", "b.xml"}, nil, 80},
+		{"exactly the budget", 20, []string{"a.xml", "b.xml"}, nil, 0},
+		{"second file over budget", 15, []string{"a.xml", "b.xml"}, errArchiveTooLarge, 5},
+		{"rereads count again", 25, []string{"a.xml", "a.xml", "a.xml"}, errArchiveTooLarge, 5},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			budget := tt.budget
+			var err error
+			for i, name := range tt.reads {
+				var data []byte
+				data, err = readZipFile(archive, name, &budget)
+				if i < len(tt.reads)-1 && err != nil {
+					t.Fatalf("read %d: %v", i, err)
+				}
+				if err == nil && len(data) != 10 {
+					t.Fatalf("read %d: got %d bytes, want 10", i, len(data))
+				}
+			}
+			if !errors.Is(err, tt.wantErr) {
+				t.Fatalf("last read: err = %v, want %v", err, tt.wantErr)
+			}
+			if budget != tt.wantBudget {
+				t.Fatalf("budget left = %d, want %d", budget, tt.wantBudget)
+			}
+		})
+	}
+
+	budget := int64(100)
+	if _, err := readZipFile(archive, "missing.xml", &budget); err == nil || budget != 100 {
+		t.Fatalf("missing file: err = %v, budget %d", err, budget)
+	}
+}
+
+func TestReadZipFileEntryLimit(t *testing.T) {
+	archive := testArchive(t, map[string][]byte{"big.xml": make([]byte, maxArchiveEntrySize+1)})
+	budget := int64(maxArchiveSize)
+	_, err := readZipFile(archive, "big.xml", &budget)
+	if err == nil || errors.Is(err, errArchiveTooLarge) {
+		t.Fatalf("err = %v, want the entry size error", err)
+	}
+}
@@ -0,0 +1,163 @@
+package services
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"strings"
+	"synapse/internal/models"
+)
+
+// contentKinds describe the form of an item, independent of its topic
+var contentKinds = []string{
+	"article", "tutorial", "recipe", "product", "video", "book",
+	"note", "reference", "news", "discussion", "other",
+}
+
+const (
+	enrichMaxTokens   = 500
+	enrichMaxTags     = 5
+	enrichMaxEntities = 10
+)
+
+// enrichmentSchema is the JSON schema of the enrichment response
+func enrichmentSchema(categories []string) map[string]interface{} {
+	stringArray := map[string]interface{}{
+		"type":  "array",
+		"items": map[string]interface{}{"type": "string"},
+	}
+	return map[string]interface{}{
+		"type": "object",
+		"properties": map[string]interface{}{
+			"category":     map[string]interface{}{"type": "string", "enum": categories},
+			"tags":         stringArray,
+			"summary":      map[string]interface{}{"type": "string"},
+			"entities":     stringArray,
+			"language":     map[string]interface{}{"type": "string"},
+			"content_kind": map[string]interface{}{"type": "string", "enum": contentKinds},
+		},
+		"required": []string{"category", "tags", "summary", "entities", "language", "content_kind"},
+	}
+}
+
+// geminiSchema converts a JSON schema to Gemini's responseSchema dialect, which spells
+// types in upper case
+func geminiSchema(schema map[string]interface{}) map[string]interface{} {
+	converted := make(map[string]interface{}, len(schema))
+	for key, value := range schema {
+		switch v := value.(type) {
+		case map[string]interface{}:
+			if key == "properties" {
+				props := make(map[string]interface{}, len(v))
+				for name, prop := range v {
+					props[name] = geminiSchema(prop.(map[string]interface{}))
+				}
+				converted[key] = props
+			} else {
+				converted[key] = geminiSchema(v)
+			}
+		case string:
+			if key == "type" {
+				v = strings.ToUpper(v)
+			}
+			converted[key] = v
+		default:
+			converted[key] = v
+		}
+	}
+	return converted
+}
+
+// EnrichContent categorizes, tags, summarizes and extracts entities, language and content
+// kind in a single JSON-mode call. The answer is validated against categories; callers
+// fall back to CategorizeContent and GenerateTags when it returns an error. knownTags are
+// existing tags the AI is asked to reuse.
+func (s *AIService) EnrichContent(ctx context.Context, title, content, itemType string, categories []models.Category, knownTags []string) (*models.Enrichment, error) {
+	// Truncate content if too long
+	truncated := content
+	if len(content) > 3000 {
+		truncated = content[:3000]
+	}
+
+	prompt, err := s.prompts.Render(PromptEnrich, enrichPromptData{
+		Title:        title,
+		Type:         itemType,
+		Content:      truncated,
+		Categories:   categories,
+		ContentKinds: contentKinds,
+		KnownTags:    knownTags,
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	response, err := s.completeJSON(withAIOperation(ctx, OpEnrich), prompt, enrichMaxTokens, enrichmentSchema(categoryNames(categories)))
+	if err != nil {
+		return nil, err
+	}
+
+	return parseEnrichment(response, categories)
+}
+
+// parseEnrichment decodes and validates an enrichment response
+func parseEnrichment(response string, categories []models.Category) (*models.Enrichment, error) {
+	// Some models wrap JSON in a markdown code fence even in JSON mode
+	response = strings.TrimSpace(response)
+	response = strings.TrimPrefix(response, "```json")
+	response = strings.TrimPrefix(response, "```")
+	response = strings.TrimSuffix(response, "```")
+
+	var raw models.Enrichment
+	if err := json.Unmarshal([]byte(strings.TrimSpace(response)), &raw); err != nil {
+		return nil, fmt.Errorf("invalid enrichment JSON: %w", err)
+	}
+
+	category := findCategory(categories, raw.Category)
+	if category == nil {
+		return nil, fmt.Errorf("enrichment returned unknown category %q", raw.Category)
+	}
+
+	enrichment := &models.Enrichment{
+		Category:    category.Name,
+		Tags:        cleanStrings(raw.Tags, enrichMaxTags),
+		Summary:     strings.TrimSpace(raw.Summary),
+		Entities:    cleanStrings(raw.Entities, enrichMaxEntities),
+		Language:    strings.ToLower(strings.TrimSpace(raw.Language)),
+		ContentKind: strings.ToLower(strings.TrimSpace(raw.ContentKind)),
+	}
+	if len(enrichment.Language) > 8 {
+		enrichment.Language = ""
+	}
+	if !containsString(contentKinds, enrichment.ContentKind) {
+		enrichment.ContentKind = "other"
+	}
+	return enrichment, nil
+}
+
+// cleanStrings trims values, drops empty ones and duplicates, and keeps at most max
+func cleanStrings(values []string, max int) []string {
+	cleaned := []string{}
+	seen := map[string]bool{}
+	for _, v := range values {
+		v = strings.TrimSpace(v)
+		key := strings.ToLower(v)
+		if v == "" || seen[key] {
+			continue
+		}
+		seen[key] = true
+		cleaned = append(cleaned, v)
+		if len(cleaned) == max {
+			break
+		}
+	}
+	return cleaned
+}
+
+func containsString(values []string, s string) bool {
+	for _, v := range values {
+		if v == s {
+			return true
+		}
+	}
+	return false
+}
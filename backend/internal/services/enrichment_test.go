@@ -0,0 +1,57 @@
+package services
+
+import (
+	"reflect"
+	"testing"
+
+	"synapse/internal/models"
+)
+
+func TestParseEnrichment(t *testing.T) {
+	categories := []models.Category{
+		{Name: "Technology", Aliases: []string{"tech"}},
+		{Name: "Recipes & Cooking"},
+	}
+
+	tests := []struct {
+		name     string
+		response string
+		want     *models.Enrichment // nil when parsing must fail
+	}{
+		{
+			name:     "valid",
+			response: `{"category":"Technology","tags":["go","databases"],"summary":" A post. ","entities":["PostgreSQL"],"language":"EN","content_kind":"Tutorial"}`,
+			want:     &models.Enrichment{Category: "Technology", Tags: []string{"go", "databases"}, Summary: "A post.", Entities: []string{"PostgreSQL"}, Language: "en", ContentKind: "tutorial"},
+		},
+		{
+			name:     "code fence and alias",
+			response: "```json\n{\"category\":\"TECH\",\"tags\":[],\"summary\":\"\",\"entities\":[],\"language\":\"de\",\"content_kind\":\"news\"}\n```",
+			want:     &models.Enrichment{Category: "Technology", Tags: []string{}, Entities: []string{}, Language: "de", ContentKind: "news"},
+		},
+		{
+			name:     "cleans tags and entities",
+			response: `{"category":"recipes & cooking","tags":["Pasta"," pasta ","","a","b","c","d","e"],"entities":["Rome","rome"],"language":"a-very-long-code","content_kind":"poem"}`,
+			want:     &models.Enrichment{Category: "Recipes & Cooking", Tags: []string{"Pasta", "a", "b", "c", "d"}, Entities: []string{"Rome"}, ContentKind: "other"},
+		},
+		{"unknown category", `{"category":"Sports","tags":[]}`, nil},
+		{"missing category", `{"tags":["go"]}`, nil},
+		{"not JSON", "Category: Technology", nil},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := parseEnrichment(tt.response, categories)
+			if tt.want == nil {
+				if err == nil {
+					t.Fatalf("got %+v, want an error", got)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatal(err)
+			}
+			if !reflect.DeepEqual(got, tt.want) {
+				t.Fatalf("got %+v, want %+v", got, tt.want)
+			}
+		})
+	}
+}
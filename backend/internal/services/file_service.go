@@ -0,0 +1,248 @@
+package services
+
+import (
+	"context"
+	"encoding/base64"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"os"
+	"strings"
+	"synapse/internal/models"
+	"synapse/internal/repository"
+	"synapse/internal/storage"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/jackc/pgx/v5"
+)
+
+var (
+	ErrFileNotFound   = errors.New("file not found")
+	ErrInvalidDataURL = errors.New("invalid data URL")
+)
+
+// inlineImageBatch is how many data-URL images are moved to the blob store per query
+const inlineImageBatch = 50
+
+// FileService stores file contents in the blob store and records which items use
+// them. Files are served from baseURL + "/" + hash.
+type FileService struct {
+	store       storage.BlobStore
+	fileRepo    *repository.FileRepository
+	baseURL     string
+	orphanGrace time.Duration
+}
+
+func NewFileService(store storage.BlobStore, fileRepo *repository.FileRepository) *FileService {
+	baseURL := strings.TrimRight(os.Getenv("FILES_BASE_URL"), "/")
+	if baseURL == "" {
+		baseURL = "/api/files"
+	}
+	return &FileService{
+		store:       store,
+		fileRepo:    fileRepo,
+		baseURL:     baseURL,
+		orphanGrace: time.Hour,
+	}
+}
+
+// URL is where a stored file is served
+func (s *FileService) URL(hash string) string {
+	return s.baseURL + "/" + hash
+}
+
+// HashOf returns the hash of a file URL made by URL, or false for any other URL
+func (s *FileService) HashOf(fileURL string) (string, bool) {
+	hash, ok := strings.CutPrefix(fileURL, s.baseURL+"/")
+	if !ok || !storage.ValidHash(hash) {
+		return "", false
+	}
+	return hash, true
+}
+
+// Store saves data in the blob store and records it. Storing the same bytes again
+// returns the existing blob.
+func (s *FileService) Store(ctx context.Context, data []byte, contentType string) (*models.Blob, error) {
+	if contentType == "" {
+		contentType, _, _ = strings.Cut(http.DetectContentType(data), ";")
+	}
+	blob := &models.Blob{
+		Hash:        storage.Hash(data),
+		ContentType: contentType,
+		Size:        int64(len(data)),
+	}
+	if err := s.store.Put(ctx, blob.Hash, data, contentType); err != nil {
+		return nil, fmt.Errorf("failed to store file: %w", err)
+	}
+	if err := s.fileRepo.CreateBlob(ctx, blob); err != nil {
+		return nil, err
+	}
+	return blob, nil
+}
+
+// StoreDataURL decodes a data URL and stores its contents; images lose their metadata
+func (s *FileService) StoreDataURL(ctx context.Context, dataURL string) (*models.Blob, error) {
+	data, mimeType, err := decodeDataURL(dataURL)
+	if err != nil {
+		return nil, err
+	}
+	if strings.HasPrefix(mimeType, "image/") {
+		return s.StoreImage(ctx, data, mimeType)
+	}
+	return s.Store(ctx, data, mimeType)
+}
+
+// Attach sets the file an item has in a role
+func (s *FileService) Attach(ctx context.Context, itemID uuid.UUID, role, hash, filename string) error {
+	return s.fileRepo.Attach(ctx, itemID, role, hash, filename)
+}
+
+// ListForItem returns the files attached to an item with their URLs
+func (s *FileService) ListForItem(ctx context.Context, itemID uuid.UUID) ([]models.ItemFile, error) {
+	files, err := s.fileRepo.ListForItem(ctx, itemID)
+	if err != nil {
+		return nil, err
+	}
+	for i := range files {
+		files[i].URL = s.URL(files[i].Hash)
+	}
+	return files, nil
+}
+
+// Open returns a stored file's record and contents; the caller closes the reader
+func (s *FileService) Open(ctx context.Context, hash string) (*models.Blob, io.ReadCloser, error) {
+	if !storage.ValidHash(hash) {
+		return nil, nil, ErrFileNotFound
+	}
+	blob, err := s.fileRepo.GetBlob(ctx, hash)
+	if errors.Is(err, pgx.ErrNoRows) {
+		return nil, nil, ErrFileNotFound
+	}
+	if err != nil {
+		return nil, nil, err
+	}
+	body, err := s.store.Get(ctx, hash)
+	if errors.Is(err, storage.ErrNotFound) {
+		return nil, nil, ErrFileNotFound
+	}
+	if err != nil {
+		return nil, nil, err
+	}
+	return blob, body, nil
+}
+
+// Read returns a stored file's contents and content type
+func (s *FileService) Read(ctx context.Context, hash string) ([]byte, string, error) {
+	blob, body, err := s.Open(ctx, hash)
+	if err != nil {
+		return nil, "", err
+	}
+	defer body.Close()
+	data, err := io.ReadAll(body)
+	if err != nil {
+		return nil, "", err
+	}
+	return data, blob.ContentType, nil
+}
+
+// StoreImage stores an image without its metadata (EXIF, GPS position, ...)
+func (s *FileService) StoreImage(ctx context.Context, data []byte, contentType string) (*models.Blob, error) {
+	return s.Store(ctx, StripImageMetadata(data, contentType), contentType)
+}
+
+// DetachRoles removes an item's files whose role starts with prefix
+func (s *FileService) DetachRoles(ctx context.Context, itemID uuid.UUID, prefix string) error {
+	return s.fileRepo.DetachRoles(ctx, itemID, prefix)
+}
+
+// DeleteUnreferenced removes the blobs no item uses any more, such as the files of
+// purged items, and returns how many were removed
+func (s *FileService) DeleteUnreferenced(ctx context.Context) (int, error) {
+	hashes, err := s.fileRepo.DeleteUnreferenced(ctx, time.Now().Add(-s.orphanGrace))
+	if err != nil {
+		return 0, err
+	}
+	for _, hash := range hashes {
+		if err := s.store.Delete(ctx, hash); err != nil {
+			fmt.Printf("Warning: Failed to delete blob %s: %v\n", hash, err)
+		}
+	}
+	return len(hashes), nil
+}
+
+// MigrateInlineImages moves images still stored as data URLs in items.image_url into
+// the blob store, pointing the items at the stored files. It returns how many items
+// were migrated.
+func (s *FileService) MigrateInlineImages(ctx context.Context) (int, error) {
+	migrated := 0
+	failed := map[uuid.UUID]bool{}
+	for {
+		images, err := s.fileRepo.ListInlineImages(ctx, inlineImageBatch+len(failed))
+		if err != nil {
+			return migrated, err
+		}
+		pending := 0
+		for itemID, dataURL := range images {
+			if failed[itemID] {
+				continue
+			}
+			pending++
+			if err := s.migrateInlineImage(ctx, itemID, dataURL); err != nil {
+				// Leave the image where it is; it still renders from the data URL
+				fmt.Printf("Warning: Failed to move image of item %s to the blob store: %v\n", itemID, err)
+				failed[itemID] = true
+				continue
+			}
+			migrated++
+		}
+		if pending == 0 {
+			return migrated, nil
+		}
+	}
+}
+
+func (s *FileService) migrateInlineImage(ctx context.Context, itemID uuid.UUID, dataURL string) error {
+	blob, err := s.StoreDataURL(ctx, dataURL)
+	if err != nil {
+		return err
+	}
+	if err := s.Attach(ctx, itemID, models.FileRoleImage, blob.Hash, ""); err != nil {
+		return err
+	}
+	return s.fileRepo.ReplaceImage(ctx, itemID, dataURL, s.URL(blob.Hash))
+}
+
+// decodeDataURL returns the contents and MIME type of a data URL
+func decodeDataURL(dataURL string) ([]byte, string, error) {
+	rest, ok := strings.CutPrefix(dataURL, "data:")
+	if !ok {
+		return nil, "", ErrInvalidDataURL
+	}
+	header, payload, ok := strings.Cut(rest, ",")
+	if !ok {
+		return nil, "", ErrInvalidDataURL
+	}
+
+	params := strings.Split(header, ";")
+	mimeType := strings.TrimSpace(params[0])
+	if mimeType == "" {
+		mimeType = "text/plain"
+	}
+	if params[len(params)-1] == "base64" {
+		// Tolerate line breaks and missing padding from hand-built URLs
+		payload = strings.TrimRight(strings.Join(strings.Fields(payload), ""), "=")
+		data, err := base64.RawStdEncoding.DecodeString(payload)
+		if err != nil {
+			return nil, "", fmt.Errorf("%w: %v", ErrInvalidDataURL, err)
+		}
+		return data, mimeType, nil
+	}
+	data, err := url.PathUnescape(payload)
+	if err != nil {
+		return nil, "", fmt.Errorf("%w: %v", ErrInvalidDataURL, err)
+	}
+	return []byte(data), mimeType, nil
+}
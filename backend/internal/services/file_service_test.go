@@ -0,0 +1,44 @@
+package services
+
+import (
+	"errors"
+	"testing"
+)
+
+func TestDecodeDataURL(t *testing.T) {
+	tests := []struct {
+		name     string
+		dataURL  string
+		wantData string
+		wantMIME string
+		wantErr  bool
+	}{
+		{"base64", "data:image/png;base64,aGVsbG8=", "hello", "image/png", false},
+		{"missing padding", "data:image/png;base64,aGVsbG8", "hello", "image/png", false},
+		{"line breaks", "data:image/png;base64,aGVs\nbG8=", "hello", "image/png", false},
+		{"parameters", "data:text/plain;charset=utf-8;base64,aGk=", "hi", "text/plain", false},
+		{"percent encoded", "data:text/plain,hello%20world", "hello world", "text/plain", false},
+		{"default type", "data:,hi", "hi", "text/plain", false},
+		{"not a data URL", "https://example.com/a.png", "", "", true},
+		{"no comma", "data:image/png;base64", "", "", true},
+		{"bad base64", "data:image/png;base64,!!!", "", "", true},
+		{"bad escape", "data:text/plain,100%", "", "", true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			data, mimeType, err := decodeDataURL(tt.dataURL)
+			if tt.wantErr {
+				if !errors.Is(err, ErrInvalidDataURL) {
+					t.Fatalf("err = %v, want ErrInvalidDataURL", err)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatal(err)
+			}
+			if string(data) != tt.wantData || mimeType != tt.wantMIME {
+				t.Fatalf("got %q, %q; want %q, %q", data, mimeType, tt.wantData, tt.wantMIME)
+			}
+		})
+	}
+}
@@ -0,0 +1,179 @@
+package services
+
+import (
+	"bytes"
+	"context"
+	"encoding/base64"
+	"encoding/json"
+	"fmt"
+	"io"
+	"math"
+	"net/http"
+	"os"
+	"strconv"
+	"strings"
+	"synapse/internal/db"
+	"time"
+
+	"github.com/google/uuid"
+)
+
+// clipSimilarityScale is the text-to-image cosine similarity treated as a perfect
+// match. CLIP similarities between a caption and a matching image are rarely above it.
+const clipSimilarityScale = 0.35
+
+// ImageEmbeddingService embeds images and search queries in a shared text-image space
+// (CLIP) so images are found by what they show. It calls a local model server with an
+// OpenAI-style /embeddings endpoint that accepts images as data URLs, such as Infinity,
+// at CLIP_URL. Without CLIP_URL it is disabled.
+type ImageEmbeddingService struct {
+	baseURL        string
+	model          string
+	minSimilarity  float64
+	client         *http.Client
+	collectionName string
+}
+
+func NewImageEmbeddingService() *ImageEmbeddingService {
+	minSimilarity := 0.2
+	if v, err := strconv.ParseFloat(os.Getenv("CLIP_MIN_SIMILARITY"), 64); err == nil {
+		minSimilarity = v
+	}
+	model := os.Getenv("CLIP_MODEL")
+	if model == "" {
+		model = "openai/clip-vit-base-patch32"
+	}
+	return &ImageEmbeddingService{
+		baseURL:        strings.TrimRight(os.Getenv("CLIP_URL"), "/"),
+		model:          model,
+		minSimilarity:  minSimilarity,
+		client:         &http.Client{Timeout: 60 * time.Second},
+		collectionName: "synapse_images",
+	}
+}
+
+// Enabled reports whether a CLIP server is configured
+func (s *ImageEmbeddingService) Enabled() bool {
+	return s.baseURL != ""
+}
+
+// Index embeds an item's image into the image collection, replacing an earlier one
+func (s *ImageEmbeddingService) Index(ctx context.Context, itemID uuid.UUID, itemType string, image []byte, mimeType string) error {
+	if !s.Enabled() {
+		return nil
+	}
+	dataURL := "data:" + mimeType + ";base64," + base64.StdEncoding.EncodeToString(image)
+	embedding, err := s.embed(ctx, "image", dataURL)
+	if err != nil {
+		return err
+	}
+	metadata := map[string]interface{}{"type": itemType}
+	return db.Chroma.UpsertEmbedding(s.collectionName, itemID.String(), embedding, metadata)
+}
+
+// Delete removes an item's image from the image collection
+func (s *ImageEmbeddingService) Delete(itemID uuid.UUID) error {
+	if !s.Enabled() {
+		return nil
+	}
+	return db.Chroma.DeleteEmbeddings(s.collectionName, []string{itemID.String()})
+}
+
+// IndexAsync indexes an item's image in the background, logging failures
+func (s *ImageEmbeddingService) IndexAsync(itemID uuid.UUID, itemType string, image []byte, mimeType string) {
+	if !s.Enabled() {
+		return
+	}
+	go func() {
+		if err := s.Index(context.Background(), itemID, itemType, image, mimeType); err != nil {
+			fmt.Printf("Warning: Failed to embed image of item %s: %v\n", itemID, err)
+		}
+	}()
+}
+
+// Search returns the items whose images best match a text query with their scores
+// (0-1), best first. Images less similar than CLIP_MIN_SIMILARITY are left out.
+func (s *ImageEmbeddingService) Search(ctx context.Context, query string, limit int) ([]uuid.UUID, []float64, error) {
+	if !s.Enabled() {
+		return nil, nil, nil
+	}
+	embedding, err := s.embed(ctx, "text", query)
+	if err != nil {
+		return nil, nil, err
+	}
+	ids, distances, err := db.Chroma.Query(s.collectionName, embedding, limit)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	var itemIDs []uuid.UUID
+	var scores []float64
+	for i, id := range ids {
+		itemID, err := uuid.Parse(id)
+		if err != nil {
+			continue
+		}
+		// Vectors are normalized, so Chroma's squared L2 distance is 2 - 2 cos
+		similarity := 1 - distances[i]/2
+		if similarity < s.minSimilarity {
+			continue
+		}
+		itemIDs = append(itemIDs, itemID)
+		scores = append(scores, math.Min(1, similarity/clipSimilarityScale))
+	}
+	return itemIDs, scores, nil
+}
+
+// embed returns the normalized embedding of a text or image (as a data URL) input
+func (s *ImageEmbeddingService) embed(ctx context.Context, modality, input string) ([]float32, error) {
+	payload := map[string]interface{}{
+		"model":    s.model,
+		"input":    []string{input},
+		"modality": modality,
+	}
+	jsonData, _ := json.Marshal(payload)
+	req, err := http.NewRequestWithContext(ctx, "POST", s.baseURL+"/embeddings", bytes.NewReader(jsonData))
+	if err != nil {
+		return nil, err
+	}
+	req.Header.Set("Content-Type", "application/json")
+
+	resp, err := s.client.Do(req)
+	if err != nil {
+		return nil, fmt.Errorf("CLIP server: %w", err)
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode != http.StatusOK {
+		body, _ := io.ReadAll(io.LimitReader(resp.Body, 1<<10))
+		return nil, fmt.Errorf("CLIP server: status %d: %s", resp.StatusCode, strings.TrimSpace(string(body)))
+	}
+	var result struct {
+		Data []struct {
+			Embedding []float32 `json:"embedding"`
+		} `json:"data"`
+	}
+	if err := json.NewDecoder(resp.Body).Decode(&result); err != nil {
+		return nil, fmt.Errorf("CLIP server: %w", err)
+	}
+	if len(result.Data) == 0 || len(result.Data[0].Embedding) == 0 {
+		return nil, fmt.Errorf("CLIP server: no embedding in response")
+	}
+	return normalize(result.Data[0].Embedding), nil
+}
+
+// normalize scales a vector to unit length
+func normalize(v []float32) []float32 {
+	var sum float64
+	for _, x := range v {
+		sum += float64(x) * float64(x)
+	}
+	if sum == 0 {
+		return v
+	}
+	norm := float32(math.Sqrt(sum))
+	for i := range v {
+		v[i] /= norm
+	}
+	return v
+}
@@ -0,0 +1,149 @@
+package services
+
+import (
+	"bytes"
+	"encoding/binary"
+	"image"
+	"image/color"
+	"image/jpeg"
+	"strings"
+	"testing"
+)
+
+func decodeBase83(s string) int {
+	value := 0
+	for _, c := range s {
+		value = value*83 + strings.IndexRune(base83Chars, c)
+	}
+	return value
+}
+
+func TestBlurhash(t *testing.T) {
+	solid := image.NewNRGBA(image.Rect(0, 0, 64, 48))
+	gradient := image.NewNRGBA(image.Rect(0, 0, 64, 48))
+	for y := 0; y < 48; y++ {
+		for x := 0; x < 64; x++ {
+			solid.SetNRGBA(x, y, color.NRGBA{R: 255, A: 255})
+			gradient.SetNRGBA(x, y, color.NRGBA{R: uint8(x * 4), G: uint8(x * 4), B: uint8(x * 4), A: 255})
+		}
+	}
+
+	tests := []struct {
+		name   string
+		img    image.Image
+		x, y   int
+		wantDC int // Average color as 0xRRGGBB; -1 skips the check
+	}{
+		{"solid 4x3", solid, 4, 3, 0xFF0000},
+		{"solid 1x1", solid, 1, 1, 0xFF0000},
+		{"gradient 4x3", gradient, 4, 3, -1},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			hash := blurhash(tt.img, tt.x, tt.y)
+			if want := 4 + 2*tt.x*tt.y; len(hash) != want {
+				t.Fatalf("hash %q has length %d, want %d", hash, len(hash), want)
+			}
+			if got := decodeBase83(hash[:1]); got != (tt.x-1)+(tt.y-1)*9 {
+				t.Fatalf("size flag = %d, want %d", got, (tt.x-1)+(tt.y-1)*9)
+			}
+			if tt.wantDC >= 0 && decodeBase83(hash[2:6]) != tt.wantDC {
+				t.Fatalf("DC = %06x, want %06x", decodeBase83(hash[2:6]), tt.wantDC)
+			}
+		})
+	}
+
+	// The second character is the quantised maximum of the AC components
+	if flat, varied := blurhash(solid, 4, 3)[1], blurhash(gradient, 4, 3)[1]; decodeBase83(string(varied)) <= decodeBase83(string(flat)) {
+		t.Fatalf("gradient maximum AC %q not above solid %q", varied, flat)
+	}
+}
+
+// jpegSegment builds a JPEG marker segment with payload
+func jpegSegment(marker byte, payload string) []byte {
+	segment := []byte{0xFF, marker, 0, 0}
+	binary.BigEndian.PutUint16(segment[2:], uint16(len(payload)+2))
+	return append(segment, payload...)
+}
+
+func TestStripJPEGMetadata(t *testing.T) {
+	var buf bytes.Buffer
+	if err := jpeg.Encode(&buf, image.NewGray(image.Rect(0, 0, 8, 8)), nil); err != nil {
+		t.Fatal(err)
+	}
+	encoded := buf.Bytes()
+
+	var data []byte
+	data = append(data, encoded[:2]...) // SOI
+	data = append(data, jpegSegment(0xE1, "Exif\x00\x00GPS")...)
+	data = append(data, jpegSegment(0xED, "Photoshop IPTC")...)
+	data = append(data, jpegSegment(0xFE, "a comment")...)
+	data = append(data, encoded[2:]...)
+
+	stripped, err := stripJPEGMetadata(data)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !bytes.Equal(stripped, encoded) {
+		t.Fatalf("stripped JPEG differs from the original: %d bytes, want %d", len(stripped), len(encoded))
+	}
+	if _, err := jpeg.Decode(bytes.NewReader(stripped)); err != nil {
+		t.Fatalf("stripped JPEG doesn't decode: %v", err)
+	}
+
+	for name, bad := range map[string][]byte{
+		"not a JPEG": []byte("GIF89a"),
+		"truncated":  append([]byte{0xFF, 0xD8}, jpegSegment(0xE1, "Exif")[:5]...),
+		"no scan":    append([]byte{0xFF, 0xD8}, jpegSegment(0xE0, "JFIF")...),
+	} {
+		if _, err := stripJPEGMetadata(bad); err != errMalformedImage {
+			t.Errorf("%s: err = %v, want errMalformedImage", name, err)
+		}
+	}
+}
+
+// tiffWithOrientation builds a TIFF header whose first IFD has an orientation tag
+func tiffWithOrientation(order binary.ByteOrder, orientation uint16) []byte {
+	tiff := make([]byte, 8+2+2*12)
+	if order == binary.LittleEndian {
+		copy(tiff, "II")
+	} else {
+		copy(tiff, "MM")
+	}
+	order.PutUint16(tiff[2:], 42)
+	order.PutUint32(tiff[4:], 8)
+	order.PutUint16(tiff[8:], 2)
+	order.PutUint16(tiff[10:], 0x010F) // Make, skipped
+	order.PutUint16(tiff[22:], 0x0112)
+	order.PutUint16(tiff[24:], 3)
+	order.PutUint32(tiff[26:], 1)
+	order.PutUint16(tiff[30:], orientation)
+	return tiff
+}
+
+func TestExifOrientation(t *testing.T) {
+	tests := []struct {
+		name string
+		tiff []byte
+		want int
+	}{
+		{"little endian", tiffWithOrientation(binary.LittleEndian, 6), 6},
+		{"big endian", tiffWithOrientation(binary.BigEndian, 3), 3},
+		{"out of range", tiffWithOrientation(binary.LittleEndian, 9), 0},
+		{"truncated IFD", tiffWithOrientation(binary.LittleEndian, 6)[:20], 0},
+		{"bad byte order", []byte("XX\x00\x2a\x08\x00\x00\x00\x00\x00"), 0},
+		{"too short", []byte("II"), 0},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := exifOrientation(tt.tiff); got != tt.want {
+				t.Fatalf("exifOrientation = %d, want %d", got, tt.want)
+			}
+		})
+	}
+
+	jpegData := append([]byte{0xFF, 0xD8}, jpegSegment(0xE1, "Exif\x00\x00"+string(tiffWithOrientation(binary.BigEndian, 8)))...)
+	if got := jpegOrientation(jpegData); got != 8 {
+		t.Fatalf("jpegOrientation = %d, want 8", got)
+	}
+}
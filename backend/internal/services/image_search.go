@@ -0,0 +1,118 @@
+package services
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/url"
+	"os"
+	"time"
+)
+
+// ImageSearchProvider finds a stock photo for a search query. Without one configured,
+// items without an image get a generated cover instead.
+type ImageSearchProvider interface {
+	Name() string
+	// SearchImage returns the URL of the best photo for query, or "" if none matched
+	SearchImage(ctx context.Context, query string) (string, error)
+}
+
+// NewImageSearchProviderFromEnv returns the provider selected by IMAGE_SEARCH_PROVIDER
+// ("unsplash" or "pexels"), or nil when none is set up
+func NewImageSearchProviderFromEnv() ImageSearchProvider {
+	client := &http.Client{Timeout: 10 * time.Second}
+	switch provider := os.Getenv("IMAGE_SEARCH_PROVIDER"); provider {
+	case "":
+		return nil
+	case "unsplash":
+		if key := os.Getenv("UNSPLASH_ACCESS_KEY"); key != "" {
+			return &unsplashProvider{accessKey: key, client: client}
+		}
+		fmt.Println("Warning: IMAGE_SEARCH_PROVIDER is unsplash but UNSPLASH_ACCESS_KEY is not set; using generated covers")
+	case "pexels":
+		if key := os.Getenv("PEXELS_API_KEY"); key != "" {
+			return &pexelsProvider{apiKey: key, client: client}
+		}
+		fmt.Println("Warning: IMAGE_SEARCH_PROVIDER is pexels but PEXELS_API_KEY is not set; using generated covers")
+	default:
+		fmt.Printf("Warning: Unknown IMAGE_SEARCH_PROVIDER %q; using generated covers\n", provider)
+	}
+	return nil
+}
+
+// unsplashProvider searches the Unsplash API (https://unsplash.com/documentation)
+type unsplashProvider struct {
+	accessKey string
+	client    *http.Client
+}
+
+func (p *unsplashProvider) Name() string {
+	return "unsplash"
+}
+
+func (p *unsplashProvider) SearchImage(ctx context.Context, query string) (string, error) {
+	endpoint := "https://api.unsplash.com/search/photos?per_page=1&orientation=landscape&content_filter=high&query=" + url.QueryEscape(query)
+	var result struct {
+		Results []struct {
+			URLs struct {
+				Regular string `json:"regular"`
+			} `json:"urls"`
+		} `json:"results"`
+	}
+	if err := getImageSearchJSON(ctx, p.client, endpoint, "Client-ID "+p.accessKey, &result); err != nil {
+		return "", fmt.Errorf("unsplash search: %w", err)
+	}
+	if len(result.Results) == 0 {
+		return "", nil
+	}
+	return result.Results[0].URLs.Regular, nil
+}
+
+// pexelsProvider searches the Pexels API (https://www.pexels.com/api/documentation)
+type pexelsProvider struct {
+	apiKey string
+	client *http.Client
+}
+
+func (p *pexelsProvider) Name() string {
+	return "pexels"
+}
+
+func (p *pexelsProvider) SearchImage(ctx context.Context, query string) (string, error) {
+	endpoint := "https://api.pexels.com/v1/search?per_page=1&orientation=landscape&query=" + url.QueryEscape(query)
+	var result struct {
+		Photos []struct {
+			Src struct {
+				Large string `json:"large"`
+			} `json:"src"`
+		} `json:"photos"`
+	}
+	if err := getImageSearchJSON(ctx, p.client, endpoint, p.apiKey, &result); err != nil {
+		return "", fmt.Errorf("pexels search: %w", err)
+	}
+	if len(result.Photos) == 0 {
+		return "", nil
+	}
+	return result.Photos[0].Src.Large, nil
+}
+
+func getImageSearchJSON(ctx context.Context, client *http.Client, endpoint, authorization string, out interface{}) error {
+	req, err := http.NewRequestWithContext(ctx, "GET", endpoint, nil)
+	if err != nil {
+		return err
+	}
+	req.Header.Set("Authorization", authorization)
+	req.Header.Set("Accept", "application/json")
+
+	resp, err := client.Do(req)
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode != http.StatusOK {
+		return fmt.Errorf("status %d", resp.StatusCode)
+	}
+	return json.NewDecoder(resp.Body).Decode(out)
+}
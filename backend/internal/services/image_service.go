@@ -0,0 +1,198 @@
+package services
+
+import (
+	"bytes"
+	"context"
+	"fmt"
+	"io"
+	"net/http"
+	"regexp"
+	"strconv"
+	"strings"
+	"synapse/internal/models"
+	"synapse/internal/repository"
+	"time"
+
+	"github.com/google/uuid"
+)
+
+// imageBackfillBatch is how many unprocessed images are loaded per query
+const imageBackfillBatch = 20
+
+// ImageService processes item images in the background: it measures them, computes a
+// dominant color and a blurhash to show while they load, and stores resized variants
+// in the blob store so cards don't download full-size images.
+type ImageService struct {
+	itemRepo     *repository.ItemRepository
+	fileService  *FileService
+	client       *http.Client
+	maxImageSize int64
+}
+
+func NewImageService(itemRepo *repository.ItemRepository, fileService *FileService) *ImageService {
+	return &ImageService{
+		itemRepo:     itemRepo,
+		fileService:  fileService,
+		client:       &http.Client{Timeout: 30 * time.Second},
+		maxImageSize: int64(envInt("IMAGE_MAX_MB", 15)) << 20,
+	}
+}
+
+// Process makes the variants and placeholders of an item's image. Failures are saved
+// on the item too, so the image isn't retried until it changes.
+func (s *ImageService) Process(ctx context.Context, itemID uuid.UUID, imageURL string) error {
+	info, err := s.process(ctx, itemID, imageURL)
+	if err != nil {
+		info = &models.ImageInfo{Error: err.Error()}
+	}
+	info.Source = imageURL
+	if saveErr := s.itemRepo.UpdateImageInfo(ctx, itemID, info); saveErr != nil {
+		return saveErr
+	}
+	return err
+}
+
+// ProcessAsync processes an item's image in the background, logging failures
+func (s *ImageService) ProcessAsync(itemID uuid.UUID, imageURL string) {
+	if imageURL == "" {
+		return
+	}
+	go func() {
+		if err := s.Process(context.Background(), itemID, imageURL); err != nil {
+			fmt.Printf("Warning: Failed to process image of item %s: %v\n", itemID, err)
+		}
+	}()
+}
+
+// Backfill processes the images of items saved before image processing existed, or
+// whose image changed without being processed. It returns how many were processed.
+func (s *ImageService) Backfill(ctx context.Context) (int, error) {
+	processed := 0
+	seen := map[uuid.UUID]bool{}
+	for {
+		images, err := s.itemRepo.ListUnprocessedImages(ctx, imageBackfillBatch+len(seen))
+		if err != nil {
+			return processed, err
+		}
+		pending := 0
+		for itemID, imageURL := range images {
+			if seen[itemID] {
+				continue
+			}
+			seen[itemID] = true
+			pending++
+			if err := s.Process(ctx, itemID, imageURL); err != nil {
+				fmt.Printf("Warning: Failed to process image of item %s: %v\n", itemID, err)
+				continue
+			}
+			processed++
+		}
+		if pending == 0 {
+			return processed, nil
+		}
+	}
+}
+
+func (s *ImageService) process(ctx context.Context, itemID uuid.UUID, imageURL string) (*models.ImageInfo, error) {
+	data, err := s.Load(ctx, imageURL)
+	if err != nil {
+		return nil, err
+	}
+	// Variants of an earlier image are dropped; unused blobs go with the next sweep
+	if err := s.fileService.DetachRoles(ctx, itemID, models.FileRoleVariant); err != nil {
+		return nil, err
+	}
+	if isSVG(data) {
+		// Vector images (generated covers) scale without variants
+		return svgInfo(data), nil
+	}
+	img, err := decodeImage(data)
+	if err != nil {
+		return nil, err
+	}
+	bounds := img.Bounds()
+	info := &models.ImageInfo{
+		Width:    bounds.Dx(),
+		Height:   bounds.Dy(),
+		Color:    dominantColor(img),
+		Blurhash: blurhash(img, 4, 3),
+	}
+
+	resized, err := resizeImages(img)
+	if err != nil {
+		return nil, err
+	}
+	for _, variant := range resized {
+		blob, err := s.fileService.Store(ctx, variant.data, variant.contentType)
+		if err != nil {
+			return nil, err
+		}
+		if err := s.fileService.Attach(ctx, itemID, models.FileRoleVariant+variant.name, blob.Hash, ""); err != nil {
+			return nil, err
+		}
+		info.Variants = append(info.Variants, models.ImageVariant{
+			Name:   variant.name,
+			Width:  variant.width,
+			Height: variant.height,
+			URL:    s.fileService.URL(blob.Hash),
+		})
+	}
+	return info, nil
+}
+
+var svgSizePattern = regexp.MustCompile(`<svg[^>]*?\swidth="(\d+)"[^>]*?\sheight="(\d+)"`)
+
+func isSVG(data []byte) bool {
+	head := bytes.TrimSpace(data[:min(len(data), 512)])
+	return bytes.HasPrefix(head, []byte("<svg")) || (bytes.HasPrefix(head, []byte("<?xml")) && bytes.Contains(head, []byte("<svg")))
+}
+
+// svgInfo reads the size of an SVG image from its root element
+func svgInfo(data []byte) *models.ImageInfo {
+	info := &models.ImageInfo{}
+	if m := svgSizePattern.FindSubmatch(data); m != nil {
+		info.Width, _ = strconv.Atoi(string(m[1]))
+		info.Height, _ = strconv.Atoi(string(m[2]))
+	}
+	return info
+}
+
+// Load returns the bytes of an image URL: a stored file, a data URL or a remote image
+func (s *ImageService) Load(ctx context.Context, imageURL string) ([]byte, error) {
+	if hash, ok := s.fileService.HashOf(imageURL); ok {
+		data, _, err := s.fileService.Read(ctx, hash)
+		return data, err
+	}
+	if strings.HasPrefix(imageURL, "data:") {
+		data, _, err := decodeDataURL(imageURL)
+		return data, err
+	}
+	if !strings.HasPrefix(imageURL, "http://") && !strings.HasPrefix(imageURL, "https://") {
+		return nil, fmt.Errorf("unsupported image URL %q", imageURL)
+	}
+
+	req, err := http.NewRequestWithContext(ctx, "GET", imageURL, nil)
+	if err != nil {
+		return nil, err
+	}
+	req.Header.Set("User-Agent", "Mozilla/5.0 (compatible; SynapseBot/1.0)")
+	req.Header.Set("Accept", "image/*")
+
+	resp, err := s.client.Do(req)
+	if err != nil {
+		return nil, err
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode != http.StatusOK {
+		return nil, fmt.Errorf("fetching image: status %d", resp.StatusCode)
+	}
+	data, err := io.ReadAll(io.LimitReader(resp.Body, s.maxImageSize+1))
+	if err != nil {
+		return nil, err
+	}
+	if int64(len(data)) > s.maxImageSize {
+		return nil, fmt.Errorf("image larger than %d MB", s.maxImageSize>>20)
+	}
+	return data, nil
+}
@@ -0,0 +1,160 @@
+package services
+
+import (
+	"context"
+	"fmt"
+	"strings"
+	"synapse/internal/db"
+	"synapse/internal/models"
+	"synapse/internal/repository"
+
+	"github.com/google/uuid"
+)
+
+// maxEmbeddingRunes bounds the text embedded for a whole item; chunks cover the rest
+// of long items
+const maxEmbeddingRunes = 8000
+
+// Indexer regenerates an item's vector after its content or annotations change, and
+// embeds the chunks of long items
+type Indexer struct {
+	itemRepo        *repository.ItemRepository
+	annotationRepo  *repository.AnnotationRepository
+	chunkRepo       *repository.ChunkRepository
+	aiService       *AIService
+	collectionName  string
+	chunkCollection string
+}
+
+func NewIndexer(itemRepo *repository.ItemRepository, annotationRepo *repository.AnnotationRepository, chunkRepo *repository.ChunkRepository, aiService *AIService) *Indexer {
+	return &Indexer{
+		itemRepo:        itemRepo,
+		annotationRepo:  annotationRepo,
+		chunkRepo:       chunkRepo,
+		aiService:       aiService,
+		collectionName:  "synapse_items",
+		chunkCollection: "synapse_chunks",
+	}
+}
+
+// Reindex embeds an item's content together with its highlights and notes and replaces
+// its vector in ChromaDB
+func (s *Indexer) Reindex(ctx context.Context, itemID uuid.UUID) error {
+	item, err := s.itemRepo.GetByID(ctx, itemID)
+	if err != nil {
+		return err
+	}
+	annotations, err := s.annotationRepo.ListForItem(ctx, itemID)
+	if err != nil {
+		return err
+	}
+
+	text := describedText(itemText(item), item.ImageCaption, item.OcrText)
+	embedding, err := s.aiService.GenerateEmbedding(ctx, embeddingText(truncateRunes(text, maxEmbeddingRunes), annotations))
+	if err != nil {
+		return fmt.Errorf("failed to generate embedding: %w", err)
+	}
+	metadata := map[string]interface{}{
+		"title": item.Title,
+		"type":  item.Type,
+	}
+	embeddingID := item.EmbeddingID
+	if embeddingID == "" {
+		embeddingID = item.ID.String()
+	}
+	return db.Chroma.UpsertEmbedding(s.collectionName, embeddingID, embedding, metadata)
+}
+
+// ReindexAsync reindexes an item in the background, logging failures
+func (s *Indexer) ReindexAsync(itemID uuid.UUID) {
+	go func() {
+		if err := s.Reindex(context.Background(), itemID); err != nil {
+			fmt.Printf("Warning: Failed to reindex item %s: %v\n", itemID, err)
+		}
+	}()
+}
+
+// IndexChunks embeds each of an item's chunks into the chunk collection. Chunks that
+// fail to embed are skipped, so the rest stay searchable.
+func (s *Indexer) IndexChunks(ctx context.Context, itemID uuid.UUID) error {
+	chunks, err := s.chunkRepo.ListForItem(ctx, itemID)
+	if err != nil {
+		return err
+	}
+
+	failed := 0
+	for _, chunk := range chunks {
+		embedding, err := s.aiService.GenerateEmbedding(ctx, chunk.Content)
+		if err == nil {
+			metadata := map[string]interface{}{
+				"item_id":  itemID.String(),
+				"page":     chunk.Page,
+				"position": chunk.Position,
+			}
+			err = db.Chroma.UpsertEmbedding(s.chunkCollection, chunk.EmbeddingID, embedding, metadata)
+		}
+		if err != nil {
+			failed++
+			fmt.Printf("Warning: Failed to index chunk %d of item %s: %v\n", chunk.Position, itemID, err)
+		}
+	}
+	if failed > 0 && failed == len(chunks) {
+		return fmt.Errorf("none of %d chunks indexed", failed)
+	}
+	return nil
+}
+
+// IndexChunksAsync indexes an item's chunks in the background, logging failures
+func (s *Indexer) IndexChunksAsync(itemID uuid.UUID) {
+	go func() {
+		if err := s.IndexChunks(context.Background(), itemID); err != nil {
+			fmt.Printf("Warning: Failed to index chunks of item %s: %v\n", itemID, err)
+		}
+	}()
+}
+
+// truncateRunes cuts text to at most n runes
+func truncateRunes(text string, n int) string {
+	if len(text) <= n {
+		return text
+	}
+	runes := []rune(text)
+	if len(runes) <= n {
+		return text
+	}
+	return string(runes[:n])
+}
+
+// describedText adds what is known about an item's image to its text: the text read
+// from the image, unless the item's text already holds it (uploads and screenshots),
+// and the vision model's description
+func describedText(text, caption, ocrText string) string {
+	if ocrText != "" && !strings.Contains(text, ocrText) {
+		text = joinNote(text, ocrText)
+	}
+	if caption != "" {
+		text += "\n\nImage: " + caption
+	}
+	return text
+}
+
+// embeddingText appends the quoted highlights and notes to an item's content, so
+// semantic search also finds an item by what the user marked or wrote about it
+func embeddingText(content string, annotations []models.Annotation) string {
+	if len(annotations) == 0 {
+		return content
+	}
+
+	var b strings.Builder
+	b.WriteString(content)
+	b.WriteString("\n\nHighlights and notes:")
+	for _, annotation := range annotations {
+		if annotation.Quote != "" {
+			b.WriteString("\n> " + annotation.Quote)
+		}
+		if annotation.Note != "" {
+			b.WriteString("\n" + annotation.Note)
+		}
+	}
+	return b.String()
+}
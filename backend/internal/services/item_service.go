@@ -2,8 +2,11 @@ package services
 
 import (
 	"context"
+	"errors"
 	"fmt"
+	"net/http"
 	"regexp"
+	"strconv"
 	"strings"
 	"synapse/internal/db"
 	"synapse/internal/models"
@@ -11,96 +14,184 @@ import (
 	"time"
 
 	"github.com/google/uuid"
+	"github.com/jackc/pgx/v5"
 )
 
+var ErrItemNotFound = errors.New("item not found")
+
+var ErrEnrichmentFailed = errors.New("AI enrichment failed")
+
 type ItemService struct {
-	itemRepo        *repository.ItemRepository
-	aiService       *AIService
-	metadataService *MetadataService
-	ocrService      *OCRService
-	collectionName  string
+	itemRepo          *repository.ItemRepository
+	aiService         *AIService
+	categoryService   *CategoryService
+	tagService        *TagService
+	ruleService       *RuleService
+	collectionService *CollectionService
+	metadataService   *MetadataService
+	ocrService        *OCRService
+	chunkRepo         *repository.ChunkRepository
+	indexer           *Indexer
+	fileService       *FileService
+	imageService      *ImageService
+	imageEmbeddings   *ImageEmbeddingService
+	collectionName    string
 }
 
-func NewItemService(itemRepo *repository.ItemRepository, aiService *AIService) *ItemService {
+func NewItemService(itemRepo *repository.ItemRepository, aiService *AIService, categoryService *CategoryService, tagService *TagService, ruleService *RuleService, collectionService *CollectionService, chunkRepo *repository.ChunkRepository, indexer *Indexer, fileService *FileService, imageService *ImageService, ocrService *OCRService, imageEmbeddings *ImageEmbeddingService) *ItemService {
 	return &ItemService{
-		itemRepo:        itemRepo,
-		aiService:       aiService,
-		metadataService: NewMetadataService(),
-		ocrService:      NewOCRService(),
-		collectionName:  "synapse_items",
+		itemRepo:          itemRepo,
+		aiService:         aiService,
+		categoryService:   categoryService,
+		tagService:        tagService,
+		ruleService:       ruleService,
+		collectionService: collectionService,
+		metadataService:   NewMetadataService(),
+		ocrService:        ocrService,
+		chunkRepo:         chunkRepo,
+		indexer:           indexer,
+		fileService:       fileService,
+		imageService:      imageService,
+		imageEmbeddings:   imageEmbeddings,
+		collectionName:    "synapse_items",
 	}
 }
 
+// sourceDocument is what was extracted from an item's source before the item is created:
+// a fetched article, a PDF's pages or an uploaded image. Its fields are nil when unused.
+type sourceDocument struct {
+	article   *models.Article
+	pdf       *models.PDFDocument
+	image     []byte // Uploaded image or screenshot, OCR'd from its bytes
+	imageType string
+	ocr       *models.OCRResult // Text recognized in image
+}
+
+// screenshotPlaceholder starts the content older extension versions send with screenshots
+const screenshotPlaceholder = "Screenshot captured from:"
+
 func (s *ItemService) CreateItem(ctx context.Context, req *models.CreateItemRequest) (*models.Item, error) {
+	return s.createItem(ctx, req, s.fetchSource(ctx, req))
+}
+
+// fetchSource fetches what a save without content points at: bare URL saves (without
+// the extension) get their text and metadata from the page, and PDFs from the document
+// itself. Screenshots sent as data URLs are decoded to be OCR'd. The request is filled
+// from what was found.
+func (s *ItemService) fetchSource(ctx context.Context, req *models.CreateItemRequest) *sourceDocument {
+	source := &sourceDocument{}
+	if isInlineImageSave(req) {
+		image, mimeType, err := decodeDataURL(req.ImageURL)
+		if err != nil {
+			fmt.Printf("Warning: Failed to decode screenshot of %s: %v\n", req.SourceURL, err)
+			return source
+		}
+		source.image, source.imageType = image, mimeType
+		if strings.HasPrefix(strings.TrimSpace(req.Content), screenshotPlaceholder) {
+			// The recognized text describes the screenshot better
+			req.Content = ""
+		}
+	} else if isPDFURL(req.SourceURL) && hasNoContent(req) {
+		fetched, err := s.metadataService.FetchPDF(ctx, req.SourceURL)
+		if err != nil {
+			fmt.Printf("Warning: Failed to extract PDF text from %s: %v\n", req.SourceURL, err)
+		} else if pdfText(fetched) != "" {
+			source.pdf = fetched
+			applyPDF(req, fetched)
+		}
+	} else if isBareURLSave(req) {
+		fetched, err := s.metadataService.FetchArticle(ctx, req.SourceURL)
+		if err != nil {
+			fmt.Printf("Warning: Failed to extract article from %s: %v\n", req.SourceURL, err)
+		} else if fetched.Content != "" {
+			source.article = fetched
+			applyArticle(req, fetched)
+		}
+	}
+	return source
+}
+
+// createItem enriches, embeds and saves an item, running the extraction steps that fit
+// its source
+func (s *ItemService) createItem(ctx context.Context, req *models.CreateItemRequest, source *sourceDocument) (*models.Item, error) {
 	// Generate ID
 	itemID := uuid.New()
 	embeddingID := itemID.String()
+	article := source.article
 
-	// Prepare content for processing
-	content := req.Content
+	// Captured HTML and Markdown are stored as sanitized Markdown; everything else
+	// (rules, AI and embeddings) works on the plain-text projection
+	format, body := contentBody(req)
+	if body == "" {
+		format, body = models.FormatText, req.Title
+	}
+	content := PlainText(format, body)
 	if content == "" {
 		content = req.Title
 	}
 
-	// Generate category, tags, and embedding in parallel (synchronous for initial save)
-	type categoryResult struct {
-		category string
-		err      error
+	// Images are read and described first so that their text and description are
+	// enriched and embedded with the item
+	caption := ""
+	if source.image != nil {
+		source.ocr, caption = s.readImage(ctx, source.image, source.imageType, req.OCRLanguages)
 	}
-	type tagsResult struct {
-		tags []string
-		err  error
+	if text := OCRText(source.ocr); text != "" {
+		if content == req.Title {
+			content = text
+		} else {
+			content = joinNote(content, text)
+		}
+	}
+	// The caption goes to the AI steps and the embedding but stays out of the content
+	described := describedText(content, caption, "")
+
+	// Rules run first; they can set the category and tags and skip AI steps
+	classified := &models.Item{
+		Title:          req.Title,
+		Content:        described,
+		SourceURL:      req.SourceURL,
+		Type:           req.Type,
+		Metadata:       req.Metadata,
+		CategorySource: models.SourceAI,
 	}
+	if article != nil {
+		classified.Language = article.Language
+	}
+	match := s.ruleService.Evaluate(ctx, classified)
+	skipSummary := containsString(match.SkipAI, models.StepSummary)
+
+	// Generate enrichment (category, tags, summary, ...) and embedding in parallel (synchronous for initial save)
 	type embeddingResult struct {
 		embedding []float32
 		err       error
 	}
 
-	categoryChan := make(chan categoryResult, 1)
-	tagsChan := make(chan tagsResult, 1)
+	enrichmentChan := make(chan *models.Enrichment, 1)
 	embeddingChan := make(chan embeddingResult, 1)
 
-	// Generate category (AI-powered categorization)
+	// Enrich content (AI-powered categorization and tagging)
 	go func() {
-		category, err := s.aiService.CategorizeContent(ctx, req.Title, content, req.Type)
-		categoryChan <- categoryResult{category: category, err: err}
-	}()
-
-	// Generate tags
-	go func() {
-		tags, err := s.aiService.GenerateTags(ctx, content)
-		tagsChan <- tagsResult{tags: tags, err: err}
+		enrichment, err := s.enrich(ctx, classified, match)
+		if err != nil {
+			fmt.Printf("Warning: %v\n", err)
+		}
+		enrichmentChan <- enrichment
 	}()
 
 	// Generate embedding
 	go func() {
-		embedding, err := s.aiService.GenerateEmbedding(ctx, content)
+		embedding, err := s.aiService.GenerateEmbedding(ctx, truncateRunes(described, maxEmbeddingRunes))
 		embeddingChan <- embeddingResult{embedding: embedding, err: err}
 	}()
 
 	// Wait for all results
-	categoryRes := <-categoryChan
-	tagsRes := <-tagsChan
+	enrichment := <-enrichmentChan
 	embeddingRes := <-embeddingChan
 
-	// Handle errors - make AI features optional if API fails
-	if categoryRes.err != nil {
-		// If categorization fails, use a default category based on type
-		categoryRes.category = s.getDefaultCategory(req.Type, req.SourceURL)
-	}
-	
-	// Override category for YouTube videos - always "Videos & Entertainment"
-	if req.SourceURL != "" && (strings.Contains(req.SourceURL, "youtube.com") || strings.Contains(req.SourceURL, "youtu.be")) {
-		categoryRes.category = "Videos & Entertainment"
-	}
-	
-	// Override category for video type - always "Videos & Entertainment"
-	if req.Type == "video" {
-		categoryRes.category = "Videos & Entertainment"
-	}
-	if tagsRes.err != nil {
-		// Tags are optional, continue with empty tags
-		tagsRes.tags = []string{}
+	s.applyClassification(classified, enrichment, match)
+	if classified.Category == "" {
+		classified.Category = s.categoryService.DefaultFor(ctx, req.Type)
 	}
 	if embeddingRes.err != nil {
 		// If embedding fails, we can't proceed - return error
@@ -142,12 +233,17 @@ func (s *ItemService) CreateItem(ctx context.Context, req *models.CreateItemRequ
 		// If URL type (not video), get embed and preview
 		// This will also handle PDFs via GetURLMetadata
 		if req.Type == "url" && req.SourceURL != "" && embedHTML == "" {
-			embedHTML, imageURL, err = s.metadataService.GetURLMetadata(ctx, req.SourceURL)
+			if article != nil {
+				// The page was already fetched for its article
+				embedHTML = PreviewEmbed(imageURL)
+			} else {
+				embedHTML, imageURL, err = s.metadataService.GetURLMetadata(ctx, req.SourceURL)
+			}
 		}
 		
 		// Check if URL is a PDF and generate embed if needed (fallback if GetURLMetadata didn't catch it)
-		if embedHTML == "" && req.SourceURL != "" && (strings.HasSuffix(strings.ToLower(req.SourceURL), ".pdf") || strings.Contains(strings.ToLower(req.SourceURL), ".pdf?")) {
-			embedHTML = fmt.Sprintf(`<iframe width="100%%" height="100%%" src="%s" frameborder="0" style="position: absolute; top: 0; left: 0; width: 100%%; height: 100%%;" type="application/pdf"></iframe>`, req.SourceURL)
+		if embedHTML == "" && req.SourceURL != "" && isPDFURL(req.SourceURL) {
+			embedHTML = PDFEmbed(req.SourceURL)
 		}
 		
 		// Check if URL is a YouTube video even if type is not "video"
@@ -201,28 +297,36 @@ func (s *ItemService) CreateItem(ctx context.Context, req *models.CreateItemRequ
 		// If still no image, try to fetch a relevant image based on category
 		// This should work for all content types (text, blog, etc.)
 		if imageURL == "" {
-			if categoryRes.category != "" {
+			if classified.Category != "" {
 				// Use category-based image fetching
-				relevantImage, err2 := s.metadataService.FetchRelevantImage(ctx, req.Title, content, req.Type, categoryRes.category)
+				relevantImage, err2 := s.metadataService.FetchRelevantImage(ctx, req.Title, content, req.Type, s.categoryService.ImageKeyword(ctx, classified.Category))
 				if err2 == nil && relevantImage != "" {
 					imageURL = relevantImage
 				}
 			} else if req.Type != "" {
 				// Fallback: use type-based default category
-				defaultCategory := s.getDefaultCategory(req.Type, req.SourceURL)
+				defaultCategory := s.categoryService.DefaultFor(ctx, req.Type)
 				if defaultCategory != "" {
-					relevantImage, err2 := s.metadataService.FetchRelevantImage(ctx, req.Title, content, req.Type, defaultCategory)
+					relevantImage, err2 := s.metadataService.FetchRelevantImage(ctx, req.Title, content, req.Type, s.categoryService.ImageKeyword(ctx, defaultCategory))
 					if err2 == nil && relevantImage != "" {
 						imageURL = relevantImage
 					}
 				}
 			}
 		}
+
+		// Without an image of its own, an item gets a cover drawn from its category
+		if imageURL == "" && req.Type != "video" && req.Type != "amazon" {
+			imageURL = s.coverImage(ctx, req.Title, classified.Category)
+		}
 		
 		metadataChan <- metadataResult{embedHTML: embedHTML, imageURL: imageURL, err: err}
 	}()
 	
 	metadataRes := <-metadataChan
+	if strings.HasPrefix(metadataRes.imageURL, "data:") {
+		metadataRes.imageURL, metadataRes.embedHTML = s.storeInlineImage(ctx, metadataRes.imageURL, metadataRes.embedHTML)
+	}
 
 	// Store embedding in ChromaDB (optional - if it fails, continue without vector search)
 	metadata := map[string]interface{}{
@@ -236,17 +340,11 @@ func (s *ItemService) CreateItem(ctx context.Context, req *models.CreateItemRequ
 		// Continue without embedding - item can still be saved
 	}
 
-		// Extract OCR text from images/screenshots asynchronously
-		var ocrText string
-		if (req.Type == "image" || req.Type == "screenshot") && metadataRes.imageURL != "" {
-			// Extract OCR text in background
-			go func() {
-				extractedText, err := s.ocrService.ExtractTextFromImage(context.Background(), metadataRes.imageURL)
-				if err == nil && extractedText != "" {
-					// Update item with OCR text
-					s.updateOCRText(context.Background(), itemID, extractedText)
-				}
-			}()
+		// Uploads and screenshots were read above; images saved by URL are read in the background
+		ocrText := OCRText(source.ocr)
+		readImageURL := ""
+		if source.image == nil && (req.Type == "image" || req.Type == "screenshot") {
+			readImageURL = metadataRes.imageURL
 		}
 
 		// Set initial summary (will be replaced by async AI summary)
@@ -283,29 +381,64 @@ func (s *ItemService) CreateItem(ctx context.Context, req *models.CreateItemRequ
 		}
 
 		item := &models.Item{
-			ID:          itemID,
-			Title:       req.Title,
-			Content:     content,
-			Summary:     initialSummary, // Temporary summary, will be replaced asynchronously
-			SourceURL:   req.SourceURL,
-			Type:        req.Type,
-			Category:    categoryRes.category,
-			Tags:        tagsRes.tags,
-			EmbeddingID: embeddingID,
-			ImageURL:    metadataRes.imageURL,
-			EmbedHTML:   metadataRes.embedHTML,
-			OcrText:     ocrText, // Will be updated asynchronously for images
-			CreatedAt:   time.Now(),
+			ID:             itemID,
+			Title:          req.Title,
+			Content:        body,
+			ContentFormat:  format,
+			ContentText:    content,
+			Summary:        initialSummary, // Temporary summary, will be replaced asynchronously
+			SourceURL:      req.SourceURL,
+			Type:           req.Type,
+			Category:       classified.Category,
+			CategorySource: classified.CategorySource,
+			Tags:           classified.Tags,
+			TagSources:     classified.TagSources,
+			EmbeddingID:    embeddingID,
+			ImageURL:       metadataRes.imageURL,
+			EmbedHTML:      metadataRes.embedHTML,
+			OcrText:        ocrText, // Updated asynchronously for images saved by URL
+			ImageCaption:   caption,
+			Entities:       classified.Entities,
+			Language:       classified.Language,
+			ContentKind:    classified.ContentKind,
+			Metadata:       req.Metadata,
+			CreatedAt:      time.Now(),
+		}
+
+		// The enrichment call already produced a semantic summary
+		hasSummary := enrichment != nil && enrichment.Summary != ""
+		if req.Type != "video" && hasSummary && !skipSummary {
+			item.Summary = enrichment.Summary
 		}
 
 		// Save to database
 		if err := s.itemRepo.Create(ctx, item); err != nil {
 			return nil, fmt.Errorf("failed to save item: %w", err)
 		}
+		s.addToCollections(ctx, itemID, match.Collections)
+		if hash, ok := s.fileService.HashOf(item.ImageURL); ok {
+			if err := s.fileService.Attach(ctx, itemID, models.FileRoleImage, hash, ""); err != nil {
+				fmt.Printf("Warning: Failed to attach image to item %s: %v\n", itemID, err)
+			}
+		}
+		s.imageService.ProcessAsync(itemID, item.ImageURL)
+		if source.image != nil {
+			if source.ocr != nil {
+				s.saveOCR(ctx, itemID, source.ocr)
+			}
+			s.imageEmbeddings.IndexAsync(itemID, req.Type, source.image, source.imageType)
+		} else if readImageURL != "" {
+			go s.readImageURL(context.Background(), itemID, req.Type, readImageURL, req.OCRLanguages)
+		}
+		if source.pdf != nil {
+			s.indexPages(ctx, itemID, source.pdf.Pages)
+		}
 
 		// Asynchronously generate AI summary (doesn't affect description/content)
 		// For videos, extract description and generate a short summary
-		if req.Type == "video" && req.SourceURL != "" {
+		if skipSummary {
+			// A rule turned AI summaries off for this item; keep the initial summary
+		} else if req.Type == "video" && req.SourceURL != "" {
 			// Extract description from metadata if available, otherwise use content
 			description := ""
 			if req.Metadata != nil && req.Metadata["description"] != "" {
@@ -323,14 +456,349 @@ func (s *ItemService) CreateItem(ctx context.Context, req *models.CreateItemRequ
 				// Generate short AI summary asynchronously (description stays unchanged)
 				go s.generateAndUpdateVideoSummaryAsync(context.Background(), itemID, req.SourceURL, req.Title, description)
 			}
-		} else {
+		} else if !hasSummary {
 			// For non-videos, generate regular summary
-			go s.generateAndUpdateSummaryAsync(context.Background(), itemID, req.Title, content)
+			go s.generateAndUpdateSummaryAsync(context.Background(), itemID, req.Title, described)
+		}
+
+	return item, nil
+}
+
+// storeInlineImage moves an image sent as a data URL (screenshots) into the blob store
+// and returns the image URL and embed pointing at the stored file. If the image can't
+// be stored it stays inline.
+func (s *ItemService) storeInlineImage(ctx context.Context, dataURL, embedHTML string) (string, string) {
+	blob, err := s.fileService.StoreDataURL(ctx, dataURL)
+	if err != nil {
+		fmt.Printf("Warning: Failed to store inline image: %v\n", err)
+		return dataURL, embedHTML
+	}
+	fileURL := s.fileService.URL(blob.Hash)
+	return fileURL, strings.ReplaceAll(embedHTML, dataURL, fileURL)
+}
+
+// isBareURLSave reports whether req is a web page saved without its text, as the
+// extension sends it
+func isBareURLSave(req *models.CreateItemRequest) bool {
+	lowerURL := strings.ToLower(req.SourceURL)
+	if !strings.HasPrefix(lowerURL, "http://") && !strings.HasPrefix(lowerURL, "https://") {
+		return false
+	}
+	if req.Type == "video" || strings.Contains(lowerURL, "youtube.com") || strings.Contains(lowerURL, "youtu.be") || isPDFURL(lowerURL) {
+		return false
+	}
+	return hasNoContent(req)
+}
+
+// isInlineImageSave reports whether req is an image saved as a data URL, as the
+// extension sends screenshots
+func isInlineImageSave(req *models.CreateItemRequest) bool {
+	return (req.Type == "image" || req.Type == "screenshot") && strings.HasPrefix(req.ImageURL, "data:image/")
+}
+
+// hasNoContent reports whether a save carries no text beyond its URL or title
+func hasNoContent(req *models.CreateItemRequest) bool {
+	content := strings.TrimSpace(req.Content)
+	return strings.TrimSpace(req.HTML) == "" && (content == "" || content == req.SourceURL || content == req.Title)
+}
+
+// applyPDF fills a PDF save from the document: its text becomes the content, and its
+// author and page count are added to the metadata
+func applyPDF(req *models.CreateItemRequest, doc *models.PDFDocument) {
+	req.Content = pdfText(doc)
+	req.Format = models.FormatText
+	if title := strings.TrimSpace(req.Title); title == "" || title == req.SourceURL {
+		req.Title = pdfTitle(doc, req.SourceURL)
+	}
+
+	if req.Metadata == nil {
+		req.Metadata = map[string]string{}
+	}
+	if doc.Author != "" && req.Metadata["author"] == "" {
+		req.Metadata["author"] = doc.Author
+	}
+	req.Metadata["page_count"] = strconv.Itoa(doc.PageCount)
+}
+
+// indexPages stores the page-numbered chunks of an item and embeds them in the background
+func (s *ItemService) indexPages(ctx context.Context, itemID uuid.UUID, pages []string) {
+	if err := s.chunkRepo.Replace(ctx, itemID, pageChunks(itemID, pages)); err != nil {
+		fmt.Printf("Warning: Failed to save chunks of item %s: %v\n", itemID, err)
+		return
+	}
+	s.indexer.IndexChunksAsync(itemID)
+}
+
+// contentBody returns the format and stored form of a request's content: HTML is
+// converted to Markdown, and Markdown is sanitized
+func contentBody(req *models.CreateItemRequest) (string, string) {
+	if strings.TrimSpace(req.HTML) != "" {
+		md, err := HTMLToMarkdown(req.HTML, req.SourceURL)
+		if err == nil && md != "" {
+			return models.FormatMarkdown, md
+		}
+		if err != nil {
+			fmt.Printf("Warning: Failed to convert HTML to Markdown: %v\n", err)
+		}
+	}
+	if req.Format == models.FormatMarkdown {
+		return models.FormatMarkdown, SanitizeMarkdown(req.Content)
+	}
+	return models.FormatText, req.Content
+}
+
+// applyArticle fills a bare URL save from the page's article. The extracted byline,
+// site name and publication date are added to the metadata unless already given.
+func applyArticle(req *models.CreateItemRequest, article *models.Article) {
+	req.Content = article.Content
+	req.Format = models.FormatMarkdown
+	req.HTML = ""
+	if title := strings.TrimSpace(req.Title); (title == "" || title == req.SourceURL) && article.Title != "" {
+		req.Title = article.Title
+	}
+	if req.ImageURL == "" {
+		req.ImageURL = article.LeadImage
+	}
+
+	if req.Metadata == nil {
+		req.Metadata = map[string]string{}
+	}
+	setDefault := func(key, value string) {
+		if value != "" && req.Metadata[key] == "" {
+			req.Metadata[key] = value
+		}
+	}
+	setDefault("author", article.Byline)
+	setDefault("site_name", article.SiteName)
+	setDefault("description", article.Excerpt)
+	if article.PublishedAt != nil {
+		setDefault("published_at", article.PublishedAt.Format(time.RFC3339))
+	}
+}
+
+// enrich runs AI enrichment for item, or returns nil if a matching rule skips it
+func (s *ItemService) enrich(ctx context.Context, item *models.Item, match *models.RuleMatch) (*models.Enrichment, error) {
+	if containsString(match.SkipAI, models.StepEnrichment) {
+		return nil, nil
+	}
+	return s.enrichContent(ctx, item.Title, describedText(itemText(item), item.ImageCaption, item.OcrText), item.Type)
+}
+
+// itemText returns the plain-text projection of an item's content
+func itemText(item *models.Item) string {
+	if item.ContentText != "" {
+		return item.ContentText
+	}
+	return PlainText(item.ContentFormat, item.Content)
+}
+
+// applyClassification sets the category and tags of item from rule actions and AI
+// enrichment (nil when skipped or failed). Values set by the user or an import are never
+// replaced, rule values only by rules, and AI values are replaced by the new AI answer.
+func (s *ItemService) applyClassification(item *models.Item, enrichment *models.Enrichment, match *models.RuleMatch) {
+	switch {
+	case item.CategorySource == models.SourceUser || item.CategorySource == models.SourceImport:
+	case match.Category != "":
+		item.Category = match.Category
+		item.CategorySource = models.SourceRule
+	case item.CategorySource == models.SourceAI && enrichment != nil && enrichment.Category != "":
+		item.Category = enrichment.Category
+	}
+
+	// Without new AI tags (nil after a failed tagging call) the previous AI tags stay
+	newAITags := enrichment != nil && enrichment.Tags != nil
+
+	tags := []string{}
+	sources := map[string]string{}
+	for _, tag := range item.Tags {
+		source := item.TagSources[tag]
+		if source == "" {
+			source = models.SourceAI
+		}
+		if source != models.SourceAI || !newAITags {
+			tags = append(tags, tag)
+			sources[tag] = source
+		}
+	}
+	for _, tag := range match.Tags {
+		if source, ok := sources[tag]; !ok || source == models.SourceAI {
+			if !ok {
+				tags = append(tags, tag)
+			}
+			sources[tag] = models.SourceRule
+		}
+	}
+	if enrichment != nil {
+		for _, tag := range enrichment.Tags {
+			if _, ok := sources[tag]; !ok {
+				tags = append(tags, tag)
+				sources[tag] = models.SourceAI
+			}
+		}
+
+		// The fallback path doesn't extract these; keep the previous values then
+		if len(enrichment.Entities) > 0 || item.Entities == nil {
+			item.Entities = enrichment.Entities
+		}
+		if enrichment.Language != "" {
+			item.Language = enrichment.Language
+		}
+		if enrichment.ContentKind != "" {
+			item.ContentKind = enrichment.ContentKind
+		}
+	}
+	if item.Entities == nil {
+		item.Entities = []string{}
+	}
+	item.Tags = tags
+	item.TagSources = sources
+}
+
+// enrichContent runs the single-call AI enrichment, falling back to separate
+// categorization and tagging calls when it fails. The category is empty when the
+// categorization call failed too, and the tags are nil when the tagging call did; when
+// both fail it returns ErrEnrichmentFailed.
+func (s *ItemService) enrichContent(ctx context.Context, title, content, itemType string) (*models.Enrichment, error) {
+	categories := s.categoryService.Categories(ctx)
+	knownTags := s.tagService.Hints(ctx)
+	enrichment, err := s.aiService.EnrichContent(ctx, title, content, itemType, categories, knownTags)
+	if err == nil {
+		enrichment.Tags = s.tagService.Normalize(ctx, enrichment.Tags)
+		return enrichment, nil
+	}
+	fmt.Printf("Warning: AI enrichment failed, falling back to separate calls: %v\n", err)
+
+	type categoryResult struct {
+		category string
+		err      error
+	}
+	type tagsResult struct {
+		tags []string
+		err  error
+	}
+
+	categoryChan := make(chan categoryResult, 1)
+	tagsChan := make(chan tagsResult, 1)
+
+	// Generate category (AI-powered categorization)
+	go func() {
+		category, err := s.aiService.CategorizeContent(ctx, title, content, itemType, categories)
+		categoryChan <- categoryResult{category: category, err: err}
+	}()
+
+	// Generate tags
+	go func() {
+		tags, err := s.aiService.GenerateTags(ctx, content, knownTags)
+		tagsChan <- tagsResult{tags: tags, err: err}
+	}()
+
+	categoryRes := <-categoryChan
+	tagsRes := <-tagsChan
+
+	if categoryRes.err != nil && tagsRes.err != nil {
+		return nil, fmt.Errorf("%w: %v", ErrEnrichmentFailed, categoryRes.err)
+	}
+
+	// Whatever failed is left unset so callers keep their current values
+	fallback := &models.Enrichment{Entities: []string{}}
+	if categoryRes.err == nil {
+		fallback.Category = categoryRes.category
+	}
+	if tagsRes.err == nil {
+		fallback.Tags = s.tagService.Normalize(ctx, tagsRes.tags)
+	}
+	return fallback, nil
+}
+
+// UpdateItem applies a user's edits to an item. The category and tags given are locked
+// against AI re-enrichment; the favorite, pinned, read and archived flags given are set.
+func (s *ItemService) UpdateItem(ctx context.Context, id uuid.UUID, req *models.UpdateItemRequest) (*models.Item, error) {
+	item, err := s.GetItem(ctx, id)
+	if err != nil {
+		return nil, err
+	}
+
+	if req.Category != nil {
+		category := s.categoryService.Resolve(ctx, *req.Category)
+		if category == "" {
+			return nil, fmt.Errorf("%w: unknown category %q", ErrInvalidCategory, *req.Category)
+		}
+		item.Category = category
+		item.CategorySource = models.SourceUser
+	}
+
+	if req.Tags != nil {
+		tags := s.tagService.Normalize(ctx, *req.Tags)
+		sources := sourcesOf(tags, models.SourceUser)
+		for _, tag := range tags {
+			// Tags set by a rule or an import keep their source
+			if source := item.TagSources[tag]; source == models.SourceRule || source == models.SourceImport {
+				sources[tag] = source
+			}
+		}
+		item.Tags = tags
+		item.TagSources = sources
+	}
+
+	if req.Category != nil || req.Tags != nil {
+		if err := s.itemRepo.UpdateClassification(ctx, item, models.SourceUser); err != nil {
+			return nil, err
 		}
+	}
+
+	if req.Favorite != nil || req.Pinned != nil || req.Read != nil || req.Archived != nil {
+		if err := s.itemRepo.UpdateState(ctx, id, req); err != nil {
+			if errors.Is(err, pgx.ErrNoRows) {
+				return nil, ErrItemNotFound
+			}
+			return nil, err
+		}
+		return s.GetItem(ctx, id)
+	}
+	return item, nil
+}
+
+// ReEnrichItem evaluates rules and runs AI enrichment again. Only values set by the AI,
+// or by a rule when a rule matches again, are replaced; user and import values are kept.
+// When the AI provider fails it returns ErrEnrichmentFailed and changes nothing.
+func (s *ItemService) ReEnrichItem(ctx context.Context, id uuid.UUID) (*models.Item, error) {
+	item, err := s.GetItem(ctx, id)
+	if err != nil {
+		return nil, err
+	}
+
+	match := s.ruleService.Evaluate(ctx, item)
+	enrichment, err := s.enrich(ctx, item, match)
+	if err != nil {
+		return nil, err
+	}
+	s.applyClassification(item, enrichment, match)
 
+	if err := s.itemRepo.UpdateClassification(ctx, item, models.SourceAI); err != nil {
+		return nil, err
+	}
+	s.addToCollections(ctx, id, match.Collections)
 	return item, nil
 }
 
+// addToCollections adds an item to the collections picked by rules
+func (s *ItemService) addToCollections(ctx context.Context, itemID uuid.UUID, collectionIDs []uuid.UUID) {
+	for _, collectionID := range collectionIDs {
+		if _, err := s.collectionService.AddItems(ctx, collectionID, []uuid.UUID{itemID}); err != nil {
+			fmt.Printf("Warning: Failed to add item %s to collection %s: %v\n", itemID, collectionID, err)
+		}
+	}
+}
+
+// sourcesOf returns a tag source map recording source for every tag
+func sourcesOf(tags []string, source string) map[string]string {
+	sources := make(map[string]string, len(tags))
+	for _, tag := range tags {
+		sources[tag] = source
+	}
+	return sources
+}
+
 // extractYouTubeIDFromURL extracts YouTube video ID from URL
 func (s *ItemService) extractYouTubeIDFromURL(url string) string {
 	patterns := []string{
@@ -367,13 +835,95 @@ func (s *ItemService) generateAndUpdateSummaryAsync(ctx context.Context, itemID
 	fmt.Printf("Successfully generated and updated semantic summary for item %s\n", itemID)
 }
 
-// updateOCRText updates the OCR text for an item
-func (s *ItemService) updateOCRText(ctx context.Context, itemID uuid.UUID, ocrText string) {
-	if err := s.itemRepo.UpdateOCRText(ctx, itemID, ocrText); err != nil {
+// readImage recognizes the text in an image and describes it with the vision model,
+// concurrently. The result is nil or the caption empty when that step fails.
+func (s *ItemService) readImage(ctx context.Context, image []byte, mimeType string, languages []string) (*models.OCRResult, string) {
+	captionChan := make(chan string, 1)
+	go func() {
+		captionChan <- s.captionImage(ctx, image, mimeType)
+	}()
+
+	result, err := s.ocrService.ExtractTextFromImageData(ctx, image, mimeType, languages)
+	if err != nil {
+		fmt.Printf("Warning: Failed to extract text from image: %v\n", err)
+		result = nil
+	}
+	return result, <-captionChan
+}
+
+// captionImage describes an image, or returns "" without a vision provider
+func (s *ItemService) captionImage(ctx context.Context, image []byte, mimeType string) string {
+	if !s.aiService.visionAvailable() {
+		return ""
+	}
+	caption, err := s.aiService.CaptionImage(ctx, image, mimeType)
+	if err != nil {
+		fmt.Printf("Warning: Failed to describe image: %v\n", err)
+		return ""
+	}
+	return caption
+}
+
+// readImageURL reads and describes an item's image from its URL, embeds the image and
+// reindexes the item with what was found. Generated covers only show the title and are
+// skipped.
+func (s *ItemService) readImageURL(ctx context.Context, itemID uuid.UUID, itemType, imageURL string, languages []string) {
+	data, err := s.imageService.Load(ctx, imageURL)
+	if err != nil {
+		fmt.Printf("Warning: Failed to load image of item %s: %v\n", itemID, err)
+		return
+	}
+	if isSVG(data) {
+		return
+	}
+	mimeType, _, _ := strings.Cut(http.DetectContentType(data), ";")
+
+	result, caption := s.readImage(ctx, data, mimeType, languages)
+	if result != nil {
+		s.saveOCR(ctx, itemID, result)
+	}
+	if caption != "" {
+		if err := s.itemRepo.UpdateImageCaption(ctx, itemID, caption); err != nil {
+			fmt.Printf("Warning: Failed to save image caption of item %s: %v\n", itemID, err)
+		}
+	}
+	if err := s.imageEmbeddings.Index(ctx, itemID, itemType, data, mimeType); err != nil {
+		fmt.Printf("Warning: Failed to embed image of item %s: %v\n", itemID, err)
+	}
+	if OCRText(result) != "" || caption != "" {
+		if err := s.indexer.Reindex(ctx, itemID); err != nil {
+			fmt.Printf("Warning: Failed to reindex item %s: %v\n", itemID, err)
+		}
+	}
+}
+
+// rereadImage drops the text, description and image embedding of an item's earlier
+// image and reads its new one
+func (s *ItemService) rereadImage(ctx context.Context, item *models.Item, imageURL string) {
+	if err := s.ocrService.Delete(ctx, item.ID); err != nil {
+		fmt.Printf("Warning: Failed to clear OCR text of item %s: %v\n", item.ID, err)
+	}
+	if err := s.itemRepo.UpdateImageCaption(ctx, item.ID, ""); err != nil {
+		fmt.Printf("Warning: Failed to clear image caption of item %s: %v\n", item.ID, err)
+	}
+	if err := s.imageEmbeddings.Delete(item.ID); err != nil {
+		fmt.Printf("Warning: Failed to remove image embedding of item %s: %v\n", item.ID, err)
+	}
+	if item.OcrText != "" || item.ImageCaption != "" {
+		if err := s.indexer.Reindex(ctx, item.ID); err != nil {
+			fmt.Printf("Warning: Failed to reindex item %s: %v\n", item.ID, err)
+		}
+	}
+	s.readImageURL(ctx, item.ID, item.Type, imageURL, nil)
+}
+
+// saveOCR stores the text recognized in an item's image
+func (s *ItemService) saveOCR(ctx context.Context, itemID uuid.UUID, result *models.OCRResult) {
+	if err := s.ocrService.Save(ctx, itemID, result); err != nil {
 		fmt.Printf("Warning: Failed to update OCR text for item %s: %v\n", itemID, err)
 		return
 	}
-	fmt.Printf("Successfully updated OCR text for item %s\n", itemID)
+	fmt.Printf("Successfully updated OCR text for item %s (%s, %d blocks)\n", itemID, result.Engine, len(result.Blocks))
 }
 
 // generateAndUpdateVideoSummaryAsync generates a video-specific summary asynchronously
@@ -393,8 +943,8 @@ func (s *ItemService) generateAndUpdateVideoSummaryAsync(ctx context.Context, it
 	summary, err := s.aiService.SummarizeYouTubeVideo(ctx, videoURL, title, description)
 	if err != nil {
 		// Check if it's a quota/rate limit error
-		if strings.Contains(err.Error(), "quota") || strings.Contains(err.Error(), "429") || strings.Contains(err.Error(), "rate limit") {
-			fmt.Printf("Warning: Gemini API quota exceeded for item %s. Summary generation skipped. Error: %v\n", itemID, err)
+		if IsRateLimited(err) {
+			fmt.Printf("Warning: AI provider rate limited or out of quota for item %s. Summary generation skipped. Error: %v\n", itemID, err)
 		} else {
 			fmt.Printf("Warning: Failed to generate video summary for item %s: %v\n", itemID, err)
 			// Fallback to regular summary only if it's not a quota issue
@@ -423,67 +973,120 @@ func (s *ItemService) generateAndUpdateVideoSummaryAsync(ctx context.Context, it
 	fmt.Printf("Successfully generated and updated video summary for item %s: %s\n", itemID, summaryPreview)
 }
 
-// getDefaultCategory returns a default category based on item type and URL
-func (s *ItemService) getDefaultCategory(itemType, sourceURL string) string {
-	// Check if it's a YouTube video first
-	if sourceURL != "" && (strings.Contains(sourceURL, "youtube.com") || strings.Contains(sourceURL, "youtu.be")) {
-		return "Videos & Entertainment"
+func (s *ItemService) GetItem(ctx context.Context, id uuid.UUID) (*models.Item, error) {
+	item, err := s.itemRepo.GetByID(ctx, id)
+	if errors.Is(err, pgx.ErrNoRows) {
+		return nil, ErrItemNotFound
 	}
-	
-	typeMap := map[string]string{
-		"video":   "Videos & Entertainment",
-		"book":    "Books & Reading",
-		"recipe":  "Food & Recipes",
-		"amazon":  "Shopping & Products",
-		"blog":    "Articles & News",
-		"url":     "Articles & News",
-		"text":    "Notes & Ideas",
-		"image":   "Design & Inspiration",
-		"screenshot": "Notes & Ideas",
+	if err != nil {
+		return nil, err
 	}
-
-	if category, ok := typeMap[itemType]; ok {
-		return category
+	files, err := s.fileService.ListForItem(ctx, id)
+	if err != nil {
+		return nil, err
 	}
-	return "Other"
-}
-
-func (s *ItemService) GetItem(ctx context.Context, id uuid.UUID) (*models.Item, error) {
-	return s.itemRepo.GetByID(ctx, id)
+	if len(files) > 0 {
+		item.Files = files
+	}
+	if item.OCR, err = s.ocrService.Get(ctx, id); err != nil {
+		return nil, err
+	}
+	return item, nil
 }
 
-func (s *ItemService) GetAllItems(ctx context.Context) ([]models.Item, error) {
-	return s.itemRepo.GetAll(ctx)
+// GetAllItems lists the items matching state, pinned items first
+func (s *ItemService) GetAllItems(ctx context.Context, state models.ItemStateFilter) ([]models.Item, error) {
+	return s.itemRepo.List(ctx, state)
 }
 
+// DeleteItem moves an item to the trash; TrashService restores or purges it
 func (s *ItemService) DeleteItem(ctx context.Context, id uuid.UUID) error {
-	return s.itemRepo.Delete(ctx, id)
+	err := s.itemRepo.Delete(ctx, id)
+	if errors.Is(err, pgx.ErrNoRows) {
+		return ErrItemNotFound
+	}
+	return err
 }
 
-// RefreshImageForItem refreshes the image URL for an existing item
+// RefreshImageForItem finds an image for an item that has none, or whose image came
+// from the retired Unsplash Source service: a stock photo if an image search provider
+// is set up, otherwise a generated cover
 func (s *ItemService) RefreshImageForItem(ctx context.Context, id uuid.UUID) error {
 	item, err := s.itemRepo.GetByID(ctx, id)
 	if err != nil {
 		return err
 	}
+	if item.ImageURL != "" && !isRetiredImageURL(item.ImageURL) {
+		return nil
+	}
 
-	// Check if image URL is from deprecated Unsplash Source API
-	if item.ImageURL != "" && strings.Contains(item.ImageURL, "source.unsplash.com") {
-		// Generate new image URL using Picsum Photos
-		newImageURL, err := s.metadataService.FetchRelevantImage(ctx, item.Title, item.Content, item.Type, item.Category)
-		if err == nil && newImageURL != "" {
-			return s.itemRepo.UpdateImageURL(ctx, id, newImageURL)
-		}
+	newImageURL, err := s.metadataService.FetchRelevantImage(ctx, item.Title, itemText(item), item.Type, s.categoryService.ImageKeyword(ctx, item.Category))
+	if err != nil {
+		fmt.Printf("Warning: Failed to find an image for item %s: %v\n", id, err)
+	}
+	if newImageURL == "" {
+		newImageURL = s.coverImage(ctx, item.Title, item.Category)
+	}
+	if newImageURL == "" {
+		return nil
 	}
+	return s.setImage(ctx, item, newImageURL)
+}
 
-	// If no image exists, try to fetch one
-	if item.ImageURL == "" {
-		newImageURL, err := s.metadataService.FetchRelevantImage(ctx, item.Title, item.Content, item.Type, item.Category)
-		if err == nil && newImageURL != "" {
-			return s.itemRepo.UpdateImageURL(ctx, id, newImageURL)
+// ReplaceRetiredImages gives the items whose image came from the retired Unsplash
+// Source service a new one, and returns how many were replaced
+func (s *ItemService) ReplaceRetiredImages(ctx context.Context) (int, error) {
+	ids, err := s.itemRepo.ListIDsByImageURL(ctx, "%source.unsplash.com%")
+	if err != nil {
+		return 0, err
+	}
+	replaced := 0
+	for _, id := range ids {
+		if err := s.RefreshImageForItem(ctx, id); err != nil {
+			fmt.Printf("Warning: Failed to replace image of item %s: %v\n", id, err)
+			continue
 		}
+		replaced++
+	}
+	return replaced, nil
+}
+
+// isRetiredImageURL reports whether an image is hosted by a service that no longer
+// serves it
+func isRetiredImageURL(imageURL string) bool {
+	return strings.Contains(imageURL, "source.unsplash.com")
+}
+
+// coverImage stores a generated cover for an item and returns its URL, or "" if it
+// couldn't be stored
+func (s *ItemService) coverImage(ctx context.Context, title, category string) string {
+	cover := RenderCover(title, category, s.categoryService.Icon(ctx, category))
+	blob, err := s.fileService.Store(ctx, cover, "image/svg+xml")
+	if err != nil {
+		fmt.Printf("Warning: Failed to store generated cover: %v\n", err)
+		return ""
 	}
+	return s.fileService.URL(blob.Hash)
+}
 
+// setImage changes an item's image and processes the new one in the background; image
+// items also have it read again. A stored image is attached to the item so the cleanup
+// sweep keeps it.
+func (s *ItemService) setImage(ctx context.Context, item *models.Item, imageURL string) error {
+	if err := s.itemRepo.UpdateImageURL(ctx, item.ID, imageURL); err != nil {
+		return err
+	}
+	if hash, ok := s.fileService.HashOf(imageURL); ok {
+		if err := s.fileService.Attach(ctx, item.ID, models.FileRoleImage, hash, ""); err != nil {
+			return err
+		}
+	} else if err := s.fileService.DetachRoles(ctx, item.ID, models.FileRoleImage); err != nil {
+		return err
+	}
+	s.imageService.ProcessAsync(item.ID, imageURL)
+	if item.Type == "image" || item.Type == "screenshot" {
+		go s.rereadImage(context.Background(), item, imageURL)
+	}
 	return nil
 }
 
@@ -513,11 +1116,11 @@ func (s *ItemService) RefreshSummaryForItem(ctx context.Context, id uuid.UUID) e
 			go s.generateAndUpdateVideoSummaryAsync(context.Background(), id, item.SourceURL, item.Title, description)
 		} else {
 			// Fallback to regular summary
-			go s.generateAndUpdateSummaryAsync(context.Background(), id, item.Title, item.Content)
+			go s.generateAndUpdateSummaryAsync(context.Background(), id, item.Title, itemText(item))
 		}
 	} else {
 		// For non-videos, use regular summarization
-		go s.generateAndUpdateSummaryAsync(context.Background(), id, item.Title, item.Content)
+		go s.generateAndUpdateSummaryAsync(context.Background(), id, item.Title, itemText(item))
 	}
 
 	return nil
@@ -0,0 +1,222 @@
+package services
+
+import (
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"os"
+	"strconv"
+	"strings"
+	"synapse/internal/models"
+	"synapse/internal/repository"
+	"sync"
+	"sync/atomic"
+	"time"
+)
+
+const (
+	cacheKindCompletion = "completion"
+	cacheKindEmbedding  = "embedding"
+
+	// Prune expired and over-limit entries every N writes instead of on every write
+	cachePruneEvery = 100
+)
+
+// LLMCache is a content-addressed cache for model completions and embeddings.
+// Entries are keyed by provider, model, prompt hash and generation parameters,
+// so repeated searches and re-summaries of unchanged content skip the remote model.
+// A nil *LLMCache is valid and behaves as a disabled cache.
+type LLMCache struct {
+	repo       *repository.LLMCacheRepository
+	enabled    bool
+	ttl        time.Duration
+	maxEntries int
+
+	writes atomic.Int64
+	mu     sync.Mutex
+	hits   map[string]int64
+	misses map[string]int64
+}
+
+func NewLLMCache(repo *repository.LLMCacheRepository) *LLMCache {
+	enabled := true
+	if v := os.Getenv("LLM_CACHE_ENABLED"); v != "" {
+		if b, err := strconv.ParseBool(v); err == nil {
+			enabled = b
+		}
+	}
+
+	ttl := 7 * 24 * time.Hour
+	if v := os.Getenv("LLM_CACHE_TTL"); v != "" {
+		if d, err := time.ParseDuration(v); err == nil && d > 0 {
+			ttl = d
+		}
+	}
+
+	maxEntries := 10000
+	if v := os.Getenv("LLM_CACHE_MAX_ENTRIES"); v != "" {
+		if n, err := strconv.Atoi(v); err == nil && n >= 0 {
+			maxEntries = n
+		}
+	}
+
+	return &LLMCache{
+		repo:       repo,
+		enabled:    enabled,
+		ttl:        ttl,
+		maxEntries: maxEntries,
+		hits:       map[string]int64{},
+		misses:     map[string]int64{},
+	}
+}
+
+// Key builds the cache key for a request. params holds generation settings
+// (max tokens, temperature, ...) that change the answer for the same prompt.
+func (c *LLMCache) Key(provider, model, kind, prompt string, params map[string]interface{}) string {
+	var b strings.Builder
+	b.WriteString(provider)
+	b.WriteByte(0)
+	b.WriteString(model)
+	b.WriteByte(0)
+	b.WriteString(kind)
+	b.WriteByte(0)
+	// json.Marshal sorts map keys, so the encoding is stable
+	paramsJSON, _ := json.Marshal(params)
+	b.Write(paramsJSON)
+	b.WriteByte(0)
+	b.WriteString(prompt)
+
+	sum := sha256.Sum256([]byte(b.String()))
+	return hex.EncodeToString(sum[:])
+}
+
+// GetCompletion returns a cached completion for key
+func (c *LLMCache) GetCompletion(ctx context.Context, key string) (string, bool) {
+	return c.get(ctx, cacheKindCompletion, key)
+}
+
+// PutCompletion stores a completion under key
+func (c *LLMCache) PutCompletion(ctx context.Context, key, provider, model, text string) {
+	c.put(ctx, cacheKindCompletion, key, provider, model, text)
+}
+
+// GetEmbedding returns a cached embedding for key
+func (c *LLMCache) GetEmbedding(ctx context.Context, key string) ([]float32, bool) {
+	raw, ok := c.get(ctx, cacheKindEmbedding, key)
+	if !ok {
+		return nil, false
+	}
+
+	var embedding []float32
+	if err := json.Unmarshal([]byte(raw), &embedding); err != nil || len(embedding) == 0 {
+		return nil, false
+	}
+	return embedding, true
+}
+
+// PutEmbedding stores an embedding under key
+func (c *LLMCache) PutEmbedding(ctx context.Context, key, provider, model string, embedding []float32) {
+	if len(embedding) == 0 {
+		return
+	}
+	raw, err := json.Marshal(embedding)
+	if err != nil {
+		return
+	}
+	c.put(ctx, cacheKindEmbedding, key, provider, model, string(raw))
+}
+
+func (c *LLMCache) get(ctx context.Context, kind, key string) (string, bool) {
+	if c == nil || !c.enabled {
+		return "", false
+	}
+
+	response, ok, err := c.repo.Get(ctx, key)
+	if err != nil {
+		fmt.Printf("Warning: LLM cache lookup failed: %v\n", err)
+	}
+
+	c.mu.Lock()
+	if ok {
+		c.hits[kind]++
+	} else {
+		c.misses[kind]++
+	}
+	c.mu.Unlock()
+
+	return response, ok
+}
+
+func (c *LLMCache) put(ctx context.Context, kind, key, provider, model, response string) {
+	if c == nil || !c.enabled || response == "" {
+		return
+	}
+
+	if err := c.repo.Put(ctx, key, provider, model, kind, response, c.ttl); err != nil {
+		fmt.Printf("Warning: Failed to store LLM cache entry: %v\n", err)
+		return
+	}
+
+	if c.writes.Add(1)%cachePruneEvery == 0 {
+		if err := c.repo.Prune(ctx, c.maxEntries); err != nil {
+			fmt.Printf("Warning: Failed to prune LLM cache: %v\n", err)
+		}
+	}
+}
+
+// Clear removes every cached entry
+func (c *LLMCache) Clear(ctx context.Context) error {
+	if c == nil {
+		return nil
+	}
+	return c.repo.Clear(ctx)
+}
+
+// Stats returns entry counts from the database and hit/miss counters since startup
+func (c *LLMCache) Stats(ctx context.Context) (*models.LLMCacheStats, error) {
+	if c == nil {
+		return &models.LLMCacheStats{Kinds: []models.LLMCacheKindStats{}}, nil
+	}
+
+	kinds, err := c.repo.CountByKind(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	stats := &models.LLMCacheStats{
+		Enabled:    c.enabled,
+		TTLSeconds: int64(c.ttl.Seconds()),
+		MaxEntries: c.maxEntries,
+		Kinds:      []models.LLMCacheKindStats{},
+	}
+
+	// Make sure both kinds are reported even when nothing is stored yet
+	byKind := map[string]models.LLMCacheKindStats{
+		cacheKindCompletion: {Kind: cacheKindCompletion},
+		cacheKindEmbedding:  {Kind: cacheKindEmbedding},
+	}
+	for _, k := range kinds {
+		byKind[k.Kind] = k
+	}
+
+	for _, kind := range []string{cacheKindCompletion, cacheKindEmbedding} {
+		k := byKind[kind]
+		k.Hits = c.hits[kind]
+		k.Misses = c.misses[kind]
+		stats.Entries += k.Entries
+		stats.Hits += k.Hits
+		stats.Misses += k.Misses
+		stats.Kinds = append(stats.Kinds, k)
+	}
+
+	if total := stats.Hits + stats.Misses; total > 0 {
+		stats.HitRate = float64(stats.Hits) / float64(total)
+	}
+
+	return stats, nil
+}
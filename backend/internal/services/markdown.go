@@ -0,0 +1,372 @@
+package services
+
+import (
+	"net/url"
+	"regexp"
+	"strconv"
+	"strings"
+	"synapse/internal/models"
+
+	"golang.org/x/net/html"
+	"golang.org/x/net/html/atom"
+)
+
+var (
+	markdownEscaper = strings.NewReplacer(`\`, `\\`, "*", `\*`, "_", `\_`, "`", "\\`", "[", `\[`, "]", `\]`, "<", `\<`)
+	lineStartMarkRe = regexp.MustCompile(`^(#{1,6}\s|>|[-+]\s|\d+[.)]\s)`)
+
+	rawHTMLTagRe     = regexp.MustCompile(`</?[a-zA-Z][^>]*>|<!--[\s\S]*?-->`)
+	unsafeLinkRe     = regexp.MustCompile(`(?i)\]\(\s*(?:javascript|vbscript|data|file):(?:[^()]|\([^()]*\))*\)`)
+	headingRe        = regexp.MustCompile(`^#{1,6}\s+`)
+	quoteRe          = regexp.MustCompile(`^(?:>\s?)+`)
+	listMarkerRe     = regexp.MustCompile(`^\s*(?:[-+*]|\d+[.)])\s+`)
+	imageRe          = regexp.MustCompile(`!\[([^\]]*)\]\([^)]*\)`)
+	linkRe           = regexp.MustCompile(`\[([^\]]*)\]\([^)]*\)`)
+	strongRe         = regexp.MustCompile(`\*\*(.+?)\*\*|__(.+?)__`)
+	emphasisRe       = regexp.MustCompile(`(^|[^\w\\])[_*]([^_*\s](?:[^_*]*[^_*\s])?)[_*]`)
+	inlineCodeRe     = regexp.MustCompile("`([^`]*)`")
+	tableSeparatorRe = regexp.MustCompile(`^\|?\s*:?-{3,}:?\s*(\|\s*:?-{3,}:?\s*)*\|?$`)
+	ruleRe           = regexp.MustCompile(`^(?:-{3,}|\*{3,}|_{3,})$`)
+	blankLinesRe     = regexp.MustCompile(`\n{3,}`)
+	markdownEscapeRe = regexp.MustCompile("\\\\([\\\\`*_{}\\[\\]()#+\\-.!<>|])")
+)
+
+// HTMLToMarkdown converts captured HTML to Markdown, keeping headings, emphasis, links,
+// images, lists, quotes, tables and code blocks. It is sanitized by construction: only
+// known elements are rendered, links keep http(s) and mailto targets and images http(s)
+// ones, and text is escaped so it can't turn into markup. pageURL resolves relative links.
+func HTMLToMarkdown(input, pageURL string) (string, error) {
+	doc, err := html.Parse(strings.NewReader(input))
+	if err != nil {
+		return "", err
+	}
+	base, _ := url.Parse(pageURL)
+	return renderMarkdown(doc, base), nil
+}
+
+// SanitizeMarkdown removes raw HTML and script links from Markdown sent by a client.
+// Fenced code blocks are left as they are.
+func SanitizeMarkdown(md string) string {
+	lines := strings.Split(md, "\n")
+	inFence := false
+	for i, line := range lines {
+		if strings.HasPrefix(strings.TrimSpace(line), "```") {
+			inFence = !inFence
+			continue
+		}
+		if !inFence {
+			line = rawHTMLTagRe.ReplaceAllString(line, "")
+			lines[i] = unsafeLinkRe.ReplaceAllString(line, "](#)")
+		}
+	}
+	return strings.TrimSpace(strings.Join(lines, "\n"))
+}
+
+// PlainText returns the plain-text projection of content in format: Markdown loses its
+// markup, keeping the text of links, images and code. It is what gets embedded and searched.
+func PlainText(format, content string) string {
+	if format != models.FormatMarkdown {
+		return content
+	}
+
+	var out []string
+	inFence := false
+	for _, line := range strings.Split(content, "\n") {
+		trimmed := strings.TrimSpace(line)
+		if strings.HasPrefix(trimmed, "```") {
+			inFence = !inFence
+			continue
+		}
+		if inFence {
+			out = append(out, line)
+			continue
+		}
+		if tableSeparatorRe.MatchString(trimmed) && strings.Contains(trimmed, "-") && strings.Contains(trimmed, "|") || ruleRe.MatchString(trimmed) {
+			continue
+		}
+
+		line = quoteRe.ReplaceAllString(trimmed, "")
+		line = headingRe.ReplaceAllString(line, "")
+		line = listMarkerRe.ReplaceAllString(line, "")
+		if strings.HasPrefix(line, "|") {
+			cells := strings.Split(strings.Trim(strings.ReplaceAll(line, `\|`, "\x00"), "|"), "|")
+			for i := range cells {
+				cells[i] = strings.ReplaceAll(strings.TrimSpace(cells[i]), "\x00", "|")
+			}
+			line = strings.Join(cells, " ")
+		}
+		line = imageRe.ReplaceAllString(line, "$1")
+		line = linkRe.ReplaceAllString(line, "$1")
+		line = strongRe.ReplaceAllString(line, "$1$2")
+		line = emphasisRe.ReplaceAllString(line, "$1$2")
+		line = inlineCodeRe.ReplaceAllString(line, "$1")
+		line = markdownEscapeRe.ReplaceAllString(line, "$1")
+		out = append(out, strings.TrimRight(line, " "))
+	}
+	return strings.TrimSpace(blankLinesRe.ReplaceAllString(strings.Join(out, "\n"), "\n\n"))
+}
+
+// renderMarkdown renders the blocks under root separated by blank lines
+func renderMarkdown(root *html.Node, base *url.URL) string {
+	return strings.Join(markdownBlocks(root, base), "\n\n")
+}
+
+// markdownBlocks renders the children of n as Markdown blocks. Runs of inline content
+// between block elements become paragraphs.
+func markdownBlocks(n *html.Node, base *url.URL) []string {
+	var blocks []string
+	var inline strings.Builder
+	flush := func() {
+		if text := strings.TrimSpace(inline.String()); text != "" {
+			blocks = append(blocks, escapeLineStart(text))
+		}
+		inline.Reset()
+	}
+
+	for child := n.FirstChild; child != nil; child = child.NextSibling {
+		if child.Type != html.ElementNode || !isMarkdownBlock(child) {
+			inline.WriteString(markdownInline(child, base))
+			continue
+		}
+		flush()
+		if block := markdownBlock(child, base); block != "" {
+			blocks = append(blocks, block)
+		}
+	}
+	flush()
+	return blocks
+}
+
+func isMarkdownBlock(n *html.Node) bool {
+	return blockElements[n.DataAtom] && n.DataAtom != atom.Br || skippedElements[n.DataAtom] ||
+		n.DataAtom == atom.Head || n.DataAtom == atom.Body || n.DataAtom == atom.Html
+}
+
+// markdownBlock renders one block element
+func markdownBlock(n *html.Node, base *url.URL) string {
+	if skippedElements[n.DataAtom] || n.DataAtom == atom.Head || isBoilerplate(n) {
+		return ""
+	}
+
+	switch n.DataAtom {
+	case atom.H1, atom.H2, atom.H3, atom.H4, atom.H5, atom.H6:
+		level, _ := strconv.Atoi(n.Data[1:])
+		if text := collapseSpace(markdownInline(n, base)); text != "" {
+			return strings.Repeat("#", level) + " " + text
+		}
+		return ""
+	case atom.Pre:
+		code := strings.Trim(nodeText(n), "\n")
+		if code == "" {
+			return ""
+		}
+		return "```" + codeLanguage(n) + "\n" + code + "\n```"
+	case atom.Blockquote:
+		inner := renderMarkdown(n, base)
+		if inner == "" {
+			return ""
+		}
+		return prefixLines(inner, "> ", "> ")
+	case atom.Ul, atom.Ol:
+		return markdownList(n, base)
+	case atom.Hr:
+		return "---"
+	case atom.Table:
+		return markdownTable(n, base)
+	}
+	return renderMarkdown(n, base)
+}
+
+// markdownList renders list items, indenting their continuation lines under the marker
+func markdownList(n *html.Node, base *url.URL) string {
+	var items []string
+	number := 1
+	if start, err := strconv.Atoi(attr(n, "start")); err == nil {
+		number = start
+	}
+	for li := n.FirstChild; li != nil; li = li.NextSibling {
+		if li.Type != html.ElementNode || li.DataAtom != atom.Li {
+			continue
+		}
+		marker := "- "
+		if n.DataAtom == atom.Ol {
+			marker = strconv.Itoa(number) + ". "
+			number++
+		}
+		body := strings.Join(markdownBlocks(li, base), "\n")
+		if body == "" {
+			continue
+		}
+		items = append(items, prefixLines(body, marker, strings.Repeat(" ", len(marker))))
+	}
+	return strings.Join(items, "\n")
+}
+
+// markdownTable renders a table as a pipe table, treating the first row as the header
+func markdownTable(n *html.Node, base *url.URL) string {
+	var rows [][]string
+	var walk func(n *html.Node)
+	walk = func(n *html.Node) {
+		if n.Type == html.ElementNode && n.DataAtom == atom.Tr {
+			var cells []string
+			for cell := n.FirstChild; cell != nil; cell = cell.NextSibling {
+				if cell.Type == html.ElementNode && (cell.DataAtom == atom.Td || cell.DataAtom == atom.Th) {
+					text := collapseSpace(strings.Join(markdownBlocks(cell, base), " "))
+					cells = append(cells, strings.ReplaceAll(text, "|", `\|`))
+				}
+			}
+			if len(cells) > 0 {
+				rows = append(rows, cells)
+			}
+			return
+		}
+		for child := n.FirstChild; child != nil; child = child.NextSibling {
+			walk(child)
+		}
+	}
+	walk(n)
+	if len(rows) == 0 {
+		return ""
+	}
+
+	columns := 0
+	for _, row := range rows {
+		if len(row) > columns {
+			columns = len(row)
+		}
+	}
+	var lines []string
+	for i, row := range rows {
+		for len(row) < columns {
+			row = append(row, "")
+		}
+		lines = append(lines, "| "+strings.Join(row, " | ")+" |")
+		if i == 0 {
+			lines = append(lines, "|"+strings.Repeat(" --- |", columns))
+		}
+	}
+	return strings.Join(lines, "\n")
+}
+
+// markdownInline renders inline content: text, emphasis, code, links and images
+func markdownInline(n *html.Node, base *url.URL) string {
+	switch n.Type {
+	case html.TextNode:
+		return markdownEscaper.Replace(whitespaceRe.ReplaceAllString(n.Data, " "))
+	case html.ElementNode:
+	default:
+		return ""
+	}
+	if skippedElements[n.DataAtom] {
+		return ""
+	}
+
+	children := func() string {
+		var b strings.Builder
+		for child := n.FirstChild; child != nil; child = child.NextSibling {
+			b.WriteString(markdownInline(child, base))
+		}
+		return b.String()
+	}
+
+	switch n.DataAtom {
+	case atom.Br:
+		return "  \n"
+	case atom.Strong, atom.B:
+		return wrapInline(children(), "**")
+	case atom.Em, atom.I:
+		return wrapInline(children(), "_")
+	case atom.Code, atom.Kbd, atom.Samp:
+		code := collapseSpace(nodeText(n))
+		if code == "" {
+			return ""
+		}
+		fence := "`"
+		if strings.Contains(code, "`") {
+			fence = "``"
+		}
+		return fence + code + fence
+	case atom.A:
+		text := children()
+		href := safeURL(base, attr(n, "href"), "http", "https", "mailto")
+		if href == "" || strings.TrimSpace(text) == "" {
+			return text
+		}
+		return "[" + strings.TrimSpace(text) + "](" + href + ")"
+	case atom.Img:
+		src := safeURL(base, firstNonEmpty(attr(n, "src"), attr(n, "data-src")), "http", "https")
+		if src == "" {
+			return ""
+		}
+		return "![" + markdownEscaper.Replace(collapseSpace(attr(n, "alt"))) + "](" + src + ")"
+	}
+	if isMarkdownBlock(n) {
+		// Block elements nested in inline ones (a div inside a link) render inline
+		return " " + collapseSpace(strings.Join(markdownBlocks(n, base), " ")) + " "
+	}
+	return children()
+}
+
+// wrapInline wraps text in a Markdown delimiter, keeping surrounding spaces outside it
+func wrapInline(text, delimiter string) string {
+	trimmed := strings.TrimSpace(text)
+	if trimmed == "" {
+		return text
+	}
+	lead := text[:len(text)-len(strings.TrimLeft(text, " "))]
+	trail := text[len(strings.TrimRight(text, " ")):]
+	return lead + delimiter + trimmed + delimiter + trail
+}
+
+// safeURL resolves ref against base and returns it if its scheme is allowed
+func safeURL(base *url.URL, ref string, schemes ...string) string {
+	resolved := resolveURL(base, ref)
+	if resolved == "" {
+		return ""
+	}
+	parsed, err := url.Parse(resolved)
+	if err != nil || !containsString(schemes, strings.ToLower(parsed.Scheme)) {
+		return ""
+	}
+	return strings.NewReplacer("(", "%28", ")", "%29", " ", "%20").Replace(resolved)
+}
+
+// codeLanguage reads the language of a code block from a language-x or lang-x class
+func codeLanguage(pre *html.Node) string {
+	classes := attr(pre, "class")
+	if code := findFirst(pre, atom.Code); code != nil {
+		classes += " " + attr(code, "class")
+	}
+	for _, class := range strings.Fields(classes) {
+		for _, prefix := range []string{"language-", "lang-"} {
+			if strings.HasPrefix(class, prefix) {
+				return strings.TrimPrefix(class, prefix)
+			}
+		}
+	}
+	return ""
+}
+
+// escapeLineStart escapes text that would start a heading, quote or list item
+func escapeLineStart(text string) string {
+	if lineStartMarkRe.MatchString(text) {
+		return `\` + text
+	}
+	return text
+}
+
+// prefixLines prefixes the first line of text with first and the others with rest
+func prefixLines(text, first, rest string) string {
+	lines := strings.Split(text, "\n")
+	for i, line := range lines {
+		switch {
+		case i == 0:
+			lines[i] = first + line
+		case line == "":
+			lines[i] = strings.TrimRight(rest, " ")
+		default:
+			lines[i] = rest + line
+		}
+	}
+	return strings.Join(lines, "\n")
+}
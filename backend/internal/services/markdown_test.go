@@ -0,0 +1,94 @@
+package services
+
+import (
+	"strings"
+	"testing"
+
+	"synapse/internal/models"
+)
+
+func TestSanitizeMarkdown(t *testing.T) {
+	tests := []struct {
+		name string
+		in   string
+		want string
+	}{
+		{"plain", "# Title\n\nSome *text*.", "# Title\n\nSome *text*."},
+		{"raw html", "Hi <script>alert(1)</script> there <!-- note -->", "Hi alert(1) there"},
+		{"javascript link", "[click](javascript:alert(1))", "[click](#)"},
+		{"data link", "![x](DATA:text/html;base64,AAAA)", "![x](#)"},
+		{"safe link", "[site](https://example.com/a_(b))", "[site](https://example.com/a_(b))"},
+		{"fenced code kept", "```\n<b>bold</b>\n```\n<b>x</b>", "```\n<b>bold</b>\n```\nx"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := SanitizeMarkdown(tt.in); got != tt.want {
+				t.Fatalf("SanitizeMarkdown(%q) = %q, want %q", tt.in, got, tt.want)
+			}
+		})
+	}
+}
+
+func TestPlainText(t *testing.T) {
+	tests := []struct {
+		name string
+		in   string
+		want string
+	}{
+		{"heading and emphasis", "## Hello **big** _world_", "Hello big world"},
+		{"links and images", "See [the docs](https://x.dev) and ![a chart](c.png)", "See the docs and a chart"},
+		{"lists and quotes", "- one\n1. two\n> three", "one\ntwo\nthree"},
+		{"table", "| a | b |\n|---|---|\n| 1 | x\\|y |", "a b\n1 x|y"},
+		{"code", "Run `go test`\n```\n**not bold**\n```", "Run go test\n**not bold**"},
+		{"escapes", `2 \* 3 \_ 4`, "2 * 3 _ 4"},
+		{"snake_case kept", "use snake_case_names", "use snake_case_names"},
+		{"rule and blank lines", "a\n\n---\n\n\n\nb", "a\n\nb"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := PlainText(models.FormatMarkdown, tt.in); got != tt.want {
+				t.Fatalf("PlainText(%q) = %q, want %q", tt.in, got, tt.want)
+			}
+		})
+	}
+
+	if got := PlainText("text", "**kept**"); got != "**kept**" {
+		t.Fatalf("PlainText of plain text = %q, want it unchanged", got)
+	}
+}
+
+func TestHTMLToMarkdown(t *testing.T) {
+	tests := []struct {
+		name    string
+		in      string
+		want    []string // Substrings of the output
+		notWant []string
+	}{
+		{"heading and paragraph", "<h2>Title</h2><p>Hello <strong>there</strong></p>", []string{"## Title", "Hello **there**"}, nil},
+		{"relative link", `<p><a href="/docs">docs</a></p>`, []string{"[docs](https://example.com/docs)"}, nil},
+		{"script link dropped", `<p><a href="javascript:alert(1)">x</a></p>`, nil, []string{"javascript"}},
+		{"mailto kept", `<p><a href="mailto:a@b.c">mail</a></p>`, []string{"mailto:a@b.c"}, nil},
+		{"data image dropped", `<img src="data:image/png;base64,AAAA" alt="pixel">`, nil, []string{"data:"}},
+		{"script removed", "<p>ok</p><script>alert(1)</script>", []string{"ok"}, []string{"alert"}},
+		{"text escaped", "<p>*not* [markup]</p>", []string{`\*not\* \[markup\]`}, nil},
+		{"list", "<ul><li>one</li><li>two</li></ul>", []string{"- one", "- two"}, nil},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := HTMLToMarkdown(tt.in, "https://example.com/post")
+			if err != nil {
+				t.Fatal(err)
+			}
+			for _, want := range tt.want {
+				if !strings.Contains(got, want) {
+					t.Errorf("HTMLToMarkdown(%q) = %q, want it to contain %q", tt.in, got, want)
+				}
+			}
+			for _, notWant := range tt.notWant {
+				if strings.Contains(got, notWant) {
+					t.Errorf("HTMLToMarkdown(%q) = %q, want no %q", tt.in, got, notWant)
+				}
+			}
+		})
+	}
+}
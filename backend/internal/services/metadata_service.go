@@ -4,19 +4,33 @@ import (
 	"context"
 	"encoding/json"
 	"fmt"
+	"html"
 	"io"
+	"mime"
 	"net/http"
+	neturl "net/url"
 	"regexp"
 	"strings"
+	"synapse/internal/models"
+	"time"
+
+	"golang.org/x/net/html/charset"
 )
 
+// maxPageSize bounds how much of a fetched page is read
+const maxPageSize = 5 << 20
+
 type MetadataService struct {
-	client *http.Client
+	client      *http.Client
+	maxPDFSize  int64
+	imageSearch ImageSearchProvider // nil unless a stock image API is configured
 }
 
 func NewMetadataService() *MetadataService {
 	return &MetadataService{
-		client: &http.Client{},
+		client:      &http.Client{},
+		maxPDFSize:  int64(envInt("PDF_MAX_MB", 25)) << 20,
+		imageSearch: NewImageSearchProviderFromEnv(),
 	}
 }
 
@@ -36,7 +50,7 @@ func (s *MetadataService) GetURLMetadata(ctx context.Context, url string) (embed
 	// For PDF URLs, generate PDF embed
 	if strings.HasSuffix(strings.ToLower(url), ".pdf") || strings.Contains(strings.ToLower(url), ".pdf?") {
 		// Generate responsive PDF embed using iframe
-		embedHTML = fmt.Sprintf(`<iframe width="100%%" height="100%%" src="%s" frameborder="0" style="position: absolute; top: 0; left: 0; width: 100%%; height: 100%%;" type="application/pdf"></iframe>`, url)
+		embedHTML = PDFEmbed(url)
 		// PDFs don't have preview images, but we can use a generic PDF icon if needed
 		return embedHTML, "", nil
 	}
@@ -45,11 +59,106 @@ func (s *MetadataService) GetURLMetadata(ctx context.Context, url string) (embed
 	imageURL, _ = s.getOpenGraphImage(ctx, url)
 	
 	// Generate simple embed for other URLs
-	if imageURL != "" {
-		embedHTML = fmt.Sprintf(`<div class="url-preview"><img src="%s" alt="Preview" style="max-width: 100%%; border-radius: 8px;" /></div>`, imageURL)
+	return PreviewEmbed(imageURL), imageURL, nil
+}
+
+// PreviewEmbed returns the embed HTML showing a page's preview image, or "" without an
+// http(s) image
+func PreviewEmbed(imageURL string) string {
+	src := embedSrc(imageURL)
+	if src == "" {
+		return ""
+	}
+	return fmt.Sprintf(`<div class="url-preview"><img src="%s" alt="Preview" style="max-width: 100%%; border-radius: 8px;" /></div>`, src)
+}
+
+// PDFEmbed returns the embed HTML showing a PDF, or "" if pdfURL isn't http(s)
+func PDFEmbed(pdfURL string) string {
+	src := embedSrc(pdfURL)
+	if src == "" {
+		return ""
+	}
+	return fmt.Sprintf(`<iframe width="100%%" height="100%%" src="%s" frameborder="0" style="position: absolute; top: 0; left: 0; width: 100%%; height: 100%%;" type="application/pdf"></iframe>`, src)
+}
+
+// embedSrc returns an http(s) URL escaped for an HTML attribute, or "" for any other URL.
+// Embeds are rendered as raw HTML, so URLs taken from fetched pages must not be able to
+// close the attribute or run script.
+func embedSrc(rawURL string) string {
+	u, err := neturl.Parse(strings.TrimSpace(rawURL))
+	if err != nil || (u.Scheme != "http" && u.Scheme != "https") || u.Host == "" {
+		return ""
+	}
+	return html.EscapeString(u.String())
+}
+
+// FetchArticle downloads an HTML page and extracts its readable text and metadata
+func (s *MetadataService) FetchArticle(ctx context.Context, pageURL string) (*models.Article, error) {
+	ctx, cancel := context.WithTimeout(ctx, 15*time.Second)
+	defer cancel()
+
+	req, err := http.NewRequestWithContext(ctx, "GET", pageURL, nil)
+	if err != nil {
+		return nil, err
+	}
+	req.Header.Set("User-Agent", "Mozilla/5.0 (compatible; SynapseBot/1.0)")
+	req.Header.Set("Accept", "text/html,application/xhtml+xml")
+
+	resp, err := s.client.Do(req)
+	if err != nil {
+		return nil, err
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode != http.StatusOK {
+		return nil, fmt.Errorf("fetching %s: status %d", pageURL, resp.StatusCode)
+	}
+	contentType := resp.Header.Get("Content-Type")
+	if mediaType, _, _ := mime.ParseMediaType(contentType); mediaType != "" && mediaType != "text/html" && mediaType != "application/xhtml+xml" {
+		return nil, fmt.Errorf("fetching %s: not an HTML page (%s)", pageURL, mediaType)
+	}
+
+	// Pages in other encodings are converted to UTF-8
+	body, err := charset.NewReader(io.LimitReader(resp.Body, maxPageSize), contentType)
+	if err != nil {
+		return nil, err
+	}
+	return ExtractArticle(resp.Request.URL.String(), body)
+}
+
+// FetchPDF downloads a PDF, up to PDF_MAX_MB, and extracts its text and metadata
+func (s *MetadataService) FetchPDF(ctx context.Context, pdfURL string) (*models.PDFDocument, error) {
+	ctx, cancel := context.WithTimeout(ctx, 60*time.Second)
+	defer cancel()
+
+	req, err := http.NewRequestWithContext(ctx, "GET", pdfURL, nil)
+	if err != nil {
+		return nil, err
+	}
+	req.Header.Set("User-Agent", "Mozilla/5.0 (compatible; SynapseBot/1.0)")
+	req.Header.Set("Accept", "application/pdf")
+
+	resp, err := s.client.Do(req)
+	if err != nil {
+		return nil, err
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode != http.StatusOK {
+		return nil, fmt.Errorf("fetching %s: status %d", pdfURL, resp.StatusCode)
+	}
+	if resp.ContentLength > s.maxPDFSize {
+		return nil, fmt.Errorf("fetching %s: PDF larger than %d bytes", pdfURL, s.maxPDFSize)
 	}
 
-	return embedHTML, imageURL, nil
+	data, err := io.ReadAll(io.LimitReader(resp.Body, s.maxPDFSize+1))
+	if err != nil {
+		return nil, err
+	}
+	if int64(len(data)) > s.maxPDFSize {
+		return nil, fmt.Errorf("fetching %s: PDF larger than %d bytes", pdfURL, s.maxPDFSize)
+	}
+	return ExtractPDF(data)
 }
 
 // DetectBookAndGetCover detects if content is about a book and fetches cover
@@ -122,39 +231,14 @@ func (s *MetadataService) extractYouTubeID(url string) string {
 	return ""
 }
 
+// getOpenGraphImage returns a page's lead image: og:image, twitter:image or the first
+// image of the article
 func (s *MetadataService) getOpenGraphImage(ctx context.Context, url string) (string, error) {
-	req, err := http.NewRequestWithContext(ctx, "GET", url, nil)
-	if err != nil {
-		return "", err
-	}
-	req.Header.Set("User-Agent", "Mozilla/5.0 (compatible; SynapseBot/1.0)")
-	
-	resp, err := s.client.Do(req)
-	if err != nil {
-		return "", err
-	}
-	defer resp.Body.Close()
-	
-	body, err := io.ReadAll(resp.Body)
+	article, err := s.FetchArticle(ctx, url)
 	if err != nil {
 		return "", err
 	}
-	
-	// Extract og:image
-	re := regexp.MustCompile(`<meta\s+property=["']og:image["']\s+content=["']([^"']+)["']`)
-	matches := re.FindStringSubmatch(string(body))
-	if len(matches) > 1 {
-		return matches[1], nil
-	}
-	
-	// Try twitter:image
-	re = regexp.MustCompile(`<meta\s+name=["']twitter:image["']\s+content=["']([^"']+)["']`)
-	matches = re.FindStringSubmatch(string(body))
-	if len(matches) > 1 {
-		return matches[1], nil
-	}
-	
-	return "", nil
+	return article.LeadImage, nil
 }
 
 func (s *MetadataService) extractISBN(content string) string {
@@ -223,21 +307,20 @@ func (s *MetadataService) getBookCoverByTitle(ctx context.Context, title string)
 func (s *MetadataService) getRecipeImage(ctx context.Context, title string) (string, error) {
 	// Extract keywords from recipe title
 	keywords := s.extractKeywordsFromTitle(title)
-	searchQuery := "recipe"
+	searchQuery := "food"
 	if keywords != "" {
 		keywordParts := strings.Fields(keywords)
 		if len(keywordParts) > 2 {
 			keywordParts = keywordParts[:2]
 		}
-		searchQuery = "recipe," + strings.Join(keywordParts, ",")
+		searchQuery = "food " + strings.Join(keywordParts, " ")
 	}
-	// Use Unsplash Source API with recipe-specific search
-	url := fmt.Sprintf("https://source.unsplash.com/400x300/?%s", strings.ReplaceAll(searchQuery, " ", "+"))
-	return url, nil
+	return s.searchImage(ctx, searchQuery)
 }
 
-// FetchRelevantImage attempts to fetch a relevant image for any content type
-func (s *MetadataService) FetchRelevantImage(ctx context.Context, title, content, itemType, category string) (string, error) {
+// FetchRelevantImage attempts to fetch a relevant image for any content type.
+// topic is the image keyword of the item's category ("" for a generic image).
+func (s *MetadataService) FetchRelevantImage(ctx context.Context, title, content, itemType, topic string) (string, error) {
 	// Try different strategies based on type and category
 	switch itemType {
 	case "video":
@@ -270,55 +353,39 @@ func (s *MetadataService) FetchRelevantImage(ctx context.Context, title, content
 	}
 	
 	// Category-based image search (works for all types)
-	if category != "" {
-		return s.getImageByCategory(ctx, title, category)
-	}
-	
-	// Final fallback: generic image
-	return s.getImageByCategory(ctx, title, "Other")
+	return s.getImageByCategory(ctx, title, topic)
 }
 
-func (s *MetadataService) getImageByCategory(ctx context.Context, title, category string) (string, error) {
+func (s *MetadataService) getImageByCategory(ctx context.Context, title, topic string) (string, error) {
 	// Extract keywords from title for more relevant images
 	keywords := s.extractKeywordsFromTitle(title)
 	
-	// Map categories to search terms
-	categoryMap := map[string]string{
-		"Technology":        "technology",
-		"Food & Recipes":    "food",
-		"Books & Reading":   "books",
-		"Videos & Entertainment": "entertainment",
-		"Shopping & Products": "product",
-		"Articles & News":   "news",
-		"Notes & Ideas":     "notebook",
-		"Design & Inspiration": "design",
-		"Travel":            "travel",
-		"Health & Fitness":  "fitness",
-		"Education & Learning": "education",
-	}
-	
-	// Use category as base search term
-	searchTerm := categoryMap[category]
+	// Use the category's image keyword as base search term
+	searchTerm := topic
 	if searchTerm == "" {
 		searchTerm = "abstract"
 	}
 	
 	// Combine category with title keywords for better relevance
-	// Use Unsplash Source API with search terms (more relevant than Picsum)
-	// Format: https://source.unsplash.com/400x300/?keyword1,keyword2
 	searchQuery := searchTerm
 	if keywords != "" {
-		// Limit keywords to avoid too long URLs
+		// A few keywords match better than a long query
 		keywordParts := strings.Fields(keywords)
 		if len(keywordParts) > 3 {
 			keywordParts = keywordParts[:3]
 		}
-		searchQuery = searchTerm + "," + strings.Join(keywordParts, ",")
+		searchQuery = searchTerm + " " + strings.Join(keywordParts, " ")
 	}
-	
-	// Use Unsplash Source API with search terms
-	url := fmt.Sprintf("https://source.unsplash.com/400x300/?%s", strings.ReplaceAll(searchQuery, " ", "+"))
-	return url, nil
+	return s.searchImage(ctx, searchQuery)
+}
+
+// searchImage looks a photo up with the configured image search provider. Without a
+// provider it finds nothing, and the item gets a generated cover.
+func (s *MetadataService) searchImage(ctx context.Context, query string) (string, error) {
+	if s.imageSearch == nil {
+		return "", nil
+	}
+	return s.imageSearch.SearchImage(ctx, query)
 }
 
 // extractKeywordsFromTitle extracts meaningful keywords from title
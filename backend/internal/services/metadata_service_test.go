@@ -0,0 +1,62 @@
+package services
+
+import (
+	"strings"
+	"testing"
+)
+
+func TestPreviewEmbed(t *testing.T) {
+	tests := []struct {
+		name     string
+		imageURL string
+		want     string // Expected src attribute; "" for no embed
+	}{
+		{"plain", "https://example.com/a.jpg", "https://example.com/a.jpg"},
+		{"query", "https://example.com/a.jpg?w=1&h=2", "https://example.com/a.jpg?w=1&amp;h=2"},
+		{"quote breaks out", `https://example.com/a.jpg?" onerror="alert(1)`, "https://example.com/a.jpg?&#34; onerror=&#34;alert(1)"},
+		{"angle brackets", "https://example.com/<script>", "https://example.com/%3Cscript%3E"},
+		{"javascript", "javascript:alert(1)", ""},
+		{"data", "data:image/png;base64,AAAA", ""},
+		{"relative", "/a.jpg", ""},
+		{"empty", "", ""},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got := PreviewEmbed(tt.imageURL)
+			if tt.want == "" {
+				if got != "" {
+					t.Fatalf("PreviewEmbed(%q) = %q, want no embed", tt.imageURL, got)
+				}
+				return
+			}
+			if !strings.Contains(got, `src="`+tt.want+`"`) {
+				t.Fatalf("PreviewEmbed(%q) = %q, want src %q", tt.imageURL, got, tt.want)
+			}
+			if strings.Count(got, `"`) != strings.Count(PreviewEmbed("https://example.com/"), `"`) {
+				t.Fatalf("PreviewEmbed(%q) = %q adds attributes", tt.imageURL, got)
+			}
+		})
+	}
+}
+
+func TestPDFEmbed(t *testing.T) {
+	if got := PDFEmbed(`https://example.com/a.pdf?"><script>alert(1)</script>`); strings.Contains(got, "<script>") {
+		t.Fatalf("PDFEmbed kept markup: %q", got)
+	}
+	if got := PDFEmbed("javascript:alert(1)//.pdf"); got != "" {
+		t.Fatalf("PDFEmbed(javascript:) = %q, want no embed", got)
+	}
+}
+
+func TestExtractArticleLeadImage(t *testing.T) {
+	page := `<html><head><meta property="og:image" content="/a.jpg?&quot; onerror=&quot;alert(1)"></head>
+		<body><article><p>Some text long enough to be the article body.</p></article></body></html>`
+	article, err := ExtractArticle("https://example.com/post", strings.NewReader(page))
+	if err != nil {
+		t.Fatal(err)
+	}
+	embed := PreviewEmbed(article.LeadImage)
+	if strings.Contains(embed, `" onerror`) {
+		t.Fatalf("lead image %q gives embed %q with an injected attribute", article.LeadImage, embed)
+	}
+}
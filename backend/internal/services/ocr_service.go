@@ -3,127 +3,325 @@ package services
 import (
 	"bytes"
 	"context"
-	"encoding/base64"
-	"encoding/json"
+	"errors"
 	"fmt"
-	"io"
 	"net/http"
 	"os"
+	"os/exec"
+	"strconv"
 	"strings"
+	"synapse/internal/models"
+	"synapse/internal/repository"
+	"time"
+	"unicode/utf8"
+
+	"github.com/google/uuid"
+	"github.com/jackc/pgx/v5"
 )
 
+var ErrOCRUnavailable = errors.New("no OCR engine available")
+
+// OCREngine recognizes the text in an image
+type OCREngine interface {
+	Name() string
+	// Recognize returns the image's text blocks in reading order. languages are hints
+	// of the languages the text is in, as ISO 639-1 codes ("en") or Tesseract codes ("eng").
+	Recognize(ctx context.Context, image []byte, mimeType string, languages []string) (*models.OCRResult, error)
+}
+
+// OCRService recognizes text in images with the engines selected by OCR_ENGINE:
+// "tesseract", "vision" (the configured AI provider's vision model) or "auto", the
+// default, which uses Tesseract when it is installed and falls back to vision.
 type OCRService struct {
-	client    *http.Client
-	geminiKey string
+	engines   []OCREngine
+	ocrRepo   *repository.OCRRepository
+	languages []string // Default language hints, from OCR_LANGUAGES
+	timeout   time.Duration
 }
 
-func NewOCRService() *OCRService {
+func NewOCRService(aiService *AIService, ocrRepo *repository.OCRRepository) *OCRService {
+	var engines []OCREngine
+	tesseract := newTesseractEngine()
+	vision := &visionOCREngine{aiService: aiService}
+	switch mode := os.Getenv("OCR_ENGINE"); mode {
+	case models.OCREngineTesseract:
+		if tesseract == nil {
+			fmt.Println("Warning: OCR_ENGINE is tesseract but the tesseract command was not found; OCR is disabled")
+		} else {
+			engines = append(engines, tesseract)
+		}
+	case models.OCREngineVision:
+		engines = append(engines, vision)
+	default:
+		if mode != "" && mode != "auto" {
+			fmt.Printf("Warning: Unknown OCR_ENGINE %q; using auto\n", mode)
+		}
+		if tesseract != nil {
+			engines = append(engines, tesseract)
+		}
+		engines = append(engines, vision)
+	}
+
+	var languages []string
+	for _, lang := range strings.Split(os.Getenv("OCR_LANGUAGES"), ",") {
+		if lang = strings.TrimSpace(lang); lang != "" {
+			languages = append(languages, lang)
+		}
+	}
+
 	return &OCRService{
-		client:    &http.Client{},
-		geminiKey: os.Getenv("GEMINI_API_KEY"),
+		engines:   engines,
+		ocrRepo:   ocrRepo,
+		languages: languages,
+		timeout:   envDuration("OCR_TIMEOUT", 2*time.Minute),
 	}
 }
 
-// ExtractTextFromImage uses Gemini Vision API to extract text from images
-func (s *OCRService) ExtractTextFromImage(ctx context.Context, imageURL string) (string, error) {
-	if s.geminiKey == "" {
-		return "", fmt.Errorf("GEMINI_API_KEY not set")
+// ExtractTextFromImageData recognizes the text in image data (uploads, screenshots).
+// The MIME type is sniffed when empty, and without language hints OCR_LANGUAGES is
+// used. Engines are tried in order until one succeeds; an image without text gives a
+// result without blocks.
+func (s *OCRService) ExtractTextFromImageData(ctx context.Context, imageData []byte, mimeType string, languages []string) (*models.OCRResult, error) {
+	if mimeType == "" {
+		mimeType, _, _ = strings.Cut(http.DetectContentType(imageData), ";")
 	}
+	if len(languages) == 0 {
+		languages = s.languages
+	}
+	ctx, cancel := context.WithTimeout(ctx, s.timeout)
+	defer cancel()
 
-	// Download image
-	resp, err := http.Get(imageURL)
-	if err != nil {
-		return "", fmt.Errorf("failed to download image: %w", err)
+	lastErr := ErrOCRUnavailable
+	for _, engine := range s.engines {
+		result, err := engine.Recognize(ctx, imageData, mimeType, languages)
+		if err == nil {
+			result.Confidence = ocrConfidence(result.Blocks)
+			return result, nil
+		}
+		if !errors.Is(err, ErrOCRUnavailable) {
+			fmt.Printf("Warning: %s OCR failed: %v\n", engine.Name(), err)
+		}
+		lastErr = err
+		if ctx.Err() != nil {
+			break
+		}
 	}
-	defer resp.Body.Close()
+	return nil, lastErr
+}
 
-	if resp.StatusCode != http.StatusOK {
-		return "", fmt.Errorf("failed to download image: status %d", resp.StatusCode)
+// Save stores an item's OCR result and sets its ocr_text
+func (s *OCRService) Save(ctx context.Context, itemID uuid.UUID, result *models.OCRResult) error {
+	return s.ocrRepo.Save(ctx, itemID, result, OCRText(result))
+}
+
+// Delete removes an item's OCR result and its ocr_text
+func (s *OCRService) Delete(ctx context.Context, itemID uuid.UUID) error {
+	return s.ocrRepo.Delete(ctx, itemID)
+}
+
+// Get returns an item's OCR result, or nil if its image wasn't OCR'd
+func (s *OCRService) Get(ctx context.Context, itemID uuid.UUID) (*models.OCRResult, error) {
+	result, err := s.ocrRepo.Get(ctx, itemID)
+	if errors.Is(err, pgx.ErrNoRows) {
+		return nil, nil
 	}
+	return result, err
+}
 
-	// Read image data
-	imageData, err := io.ReadAll(resp.Body)
-	if err != nil {
-		return "", fmt.Errorf("failed to read image data: %w", err)
+// OCRText joins the blocks of an OCR result into plain text
+func OCRText(result *models.OCRResult) string {
+	if result == nil {
+		return ""
+	}
+	texts := make([]string, 0, len(result.Blocks))
+	for _, block := range result.Blocks {
+		texts = append(texts, block.Text)
+	}
+	return strings.Join(texts, "\n\n")
+}
+
+// ocrConfidence is the mean confidence of blocks weighted by their length, so a
+// misread stray character doesn't drag down a page of clean text
+func ocrConfidence(blocks []models.OCRBlock) float64 {
+	total, weight := 0.0, 0
+	for _, block := range blocks {
+		n := utf8.RuneCountInString(block.Text)
+		total += block.Confidence * float64(n)
+		weight += n
+	}
+	if weight == 0 {
+		return 0
 	}
+	return total / float64(weight)
+}
 
-	// Encode to base64
-	base64Image := base64.StdEncoding.EncodeToString(imageData)
+// visionOCREngine reads text with the vision model of the configured AI provider
+type visionOCREngine struct {
+	aiService *AIService
+}
 
-	// Use Gemini Vision API to extract text
-	return s.callGeminiVision(ctx, base64Image, resp.Header.Get("Content-Type"))
+func (e *visionOCREngine) Name() string {
+	return models.OCREngineVision
 }
 
-// ExtractTextFromImageData extracts text from image data (for direct uploads)
-func (s *OCRService) ExtractTextFromImageData(ctx context.Context, imageData []byte, mimeType string) (string, error) {
-	if s.geminiKey == "" {
-		return "", fmt.Errorf("GEMINI_API_KEY not set")
+func (e *visionOCREngine) Recognize(ctx context.Context, image []byte, mimeType string, languages []string) (*models.OCRResult, error) {
+	if !e.aiService.visionAvailable() {
+		return nil, fmt.Errorf("%w: AI provider %s is not configured", ErrOCRUnavailable, e.aiService.primaryProvider())
+	}
+	blocks, err := e.aiService.ReadImageText(ctx, image, mimeType, languages)
+	if err != nil {
+		return nil, err
 	}
+	return &models.OCRResult{Engine: models.OCREngineVision, Languages: languages, Blocks: blocks}, nil
+}
 
-	base64Image := base64.StdEncoding.EncodeToString(imageData)
-	return s.callGeminiVision(ctx, base64Image, mimeType)
+// tesseractLanguageCodes maps ISO 639-1 codes to Tesseract's traineddata names
+var tesseractLanguageCodes = map[string]string{
+	"ar": "ara", "cs": "ces", "da": "dan", "de": "deu", "el": "ell", "en": "eng",
+	"es": "spa", "fi": "fin", "fr": "fra", "he": "heb", "hi": "hin", "hu": "hun",
+	"id": "ind", "it": "ita", "ja": "jpn", "ko": "kor", "nl": "nld", "no": "nor",
+	"pl": "pol", "pt": "por", "ro": "ron", "ru": "rus", "sv": "swe", "th": "tha",
+	"tr": "tur", "uk": "ukr", "vi": "vie", "zh": "chi_sim",
 }
 
-func (s *OCRService) callGeminiVision(ctx context.Context, base64Image, mimeType string) (string, error) {
-	// Default to jpeg if mime type not provided
-	if mimeType == "" {
-		mimeType = "image/jpeg"
-	}
-
-	url := fmt.Sprintf("https://generativelanguage.googleapis.com/v1beta/models/gemini-pro-vision:generateContent?key=%s", s.geminiKey)
-
-	payload := map[string]interface{}{
-		"contents": []map[string]interface{}{
-			{
-				"parts": []map[string]interface{}{
-					{
-						"text": "Extract all text from this image. Return only the text content, no explanations or formatting.",
-					},
-					{
-						"inline_data": map[string]interface{}{
-							"mime_type": mimeType,
-							"data":      base64Image,
-						},
-					},
-				},
-			},
-		},
-	}
-
-	jsonData, _ := json.Marshal(payload)
-	req, _ := http.NewRequestWithContext(ctx, "POST", url, bytes.NewBuffer(jsonData))
-	req.Header.Set("Content-Type", "application/json")
-
-	resp, err := s.client.Do(req)
+// tesseractEngine runs the tesseract command (TESSERACT_PATH, or tesseract on PATH)
+type tesseractEngine struct {
+	path      string
+	installed map[string]bool // Installed languages; nil if they couldn't be listed
+}
+
+// newTesseractEngine returns nil when tesseract isn't installed
+func newTesseractEngine() *tesseractEngine {
+	path := os.Getenv("TESSERACT_PATH")
+	if path == "" {
+		path = "tesseract"
+	}
+	path, err := exec.LookPath(path)
 	if err != nil {
-		return "", fmt.Errorf("failed to call Gemini Vision API: %w", err)
+		return nil
 	}
-	defer resp.Body.Close()
 
-	if resp.StatusCode != http.StatusOK {
-		body, _ := io.ReadAll(resp.Body)
-		return "", fmt.Errorf("Gemini Vision API error: %s", string(body))
+	engine := &tesseractEngine{path: path}
+	out, err := exec.Command(path, "--list-langs").Output()
+	if err != nil {
+		fmt.Printf("Warning: Failed to list tesseract languages: %v\n", err)
+		return engine
 	}
-
-	var result struct {
-		Candidates []struct {
-			Content struct {
-				Parts []struct {
-					Text string `json:"text"`
-				} `json:"parts"`
-			} `json:"content"`
-		} `json:"candidates"`
+	engine.installed = map[string]bool{}
+	// The first line is a header: List of available languages in "/usr/share/tessdata/" (3):
+	for _, line := range strings.Split(string(out), "\n")[1:] {
+		if lang := strings.TrimSpace(line); lang != "" {
+			engine.installed[lang] = true
+		}
 	}
+	return engine
+}
 
-	if err := json.NewDecoder(resp.Body).Decode(&result); err != nil {
-		return "", fmt.Errorf("failed to decode response: %w", err)
+func (e *tesseractEngine) Name() string {
+	return models.OCREngineTesseract
+}
+
+// languages converts language hints to installed Tesseract languages. Hints without
+// installed data are dropped; tesseract then uses its default (eng).
+func (e *tesseractEngine) languages(hints []string) []string {
+	var langs []string
+	for _, hint := range hints {
+		lang := strings.ToLower(hint)
+		if code, ok := tesseractLanguageCodes[lang]; ok {
+			lang = code
+		}
+		if e.installed != nil && !e.installed[lang] {
+			continue
+		}
+		if !containsString(langs, lang) {
+			langs = append(langs, lang)
+		}
 	}
+	return langs
+}
 
-	if len(result.Candidates) == 0 || len(result.Candidates[0].Content.Parts) == 0 {
-		return "", fmt.Errorf("no text extracted from image")
+func (e *tesseractEngine) Recognize(ctx context.Context, image []byte, mimeType string, languages []string) (*models.OCRResult, error) {
+	langs := e.languages(languages)
+	args := []string{"stdin", "stdout"}
+	if len(langs) > 0 {
+		args = append(args, "-l", strings.Join(langs, "+"))
 	}
+	args = append(args, "tsv")
 
-	text := strings.TrimSpace(result.Candidates[0].Content.Parts[0].Text)
-	return text, nil
+	cmd := exec.CommandContext(ctx, e.path, args...)
+	cmd.Stdin = bytes.NewReader(image)
+	var stdout, stderr bytes.Buffer
+	cmd.Stdout = &stdout
+	cmd.Stderr = &stderr
+	if err := cmd.Run(); err != nil {
+		return nil, fmt.Errorf("tesseract: %w: %s", err, strings.TrimSpace(stderr.String()))
+	}
+	return &models.OCRResult{Engine: models.OCREngineTesseract, Languages: langs, Blocks: parseTesseractTSV(stdout.String())}, nil
 }
 
+// parseTesseractTSV groups the words of tesseract's TSV output into blocks. Lines of a
+// block are joined with newlines, and a block's confidence is the mean of its words'.
+func parseTesseractTSV(tsv string) []models.OCRBlock {
+	type tsvBlock struct {
+		box        *models.OCRBox
+		lines      []string
+		lineKey    string
+		confidence float64
+		words      int
+	}
+	var order []string
+	blocks := map[string]*tsvBlock{}
+
+	for i, row := range strings.Split(tsv, "\n") {
+		// Columns: level page_num block_num par_num line_num word_num left top width height conf text
+		fields := strings.Split(strings.TrimRight(row, "\r"), "\t")
+		if i == 0 || len(fields) < 12 {
+			continue
+		}
+		nums := make([]int, 10)
+		for j := range nums {
+			nums[j], _ = strconv.Atoi(fields[j])
+		}
+		key := fields[1] + "/" + fields[2]
+		block := blocks[key]
+		if block == nil {
+			block = &tsvBlock{}
+			blocks[key] = block
+			order = append(order, key)
+		}
+
+		switch nums[0] {
+		case 2: // Block
+			block.box = &models.OCRBox{X: nums[6], Y: nums[7], Width: nums[8], Height: nums[9]}
+		case 5: // Word
+			word := strings.TrimSpace(fields[11])
+			conf, err := strconv.ParseFloat(fields[10], 64)
+			if word == "" || err != nil || conf < 0 {
+				continue
+			}
+			lineKey := fields[3] + "/" + fields[4]
+			if block.lineKey != lineKey || len(block.lines) == 0 {
+				block.lines = append(block.lines, word)
+				block.lineKey = lineKey
+			} else {
+				block.lines[len(block.lines)-1] += " " + word
+			}
+			block.confidence += conf / 100
+			block.words++
+		}
+	}
+
+	result := []models.OCRBlock{}
+	for _, key := range order {
+		block := blocks[key]
+		if block.words == 0 {
+			continue
+		}
+		result = append(result, models.OCRBlock{
+			Text:       strings.Join(block.lines, "\n"),
+			Confidence: block.confidence / float64(block.words),
+			Box:        block.box,
+		})
+	}
+	return result
+}
@@ -0,0 +1,54 @@
+package services
+
+import (
+	"math"
+	"strings"
+	"testing"
+)
+
+func TestParseTesseractTSV(t *testing.T) {
+	rows := []string{
+		"level\tpage_num\tblock_num\tpar_num\tline_num\tword_num\tleft\ttop\twidth\theight\tconf\ttext",
+		"1\t1\t0\t0\t0\t0\t0\t0\t800\t600\t-1\t",
+		"2\t1\t1\t0\t0\t0\t10\t20\t300\t40\t-1\t",
+		"5\t1\t1\t1\t1\t1\t10\t20\t50\t15\t90\tHello",
+		"5\t1\t1\t1\t1\t2\t70\t20\t50\t15\t80\tworld",
+		"5\t1\t1\t1\t2\t1\t10\t40\t50\t15\t70\tagain\r",
+		"5\t1\t1\t1\t2\t2\t70\t40\t50\t15\t-1\t ",
+		"2\t1\t2\t0\t0\t0\t10\t100\t300\t40\t-1\t",
+		"5\t1\t2\t1\t1\t1\t10\t100\t50\t15\t95\tSecond",
+		"2\t1\t3\t0\t0\t0\t10\t200\t300\t40\t-1\t",
+		"short\trow",
+	}
+	blocks := parseTesseractTSV(strings.Join(rows, "\n"))
+
+	if len(blocks) != 2 {
+		t.Fatalf("got %d blocks, want 2 (the empty third block is dropped): %+v", len(blocks), blocks)
+	}
+	tests := []struct {
+		text       string
+		confidence float64
+		x, y       int
+	}{
+		{"Hello world\nagain", 0.8, 10, 20},
+		{"Second", 0.95, 10, 100},
+	}
+	for i, tt := range tests {
+		block := blocks[i]
+		if block.Text != tt.text {
+			t.Errorf("block %d text = %q, want %q", i, block.Text, tt.text)
+		}
+		if math.Abs(block.Confidence-tt.confidence) > 1e-9 {
+			t.Errorf("block %d confidence = %v, want %v", i, block.Confidence, tt.confidence)
+		}
+		if block.Box == nil || block.Box.X != tt.x || block.Box.Y != tt.y {
+			t.Errorf("block %d box = %+v, want at %d,%d", i, block.Box, tt.x, tt.y)
+		}
+	}
+}
+
+func TestParseTesseractTSVEmpty(t *testing.T) {
+	if blocks := parseTesseractTSV(""); len(blocks) != 0 {
+		t.Fatalf("got %+v, want no blocks", blocks)
+	}
+}
@@ -0,0 +1,139 @@
+package services
+
+import (
+	"bytes"
+	"fmt"
+	"net/url"
+	"path"
+	"strings"
+	"synapse/internal/models"
+	"unicode/utf8"
+
+	"github.com/google/uuid"
+	"github.com/ledongthuc/pdf"
+)
+
+// chunkSize is the target length of an indexed chunk in runes. Chunks never span pages.
+const chunkSize = 1000
+
+// ExtractPDF reads the text of every page of a PDF along with its title, author and page
+// count. Pages whose text can't be decoded (scans, unusual fonts) are left empty.
+func ExtractPDF(data []byte) (doc *models.PDFDocument, err error) {
+	// The parser panics on some malformed files
+	defer func() {
+		if r := recover(); r != nil {
+			doc, err = nil, fmt.Errorf("invalid PDF: %v", r)
+		}
+	}()
+
+	reader, err := pdf.NewReader(bytes.NewReader(data), int64(len(data)))
+	if err != nil {
+		return nil, fmt.Errorf("invalid PDF: %w", err)
+	}
+
+	info := reader.Trailer().Key("Info")
+	doc = &models.PDFDocument{
+		Title:     strings.TrimSpace(info.Key("Title").Text()),
+		Author:    strings.TrimSpace(info.Key("Author").Text()),
+		PageCount: reader.NumPage(),
+	}
+	for i := 1; i <= doc.PageCount; i++ {
+		page := reader.Page(i)
+		text := ""
+		if !page.V.IsNull() {
+			if extracted, err := page.GetPlainText(nil); err == nil {
+				text = cleanPDFText(extracted)
+			}
+		}
+		doc.Pages = append(doc.Pages, text)
+	}
+	return doc, nil
+}
+
+// pdfText joins the text of a document's pages, one page per paragraph
+func pdfText(doc *models.PDFDocument) string {
+	var pages []string
+	for _, page := range doc.Pages {
+		if page != "" {
+			pages = append(pages, page)
+		}
+	}
+	return strings.Join(pages, "\n\n")
+}
+
+// pdfTitle names a PDF without a title after its file name
+func pdfTitle(doc *models.PDFDocument, pdfURL string) string {
+	if doc.Title != "" {
+		return doc.Title
+	}
+	if parsed, err := url.Parse(pdfURL); err == nil {
+		if name := strings.TrimSuffix(path.Base(parsed.Path), path.Ext(parsed.Path)); name != "" && name != "." && name != "/" {
+			if unescaped, err := url.PathUnescape(name); err == nil {
+				name = unescaped
+			}
+			return strings.NewReplacer("_", " ", "-", " ").Replace(name)
+		}
+	}
+	return pdfURL
+}
+
+// pageChunks splits page texts into chunks of about chunkSize runes, breaking between
+// words. Each chunk's vector id is derived from the item and its position.
+func pageChunks(itemID uuid.UUID, pages []string) []models.ItemChunk {
+	var chunks []models.ItemChunk
+	add := func(page int, text string) {
+		chunks = append(chunks, models.ItemChunk{
+			ItemID:      itemID,
+			Position:    len(chunks),
+			Page:        page,
+			Content:     text,
+			EmbeddingID: fmt.Sprintf("%s:%d", itemID, len(chunks)),
+		})
+	}
+
+	for i, text := range pages {
+		var current strings.Builder
+		length := 0
+		for _, word := range strings.Fields(text) {
+			runes := utf8.RuneCountInString(word)
+			if length > 0 && length+1+runes > chunkSize {
+				add(i+1, current.String())
+				current.Reset()
+				length = 0
+			}
+			if length > 0 {
+				current.WriteByte(' ')
+				length++
+			}
+			current.WriteString(word)
+			length += runes
+		}
+		if current.Len() > 0 {
+			add(i+1, current.String())
+		}
+	}
+	return chunks
+}
+
+// cleanPDFText drops control characters and collapses the runs of spaces PDF text
+// extraction leaves behind
+func cleanPDFText(text string) string {
+	text = strings.Map(func(r rune) rune {
+		if r == utf8.RuneError || r < ' ' && r != '\n' {
+			return ' '
+		}
+		return r
+	}, text)
+
+	lines := strings.Split(text, "\n")
+	for i, line := range lines {
+		lines[i] = collapseSpace(line)
+	}
+	return strings.TrimSpace(strings.Join(lines, "\n"))
+}
+
+// isPDFURL reports whether a URL points at a PDF
+func isPDFURL(rawURL string) bool {
+	lowerURL := strings.ToLower(rawURL)
+	return strings.HasSuffix(lowerURL, ".pdf") || strings.Contains(lowerURL, ".pdf?")
+}
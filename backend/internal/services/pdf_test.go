@@ -0,0 +1,54 @@
+package services
+
+import (
+	"fmt"
+	"strings"
+	"testing"
+	"unicode/utf8"
+
+	"github.com/google/uuid"
+)
+
+func TestPageChunks(t *testing.T) {
+	itemID := uuid.New()
+	long := strings.TrimSpace(strings.Repeat("wörd ", 450)) // 2249 runes
+
+	tests := []struct {
+		name      string
+		pages     []string
+		wantPages []int // Page of each chunk
+	}{
+		{"one chunk per short page", []string{"first page", "second page"}, []int{1, 2}},
+		{"empty pages skipped", []string{"a b", " \n ", "c"}, []int{1, 3}},
+		{"long page split", []string{long, "tail"}, []int{1, 1, 1, 2}},
+		{"no pages", nil, nil},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			chunks := pageChunks(itemID, tt.pages)
+			if len(chunks) != len(tt.wantPages) {
+				t.Fatalf("got %d chunks, want %d", len(chunks), len(tt.wantPages))
+			}
+			for i, chunk := range chunks {
+				if chunk.Page != tt.wantPages[i] || chunk.Position != i || chunk.ItemID != itemID {
+					t.Errorf("chunk %d: page %d, position %d; want page %d, position %d", i, chunk.Page, chunk.Position, tt.wantPages[i], i)
+				}
+				if want := fmt.Sprintf("%s:%d", itemID, i); chunk.EmbeddingID != want {
+					t.Errorf("chunk %d embedding id = %q, want %q", i, chunk.EmbeddingID, want)
+				}
+				if n := utf8.RuneCountInString(chunk.Content); n > chunkSize {
+					t.Errorf("chunk %d has %d runes, more than %d", i, n, chunkSize)
+				}
+			}
+
+			// Chunks break between words and keep all of them
+			var words []string
+			for _, chunk := range chunks {
+				words = append(words, strings.Fields(chunk.Content)...)
+			}
+			if got, want := strings.Join(words, " "), strings.Join(strings.Fields(strings.Join(tt.pages, " ")), " "); got != want {
+				t.Errorf("chunks hold %q, want %q", got, want)
+			}
+		})
+	}
+}
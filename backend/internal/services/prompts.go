@@ -0,0 +1,228 @@
+package services
+
+import (
+	"bytes"
+	"embed"
+	"fmt"
+	"os"
+	"path/filepath"
+	"regexp"
+	"sort"
+	"strings"
+	"synapse/internal/models"
+	"sync"
+	"text/template"
+)
+
+// Built-in prompt templates. Each can be overridden by a file of the same name in PROMPTS_DIR.
+//
+//go:embed prompts/*.tmpl
+var builtinPrompts embed.FS
+
+// Prompt template names
+const (
+	PromptSummarize       = "summarize"
+	PromptTags            = "tags"
+	PromptEnhanceQuery    = "enhance_query"
+	PromptRerank          = "rerank"
+	PromptCategorize      = "categorize"
+	PromptSemanticSummary = "semantic_summary"
+	PromptYouTubeSummary  = "youtube_summary"
+	PromptEnrich          = "enrich"
+	PromptOCR             = "ocr"
+	PromptCaption         = "caption"
+)
+
+// Template data for each prompt
+type (
+	contentPromptData struct {
+		Content string
+	}
+	enhanceQueryPromptData struct {
+		Query string
+	}
+	rerankPromptData struct {
+		Query   string
+		Results []rerankPromptResult
+	}
+	rerankPromptResult struct {
+		Number  int
+		Title   string
+		Summary string
+		Type    string
+	}
+	categorizePromptData struct {
+		Title      string
+		Type       string
+		Content    string
+		Categories []models.Category
+	}
+	semanticSummaryPromptData struct {
+		Title   string
+		Content string
+	}
+	youtubeSummaryPromptData struct {
+		Title       string
+		Description string
+	}
+	enrichPromptData struct {
+		Title        string
+		Type         string
+		Content      string
+		Categories   []models.Category
+		ContentKinds []string
+		KnownTags    []string
+	}
+	tagsPromptData struct {
+		Content   string
+		KnownTags []string
+	}
+	ocrPromptData struct {
+		Languages []string
+	}
+)
+
+var sampleCategories = []models.Category{{Name: "Other", Description: "Anything else"}}
+
+// promptSamples holds sample data for every known prompt. Templates are rendered with
+// it when loaded so that typos in field names fail at startup instead of on first use.
+var promptSamples = map[string]interface{}{
+	PromptSummarize:       contentPromptData{Content: "sample content"},
+	PromptTags:            tagsPromptData{Content: "sample content", KnownTags: []string{"sample"}},
+	PromptEnhanceQuery:    enhanceQueryPromptData{Query: "sample query"},
+	PromptRerank:          rerankPromptData{Query: "sample query", Results: []rerankPromptResult{{Number: 1, Title: "title", Summary: "summary", Type: "text"}}},
+	PromptCategorize:      categorizePromptData{Title: "title", Type: "text", Content: "sample content", Categories: sampleCategories},
+	PromptSemanticSummary: semanticSummaryPromptData{Title: "title", Content: "sample content"},
+	PromptYouTubeSummary:  youtubeSummaryPromptData{Title: "title", Description: "description"},
+	PromptEnrich:          enrichPromptData{Title: "title", Type: "text", Content: "sample content", Categories: sampleCategories, ContentKinds: []string{"other"}, KnownTags: []string{"sample"}},
+	PromptOCR:             ocrPromptData{Languages: []string{"en"}},
+	PromptCaption:         struct{}{},
+}
+
+// promptFuncs are available to every template
+var promptFuncs = template.FuncMap{
+	"join": strings.Join,
+}
+
+// promptVersionPattern matches the version header templates start with: {{/* version: 3 */}}
+var promptVersionPattern = regexp.MustCompile(`^\{\{-?\s*/\*\s*version:\s*([^\s*]+)\s*\*/`)
+
+type promptTemplate struct {
+	tmpl    *template.Template
+	version string
+	source  string // "builtin" or the override file path
+}
+
+// PromptStore holds the parsed prompt templates
+type PromptStore struct {
+	dir string
+
+	mu      sync.RWMutex
+	prompts map[string]*promptTemplate
+}
+
+// NewPromptStore loads the built-in templates and any overrides found in dir (PROMPTS_DIR
+// when empty). It fails if a template doesn't parse or render, so misconfigured prompts
+// stop the server at startup.
+func NewPromptStore(dir string) (*PromptStore, error) {
+	if dir == "" {
+		dir = os.Getenv("PROMPTS_DIR")
+	}
+	s := &PromptStore{dir: dir}
+	if err := s.Reload(); err != nil {
+		return nil, err
+	}
+	return s, nil
+}
+
+// Reload re-reads the override directory. On error the previously loaded templates are kept.
+func (s *PromptStore) Reload() error {
+	prompts := map[string]*promptTemplate{}
+	for name := range promptSamples {
+		text, err := builtinPrompts.ReadFile("prompts/" + name + ".tmpl")
+		if err != nil {
+			return fmt.Errorf("built-in prompt %s: %w", name, err)
+		}
+		p, err := parsePrompt(name, string(text), "builtin")
+		if err != nil {
+			return err
+		}
+		prompts[name] = p
+	}
+
+	if s.dir != "" {
+		files, err := filepath.Glob(filepath.Join(s.dir, "*.tmpl"))
+		if err != nil {
+			return err
+		}
+		for _, path := range files {
+			name := strings.TrimSuffix(filepath.Base(path), ".tmpl")
+			if _, ok := promptSamples[name]; !ok {
+				return fmt.Errorf("unknown prompt template %s", path)
+			}
+			text, err := os.ReadFile(path)
+			if err != nil {
+				return err
+			}
+			p, err := parsePrompt(name, string(text), path)
+			if err != nil {
+				return err
+			}
+			prompts[name] = p
+		}
+	}
+
+	s.mu.Lock()
+	s.prompts = prompts
+	s.mu.Unlock()
+	return nil
+}
+
+func parsePrompt(name, text, source string) (*promptTemplate, error) {
+	tmpl, err := template.New(name).Funcs(promptFuncs).Option("missingkey=error").Parse(text)
+	if err != nil {
+		return nil, fmt.Errorf("prompt %s (%s): %w", name, source, err)
+	}
+	if err := tmpl.Execute(&bytes.Buffer{}, promptSamples[name]); err != nil {
+		return nil, fmt.Errorf("prompt %s (%s): %w", name, source, err)
+	}
+
+	version := "unversioned"
+	if m := promptVersionPattern.FindStringSubmatch(text); m != nil {
+		version = m[1]
+	}
+	return &promptTemplate{tmpl: tmpl, version: version, source: source}, nil
+}
+
+// Render executes the named template with data
+func (s *PromptStore) Render(name string, data interface{}) (string, error) {
+	s.mu.RLock()
+	p, ok := s.prompts[name]
+	s.mu.RUnlock()
+	if !ok {
+		return "", fmt.Errorf("unknown prompt template %s", name)
+	}
+
+	var buf bytes.Buffer
+	if err := p.tmpl.Execute(&buf, data); err != nil {
+		return "", fmt.Errorf("render prompt %s: %w", name, err)
+	}
+	return strings.TrimSpace(buf.String()), nil
+}
+
+// List describes the loaded templates
+func (s *PromptStore) List() []models.PromptInfo {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+
+	infos := make([]models.PromptInfo, 0, len(s.prompts))
+	for name, p := range s.prompts {
+		infos = append(infos, models.PromptInfo{
+			Name:    name,
+			Version: p.version,
+			Source:  p.source,
+		})
+	}
+	sort.Slice(infos, func(i, j int) bool { return infos[i].Name < infos[j].Name })
+	return infos
+}
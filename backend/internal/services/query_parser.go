@@ -8,7 +8,9 @@ import (
 	"time"
 )
 
-func ParseNaturalLanguageQuery(query string) *models.QueryFilters {
+// ParseNaturalLanguageQuery extracts filters from a plain English query. categories are
+// used to recognize category mentions by name or alias.
+func ParseNaturalLanguageQuery(query string, categories []models.Category) *models.QueryFilters {
 	filters := &models.QueryFilters{
 		SearchTerms: query,
 	}
@@ -34,11 +36,14 @@ func ParseNaturalLanguageQuery(query string) *models.QueryFilters {
 	filters.Author = extractAuthor(lowerQuery)
 	
 	// Extract category filter (reusing Source field for category)
-	filters.Source = extractCategory(lowerQuery)
+	filters.Source = extractCategory(lowerQuery, categories)
 
 	// Extract tags (common patterns)
 	filters.Tags = extractTags(lowerQuery)
 
+	// Extract favorite, pinned, read and archived filters
+	filters.ItemStateFilter = extractState(lowerQuery)
+
 	// Clean search terms (remove filter phrases) - only if not a quote query
 	if quoteQuery == "" {
 		filters.SearchTerms = cleanSearchTerms(query, filters)
@@ -241,46 +246,20 @@ func extractAuthor(query string) string {
 	return ""
 }
 
-func extractCategory(query string) string {
-	// Map common category mentions to actual category names
-	categoryMap := map[string]string{
-		"technology":     "Technology",
-		"tech":           "Technology",
-		"food":           "Food & Recipes",
-		"recipe":         "Food & Recipes",
-		"cooking":        "Food & Recipes",
-		"book":           "Books & Reading",
-		"books":          "Books & Reading",
-		"reading":        "Books & Reading",
-		"video":          "Videos & Entertainment",
-		"videos":         "Videos & Entertainment",
-		"entertainment":  "Videos & Entertainment",
-		"shopping":       "Shopping & Products",
-		"product":        "Shopping & Products",
-		"products":       "Shopping & Products",
-		"article":        "Articles & News",
-		"articles":       "Articles & News",
-		"news":           "Articles & News",
-		"note":           "Notes & Ideas",
-		"notes":          "Notes & Ideas",
-		"idea":           "Notes & Ideas",
-		"ideas":          "Notes & Ideas",
-		"design":         "Design & Inspiration",
-		"inspiration":    "Design & Inspiration",
-		"travel":         "Travel",
-		"health":         "Health & Fitness",
-		"fitness":        "Health & Fitness",
-		"education":      "Education & Learning",
-		"learning":       "Education & Learning",
-	}
-
-	for keyword, category := range categoryMap {
-		if strings.Contains(query, keyword) {
-			return category
+func extractCategory(query string, categories []models.Category) string {
+	// Match category names and aliases; the longest mention wins so "food & recipes"
+	// beats an alias like "food" of another category
+	best, bestLen := "", 0
+	for _, category := range categories {
+		keywords := append([]string{strings.ToLower(category.Name)}, category.Aliases...)
+		for _, keyword := range keywords {
+			if keyword != "" && len(keyword) > bestLen && strings.Contains(query, keyword) {
+				best, bestLen = category.Name, len(keyword)
+			}
 		}
 	}
 
-	return ""
+	return best
 }
 
 func extractTags(query string) []string {
@@ -288,14 +267,41 @@ func extractTags(query string) []string {
 	// Tags should only be extracted from explicit tag mentions like #ai
 	// This prevents removing important search terms
 	var tags []string
-	re := regexp.MustCompile(`#(\w+)`) // Finds words prefixed with #
+	re := regexp.MustCompile(`#([\w-]+)`) // Finds words prefixed with # (tags are hyphen-separated)
 	matches := re.FindAllStringSubmatch(query, -1)
 	for _, m := range matches {
-		tags = append(tags, m[1])
+		tags = append(tags, NormalizeTag(m[1]))
 	}
 	return tags
 }
 
+// statePhrases map phrases asking for items in a given state to the filter they set
+var statePhrases = []struct {
+	re    *regexp.Regexp
+	apply func(state *models.ItemStateFilter)
+}{
+	{regexp.MustCompile(`\b(?:my favou?rites?|favou?rited|starred)\b`), func(state *models.ItemStateFilter) { state.Favorite = boolPtr(true) }},
+	{regexp.MustCompile(`\bpinned\b`), func(state *models.ItemStateFilter) { state.Pinned = boolPtr(true) }},
+	{regexp.MustCompile(`\b(?:unread|not read yet|haven'?t read)\b`), func(state *models.ItemStateFilter) { state.Read = boolPtr(false) }},
+	{regexp.MustCompile(`\b(?:already read|i(?:'ve| have) read)\b`), func(state *models.ItemStateFilter) { state.Read = boolPtr(true) }},
+	{regexp.MustCompile(`\barchived\b`), func(state *models.ItemStateFilter) { state.Archived = boolPtr(true) }},
+}
+
+// extractState recognizes requests for favorite, pinned, read or unread and archived items
+func extractState(query string) models.ItemStateFilter {
+	var state models.ItemStateFilter
+	for _, phrase := range statePhrases {
+		if phrase.re.MatchString(query) {
+			phrase.apply(&state)
+		}
+	}
+	return state
+}
+
+func boolPtr(value bool) *bool {
+	return &value
+}
+
 func cleanSearchTerms(originalQuery string, filters *models.QueryFilters) string {
 	query := originalQuery
 
@@ -349,6 +355,15 @@ func cleanSearchTerms(originalQuery string, filters *models.QueryFilters) string
 	authorRe := regexp.MustCompile(`(from|by)\s+[A-Z][a-z]+`)
 	query = authorRe.ReplaceAllString(query, "")
 
+	// Remove state phrases; a query that only asks for a state has no search terms left
+	hasState := filters.Favorite != nil || filters.Pinned != nil || filters.Read != nil || filters.Archived != nil
+	for _, phrase := range statePhrases {
+		query = phrase.re.ReplaceAllString(strings.ToLower(query), "")
+	}
+	if hasState && strings.TrimSpace(regexp.MustCompile(`\b(?:show me|find|get|my|all|items?)\b`).ReplaceAllString(query, "")) == "" {
+		return ""
+	}
+
 	// Clean up extra spaces
 	query = regexp.MustCompile(`\s+`).ReplaceAllString(query, " ")
 	query = strings.TrimSpace(query)
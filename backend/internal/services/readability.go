@@ -0,0 +1,398 @@
+package services
+
+import (
+	"io"
+	"math"
+	"net/url"
+	"regexp"
+	"strings"
+	"synapse/internal/models"
+	"time"
+
+	"golang.org/x/net/html"
+	"golang.org/x/net/html/atom"
+)
+
+var (
+	// Elements whose class or id match these are boilerplate or main content
+	negativeClassRe = regexp.MustCompile(`(?i)comment|sidebar|footer|footnote|masthead|nav|menu|promo|related|share|social|sponsor|advert|\bads?\b|cookie|banner|subscribe|newsletter|popup|modal|breadcrumb|widget|outbrain|taboola`)
+	positiveClassRe = regexp.MustCompile(`(?i)article|content|post|entry|main|text|story|body|blog|prose`)
+	bylineClassRe   = regexp.MustCompile(`(?i)byline|author|writtenby|p-author`)
+	whitespaceRe    = regexp.MustCompile(`\s+`)
+)
+
+// Elements that never hold article text
+var skippedElements = map[atom.Atom]bool{
+	atom.Script: true, atom.Style: true, atom.Noscript: true, atom.Template: true, atom.Iframe: true,
+	atom.Svg: true, atom.Canvas: true, atom.Form: true, atom.Button: true, atom.Select: true,
+	atom.Input: true, atom.Textarea: true, atom.Nav: true, atom.Aside: true, atom.Footer: true,
+	atom.Header: true, atom.Menu: true, atom.Dialog: true,
+}
+
+// Elements that start a new paragraph in extracted text
+var blockElements = map[atom.Atom]bool{
+	atom.P: true, atom.Div: true, atom.Section: true, atom.Article: true, atom.Main: true,
+	atom.H1: true, atom.H2: true, atom.H3: true, atom.H4: true, atom.H5: true, atom.H6: true,
+	atom.Ul: true, atom.Ol: true, atom.Li: true, atom.Blockquote: true, atom.Pre: true,
+	atom.Table: true, atom.Tr: true, atom.Figure: true, atom.Figcaption: true, atom.Dl: true,
+	atom.Dt: true, atom.Dd: true, atom.Hr: true, atom.Br: true,
+}
+
+// publishedLayouts are the date formats found in article metadata
+var publishedLayouts = []string{
+	time.RFC3339, "2006-01-02T15:04:05Z0700", "2006-01-02T15:04:05", "2006-01-02T15:04", "2006-01-02 15:04:05",
+	"2006-01-02", time.RFC1123, time.RFC1123Z, "January 2, 2006", "Jan 2, 2006", "2 January 2006",
+}
+
+// ExtractArticle finds the main text and metadata of an HTML page the way reader modes
+// do: paragraphs are scored by length and punctuation, the scores flow to their parent
+// and grandparent, boilerplate is penalized by class name and link density, and the best
+// container (with related siblings) becomes the article, rendered as Markdown. pageURL
+// resolves relative links. It returns an article with empty Content when no main text is found.
+func ExtractArticle(pageURL string, r io.Reader) (*models.Article, error) {
+	doc, err := html.Parse(r)
+	if err != nil {
+		return nil, err
+	}
+	base, _ := url.Parse(pageURL)
+
+	article := &models.Article{}
+	meta := collectMeta(doc)
+	article.Title = firstNonEmpty(meta["og:title"], meta["twitter:title"], documentTitle(doc))
+	article.SiteName = firstNonEmpty(meta["og:site_name"], meta["application-name"])
+	article.Excerpt = firstNonEmpty(meta["og:description"], meta["description"], meta["twitter:description"])
+	article.Byline = firstNonEmpty(meta["author"], meta["article:author"], meta["parsely-author"], meta["sailthru.author"])
+	if strings.HasPrefix(article.Byline, "http") {
+		// article:author is often a profile URL
+		article.Byline = ""
+	}
+	article.Language = normalizeLanguage(firstNonEmpty(htmlLang(doc), meta["content-language"], meta["og:locale"]))
+	article.LeadImage = safeURL(base, firstNonEmpty(meta["og:image"], meta["og:image:url"], meta["twitter:image"], meta["twitter:image:src"]), "http", "https")
+	article.PublishedAt = parsePublished(firstNonEmpty(
+		meta["article:published_time"], meta["og:published_time"], meta["datepublished"], meta["date"],
+		meta["pubdate"], meta["dc.date"], meta["dc.date.issued"], firstTimeElement(doc),
+	))
+
+	body := findFirst(doc, atom.Body)
+	if body == nil {
+		body = doc
+	}
+	if article.Byline == "" {
+		article.Byline = findByline(body)
+	}
+
+	top := topCandidate(body)
+	if top == nil {
+		return article, nil
+	}
+	article.Content = renderMarkdown(top.node, base)
+	// The article usually repeats its title as a heading
+	if first, rest, found := strings.Cut(article.Content, "\n\n"); found && PlainText(models.FormatMarkdown, first) == article.Title {
+		article.Content = rest
+	}
+	if article.LeadImage == "" {
+		if img := findFirst(top.node, atom.Img); img != nil {
+			article.LeadImage = safeURL(base, firstNonEmpty(attr(img, "src"), attr(img, "data-src")), "http", "https")
+		}
+	}
+	if article.Excerpt == "" {
+		first, _, _ := strings.Cut(article.Content, "\n\n")
+		article.Excerpt = PlainText(models.FormatMarkdown, first)
+	}
+	return article, nil
+}
+
+// candidate is a container scored by the paragraphs it holds
+type candidate struct {
+	node  *html.Node
+	score float64
+}
+
+// topCandidate scores paragraph containers and returns the best one, merged with
+// siblings that score close to it
+func topCandidate(body *html.Node) *candidate {
+	scores := map[*html.Node]*candidate{}
+	var order []*html.Node
+	add := func(node *html.Node, score float64) {
+		c, ok := scores[node]
+		if !ok {
+			c = &candidate{node: node, score: classWeight(node)}
+			if node.DataAtom == atom.Article || node.DataAtom == atom.Main {
+				c.score += 10
+			}
+			scores[node] = c
+			order = append(order, node)
+		}
+		c.score += score
+	}
+
+	var walk func(n *html.Node)
+	walk = func(n *html.Node) {
+		if n.Type == html.ElementNode && (skippedElements[n.DataAtom] || isBoilerplate(n)) {
+			return
+		}
+		if n.Type == html.ElementNode && (n.DataAtom == atom.P || n.DataAtom == atom.Pre || n.DataAtom == atom.Blockquote || n.DataAtom == atom.Td) {
+			text := collapseSpace(nodeText(n))
+			if len(text) >= 25 && n.Parent != nil {
+				score := 1 + float64(strings.Count(text, ",")) + math.Min(float64(len(text))/100, 3)
+				add(n.Parent, score)
+				if n.Parent.Parent != nil {
+					add(n.Parent.Parent, score/2)
+				}
+			}
+			return
+		}
+		for child := n.FirstChild; child != nil; child = child.NextSibling {
+			walk(child)
+		}
+	}
+	walk(body)
+
+	var best *candidate
+	for _, node := range order {
+		c := scores[node]
+		c.score *= 1 - linkDensity(node)
+		if best == nil || c.score > best.score {
+			best = c
+		}
+	}
+	if best == nil || best.score < 5 {
+		return nil
+	}
+
+	// Siblings holding a good share of the text belong to the article too, e.g. when
+	// paragraphs are split across several divs
+	parent := best.node.Parent
+	if parent == nil || parent.DataAtom == atom.Body || parent.Type == html.DocumentNode {
+		return best
+	}
+	threshold := math.Max(10, best.score*0.2)
+	merged := &html.Node{Type: html.ElementNode, Data: "div", DataAtom: atom.Div}
+	for sibling := parent.FirstChild; sibling != nil; sibling = sibling.NextSibling {
+		c, scored := scores[sibling]
+		if sibling == best.node || (scored && c.score >= threshold) {
+			merged.AppendChild(cloneNode(sibling))
+		}
+	}
+	return &candidate{node: merged, score: best.score}
+}
+
+// collectMeta maps lowercased meta property/name/itemprop/http-equiv keys to their
+// first content
+func collectMeta(doc *html.Node) map[string]string {
+	meta := map[string]string{}
+	var walk func(n *html.Node)
+	walk = func(n *html.Node) {
+		if n.Type == html.ElementNode && n.DataAtom == atom.Meta {
+			content := strings.TrimSpace(attr(n, "content"))
+			for _, key := range []string{"property", "name", "itemprop", "http-equiv"} {
+				if name := strings.ToLower(attr(n, key)); name != "" && content != "" {
+					if _, seen := meta[name]; !seen {
+						meta[name] = content
+					}
+				}
+			}
+		}
+		for child := n.FirstChild; child != nil; child = child.NextSibling {
+			walk(child)
+		}
+	}
+	walk(doc)
+	return meta
+}
+
+func documentTitle(doc *html.Node) string {
+	if title := findFirst(doc, atom.Title); title != nil {
+		return collapseSpace(nodeText(title))
+	}
+	return ""
+}
+
+func htmlLang(doc *html.Node) string {
+	if root := findFirst(doc, atom.Html); root != nil {
+		return attr(root, "lang")
+	}
+	return ""
+}
+
+// firstTimeElement returns the datetime of the first <time> element
+func firstTimeElement(doc *html.Node) string {
+	if t := findFirst(doc, atom.Time); t != nil {
+		return attr(t, "datetime")
+	}
+	return ""
+}
+
+// findByline looks for a short element marked as the author
+func findByline(root *html.Node) string {
+	var byline string
+	var walk func(n *html.Node)
+	walk = func(n *html.Node) {
+		if byline != "" || (n.Type == html.ElementNode && skippedElements[n.DataAtom] && n.DataAtom != atom.Header) {
+			return
+		}
+		if n.Type == html.ElementNode && (attr(n, "rel") == "author" || attr(n, "itemprop") == "author" ||
+			bylineClassRe.MatchString(attr(n, "class")+" "+attr(n, "id"))) {
+			if text := collapseSpace(nodeText(n)); text != "" && len(text) < 100 {
+				byline = strings.TrimSpace(strings.TrimPrefix(strings.TrimPrefix(text, "By "), "by "))
+				return
+			}
+		}
+		for child := n.FirstChild; child != nil; child = child.NextSibling {
+			walk(child)
+		}
+	}
+	walk(root)
+	return byline
+}
+
+func parsePublished(value string) *time.Time {
+	value = strings.TrimSpace(value)
+	if value == "" {
+		return nil
+	}
+	for _, layout := range publishedLayouts {
+		if t, err := time.Parse(layout, value); err == nil {
+			return &t
+		}
+	}
+	return nil
+}
+
+// normalizeLanguage turns "en-US", "en_GB" or "EN" into "en"
+func normalizeLanguage(lang string) string {
+	lang = strings.ToLower(strings.TrimSpace(lang))
+	if i := strings.IndexAny(lang, "-_"); i >= 0 {
+		lang = lang[:i]
+	}
+	if len(lang) != 2 {
+		return ""
+	}
+	return lang
+}
+
+func isBoilerplate(n *html.Node) bool {
+	if n.DataAtom == atom.Body || n.DataAtom == atom.Article || n.DataAtom == atom.Main {
+		return false
+	}
+	if attr(n, "hidden") != "" || attr(n, "aria-hidden") == "true" || attr(n, "role") == "navigation" || attr(n, "role") == "complementary" {
+		return true
+	}
+	class := attr(n, "class") + " " + attr(n, "id")
+	return negativeClassRe.MatchString(class) && !positiveClassRe.MatchString(class)
+}
+
+func classWeight(n *html.Node) float64 {
+	weight := 0.0
+	for _, value := range []string{attr(n, "class"), attr(n, "id")} {
+		if value == "" {
+			continue
+		}
+		if negativeClassRe.MatchString(value) {
+			weight -= 25
+		}
+		if positiveClassRe.MatchString(value) {
+			weight += 25
+		}
+	}
+	return weight
+}
+
+// linkDensity is the share of a node's text inside links
+func linkDensity(n *html.Node) float64 {
+	total := len(collapseSpace(nodeText(n)))
+	if total == 0 {
+		return 0
+	}
+	linked := 0
+	var walk func(n *html.Node)
+	walk = func(n *html.Node) {
+		if n.Type == html.ElementNode && n.DataAtom == atom.A {
+			linked += len(collapseSpace(nodeText(n)))
+			return
+		}
+		for child := n.FirstChild; child != nil; child = child.NextSibling {
+			walk(child)
+		}
+	}
+	walk(n)
+	return math.Min(float64(linked)/float64(total), 1)
+}
+
+// nodeText concatenates the text under n, skipping scripts and styles
+func nodeText(n *html.Node) string {
+	var b strings.Builder
+	var walk func(n *html.Node)
+	walk = func(n *html.Node) {
+		if n.Type == html.TextNode {
+			b.WriteString(n.Data)
+			return
+		}
+		if n.Type == html.ElementNode && (n.DataAtom == atom.Script || n.DataAtom == atom.Style || n.DataAtom == atom.Noscript) {
+			return
+		}
+		for child := n.FirstChild; child != nil; child = child.NextSibling {
+			walk(child)
+		}
+	}
+	walk(n)
+	return b.String()
+}
+
+func findFirst(n *html.Node, a atom.Atom) *html.Node {
+	if n.Type == html.ElementNode && n.DataAtom == a {
+		return n
+	}
+	for child := n.FirstChild; child != nil; child = child.NextSibling {
+		if found := findFirst(child, a); found != nil {
+			return found
+		}
+	}
+	return nil
+}
+
+// cloneNode deep-copies n so it can be attached to another parent
+func cloneNode(n *html.Node) *html.Node {
+	clone := &html.Node{Type: n.Type, Data: n.Data, DataAtom: n.DataAtom, Namespace: n.Namespace, Attr: n.Attr}
+	for child := n.FirstChild; child != nil; child = child.NextSibling {
+		clone.AppendChild(cloneNode(child))
+	}
+	return clone
+}
+
+func attr(n *html.Node, key string) string {
+	for _, a := range n.Attr {
+		if a.Key == key {
+			return a.Val
+		}
+	}
+	return ""
+}
+
+func resolveURL(base *url.URL, ref string) string {
+	ref = strings.TrimSpace(ref)
+	if ref == "" || strings.HasPrefix(ref, "data:") {
+		return ""
+	}
+	if base == nil {
+		return ref
+	}
+	resolved, err := base.Parse(ref)
+	if err != nil {
+		return ""
+	}
+	return resolved.String()
+}
+
+func collapseSpace(s string) string {
+	return strings.TrimSpace(whitespaceRe.ReplaceAllString(s, " "))
+}
+
+func firstNonEmpty(values ...string) string {
+	for _, value := range values {
+		if strings.TrimSpace(value) != "" {
+			return strings.TrimSpace(value)
+		}
+	}
+	return ""
+}
@@ -2,45 +2,65 @@ package services
 
 import (
 	"context"
+	"errors"
 	"synapse/internal/db"
 	"synapse/internal/models"
 	"synapse/internal/repository"
 
 	"github.com/google/uuid"
+	"github.com/jackc/pgx/v5"
 )
 
 type RelationService struct {
-	itemRepo       *repository.ItemRepository
-	relationRepo   *repository.RelationRepository
-	aiService      *AIService
-	collectionName string
+	itemRepo          *repository.ItemRepository
+	relationRepo      *repository.RelationRepository
+	aiService         *AIService
+	collectionService *CollectionService
+	collectionName    string
 }
 
-func NewRelationService(itemRepo *repository.ItemRepository, relationRepo *repository.RelationRepository, aiService *AIService) *RelationService {
+func NewRelationService(itemRepo *repository.ItemRepository, relationRepo *repository.RelationRepository, aiService *AIService, collectionService *CollectionService) *RelationService {
 	return &RelationService{
-		itemRepo:       itemRepo,
-		relationRepo:   relationRepo,
-		aiService:      aiService,
-		collectionName: "synapse_items",
+		itemRepo:          itemRepo,
+		relationRepo:      relationRepo,
+		aiService:         aiService,
+		collectionService: collectionService,
+		collectionName:    "synapse_items",
 	}
 }
 
-func (s *RelationService) FindRelatedItems(ctx context.Context, itemID uuid.UUID, limit int) ([]models.RelatedItem, error) {
-	// Check cache first
-	cached, err := s.relationRepo.GetRelated(ctx, itemID, limit)
-	if err == nil && len(cached) > 0 {
-		return cached, nil
-	}
-
-	// Get the item
+// FindRelatedItems returns the items most similar to itemID. A non-nil collectionID limits
+// them to the items of that collection; scoped results bypass the relation cache.
+func (s *RelationService) FindRelatedItems(ctx context.Context, itemID uuid.UUID, limit int, collectionID *uuid.UUID) ([]models.RelatedItem, error) {
+	// Load the item first: the relation cache still holds rows for trashed items
 	item, err := s.itemRepo.GetByID(ctx, itemID)
 	if err != nil {
+		if errors.Is(err, pgx.ErrNoRows) {
+			return nil, ErrItemNotFound
+		}
 		return nil, err
 	}
 
+	var members map[uuid.UUID]bool
+	if collectionID != nil {
+		ids, err := s.collectionService.ItemIDs(ctx, *collectionID)
+		if err != nil {
+			return nil, err
+		}
+		members = ids
+	}
+
+	// Check cache first
+	if members == nil {
+		cached, err := s.relationRepo.GetRelated(ctx, itemID, limit)
+		if err == nil && len(cached) > 0 {
+			return cached, nil
+		}
+	}
+
 	// Generate embedding for the item's content to use for similarity search
 	// (We could store this, but for MVP we'll regenerate)
-	searchText := item.Title + " " + item.Content
+	searchText := item.Title + " " + itemText(item)
 	if len(searchText) > 1000 {
 		searchText = searchText[:1000]
 	}
@@ -51,7 +71,11 @@ func (s *RelationService) FindRelatedItems(ctx context.Context, itemID uuid.UUID
 	}
 
 	// Query for similar items (limit+1 to potentially exclude the item itself)
-	ids, distances, err := db.Chroma.Query(s.collectionName, embedding, limit+10)
+	candidates := limit + 10
+	if members != nil {
+		candidates = limit*10 + 10
+	}
+	ids, distances, err := db.Chroma.Query(s.collectionName, embedding, candidates)
 	if err != nil {
 		return nil, err
 	}
@@ -64,6 +88,9 @@ func (s *RelationService) FindRelatedItems(ctx context.Context, itemID uuid.UUID
 		if err != nil || relatedID == itemID {
 			continue
 		}
+		if members != nil && !members[relatedID] {
+			continue
+		}
 		relatedIDs = append(relatedIDs, relatedID)
 		relatedDistances = append(relatedDistances, distances[i])
 	}
@@ -103,8 +130,10 @@ func (s *RelationService) FindRelatedItems(ctx context.Context, itemID uuid.UUID
 			similarity = 0
 		}
 
-		// Cache the relation
-		s.relationRepo.Create(ctx, itemID, id, similarity)
+		// Cache the relation (scoped results would leave the cache incomplete)
+		if members == nil {
+			s.relationRepo.Create(ctx, itemID, id, similarity)
+		}
 
 		results = append(results, models.RelatedItem{
 			Item:            it,
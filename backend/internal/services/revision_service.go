@@ -0,0 +1,215 @@
+package services
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"strings"
+	"synapse/internal/models"
+	"synapse/internal/repository"
+
+	"github.com/google/uuid"
+	"github.com/jackc/pgx/v5"
+)
+
+var ErrRevisionNotFound = errors.New("revision not found")
+
+// maxDiffCells bounds the LCS table of a line diff; larger changes are shown as a
+// full replacement
+const maxDiffCells = 4_000_000
+
+// RevisionService lists an item's revision history and reverts items to a revision.
+// Revisions themselves are recorded by the repositories as items change.
+type RevisionService struct {
+	repo     *repository.RevisionRepository
+	itemRepo *repository.ItemRepository
+	indexer  *Indexer
+}
+
+func NewRevisionService(repo *repository.RevisionRepository, itemRepo *repository.ItemRepository, indexer *Indexer) *RevisionService {
+	return &RevisionService{
+		repo:     repo,
+		itemRepo: itemRepo,
+		indexer:  indexer,
+	}
+}
+
+// List returns an item's revisions, newest first, each with its changes from the
+// previous revision
+func (s *RevisionService) List(ctx context.Context, itemID uuid.UUID) ([]models.ItemRevision, error) {
+	if _, err := s.itemRepo.GetByID(ctx, itemID); err != nil {
+		if errors.Is(err, pgx.ErrNoRows) {
+			return nil, ErrItemNotFound
+		}
+		return nil, err
+	}
+
+	revisions, err := s.repo.List(ctx, itemID)
+	if err != nil {
+		return nil, err
+	}
+
+	previous := models.ItemRevision{}
+	for i := range revisions {
+		revisions[i].Changes = revisionChanges(&previous, &revisions[i])
+		previous = revisions[i]
+	}
+
+	for i, j := 0, len(revisions)-1; i < j; i, j = i+1, j-1 {
+		revisions[i], revisions[j] = revisions[j], revisions[i]
+	}
+	return revisions, nil
+}
+
+// Revert sets an item back to a revision, recording the revert as a new revision by the
+// user. The embedding is regenerated when the title or content changes.
+func (s *RevisionService) Revert(ctx context.Context, itemID uuid.UUID, revisionID int64) (*models.Item, error) {
+	item, err := s.itemRepo.GetByID(ctx, itemID)
+	if errors.Is(err, pgx.ErrNoRows) {
+		return nil, ErrItemNotFound
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	revisions, err := s.repo.List(ctx, itemID)
+	if err != nil {
+		return nil, err
+	}
+	var revision *models.ItemRevision
+	for i := range revisions {
+		if revisions[i].ID == revisionID {
+			revision = &revisions[i]
+		}
+	}
+	if revision == nil {
+		return nil, ErrRevisionNotFound
+	}
+
+	if err := s.itemRepo.Revert(ctx, revision, PlainText(item.ContentFormat, revision.Content)); err != nil {
+		if errors.Is(err, pgx.ErrNoRows) {
+			return nil, ErrItemNotFound
+		}
+		return nil, err
+	}
+
+	if revision.Content != item.Content || revision.Title != item.Title {
+		if err := s.indexer.Reindex(ctx, itemID); err != nil {
+			fmt.Printf("Warning: Failed to update embedding for item %s: %v\n", itemID, err)
+		}
+	}
+
+	reverted, err := s.itemRepo.GetByID(ctx, itemID)
+	if errors.Is(err, pgx.ErrNoRows) {
+		return nil, ErrItemNotFound
+	}
+	return reverted, err
+}
+
+// revisionChanges diffs the fields that differ between two revisions
+func revisionChanges(previous, current *models.ItemRevision) []models.FieldDiff {
+	fields := []struct {
+		name     string
+		old, new string
+	}{
+		{"title", previous.Title, current.Title},
+		{"content", previous.Content, current.Content},
+		{"summary", previous.Summary, current.Summary},
+		{"category", previous.Category, current.Category},
+		{"tags", strings.Join(previous.Tags, "\n"), strings.Join(current.Tags, "\n")},
+	}
+
+	changes := []models.FieldDiff{}
+	for _, field := range fields {
+		if field.old != field.new {
+			changes = append(changes, models.FieldDiff{Field: field.name, Diff: lineDiff(field.old, field.new)})
+		}
+	}
+	return changes
+}
+
+// lineDiff returns a line diff of two texts, each line prefixed with "-", "+" or " "
+func lineDiff(oldText, newText string) string {
+	oldLines := splitLines(oldText)
+	newLines := splitLines(newText)
+
+	// Common prefix and suffix need no LCS
+	prefix := 0
+	for prefix < len(oldLines) && prefix < len(newLines) && oldLines[prefix] == newLines[prefix] {
+		prefix++
+	}
+	suffix := 0
+	for suffix < len(oldLines)-prefix && suffix < len(newLines)-prefix &&
+		oldLines[len(oldLines)-1-suffix] == newLines[len(newLines)-1-suffix] {
+		suffix++
+	}
+
+	var b strings.Builder
+	for _, line := range oldLines[:prefix] {
+		b.WriteString(" " + line + "\n")
+	}
+	diffMiddle(&b, oldLines[prefix:len(oldLines)-suffix], newLines[prefix:len(newLines)-suffix])
+	for _, line := range oldLines[len(oldLines)-suffix:] {
+		b.WriteString(" " + line + "\n")
+	}
+	return strings.TrimSuffix(b.String(), "\n")
+}
+
+// diffMiddle writes the LCS diff of two line slices
+func diffMiddle(b *strings.Builder, a, c []string) {
+	if len(a)*len(c) > maxDiffCells {
+		for _, line := range a {
+			b.WriteString("-" + line + "\n")
+		}
+		for _, line := range c {
+			b.WriteString("+" + line + "\n")
+		}
+		return
+	}
+
+	// lcs[i][j] is the LCS length of a[i:] and c[j:]
+	lcs := make([][]int32, len(a)+1)
+	for i := range lcs {
+		lcs[i] = make([]int32, len(c)+1)
+	}
+	for i := len(a) - 1; i >= 0; i-- {
+		for j := len(c) - 1; j >= 0; j-- {
+			if a[i] == c[j] {
+				lcs[i][j] = lcs[i+1][j+1] + 1
+			} else if lcs[i+1][j] >= lcs[i][j+1] {
+				lcs[i][j] = lcs[i+1][j]
+			} else {
+				lcs[i][j] = lcs[i][j+1]
+			}
+		}
+	}
+
+	i, j := 0, 0
+	for i < len(a) && j < len(c) {
+		switch {
+		case a[i] == c[j]:
+			b.WriteString(" " + a[i] + "\n")
+			i++
+			j++
+		case lcs[i+1][j] >= lcs[i][j+1]:
+			b.WriteString("-" + a[i] + "\n")
+			i++
+		default:
+			b.WriteString("+" + c[j] + "\n")
+			j++
+		}
+	}
+	for ; i < len(a); i++ {
+		b.WriteString("-" + a[i] + "\n")
+	}
+	for ; j < len(c); j++ {
+		b.WriteString("+" + c[j] + "\n")
+	}
+}
+
+func splitLines(text string) []string {
+	if text == "" {
+		return []string{}
+	}
+	return strings.Split(text, "\n")
+}
@@ -0,0 +1,67 @@
+package services
+
+import (
+	"strings"
+	"testing"
+
+	"synapse/internal/models"
+)
+
+func TestLineDiff(t *testing.T) {
+	tests := []struct {
+		name     string
+		old, new string
+		want     string
+	}{
+		{"added to empty", "", "a\nb", "+a\n+b"},
+		{"removed all", "a\nb", "", "-a\n-b"},
+		{"unchanged", "a\nb", "a\nb", " a\n b"},
+		{"changed line", "a\nb\nc", "a\nx\nc", " a\n-b\n+x\n c"},
+		{"inserted line", "a\nc", "a\nb\nc", " a\n+b\n c"},
+		{"removed line", "a\nb\nc", "a\nc", " a\n-b\n c"},
+		{"moved line", "a\nb\nc", "b\nc\na", "-a\n b\n c\n+a"},
+		{"repeated lines", "x\nx", "x\ny\nx", " x\n+y\n x"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := lineDiff(tt.old, tt.new); got != tt.want {
+				t.Fatalf("lineDiff(%q, %q) =\n%s\nwant\n%s", tt.old, tt.new, got, tt.want)
+			}
+		})
+	}
+}
+
+func TestDiffMiddleLargeInput(t *testing.T) {
+	// Past maxDiffCells the middle is replaced wholesale instead of running the LCS
+	n := 0
+	for n*n <= maxDiffCells {
+		n += 100
+	}
+	a := make([]string, n)
+	c := make([]string, n)
+	for i := range a {
+		a[i] = "old"
+		c[i] = "new"
+	}
+	c[0] = "old"
+
+	var b strings.Builder
+	diffMiddle(&b, a, c)
+	lines := strings.Split(strings.TrimSuffix(b.String(), "\n"), "\n")
+	if len(lines) != 2*n || lines[0] != "-old" || lines[n] != "+old" {
+		t.Fatalf("got %d lines starting %q, want every old line removed then every new line added", len(lines), lines[0])
+	}
+}
+
+func TestRevisionChanges(t *testing.T) {
+	previous := &models.ItemRevision{Title: "A", Content: "x", Tags: []string{"go", "db"}}
+	current := &models.ItemRevision{Title: "B", Content: "x", Tags: []string{"go"}}
+
+	changes := revisionChanges(previous, current)
+	if len(changes) != 2 || changes[0].Field != "title" || changes[1].Field != "tags" {
+		t.Fatalf("changes = %+v, want title and tags", changes)
+	}
+	if changes[1].Diff != " go\n-db" {
+		t.Fatalf("tags diff = %q", changes[1].Diff)
+	}
+}
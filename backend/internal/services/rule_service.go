@@ -0,0 +1,366 @@
+package services
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net/url"
+	"regexp"
+	"strings"
+	"synapse/internal/models"
+	"synapse/internal/repository"
+
+	"github.com/google/uuid"
+	"github.com/jackc/pgx/v5"
+)
+
+var (
+	ErrRuleNotFound = errors.New("rule not found")
+	ErrInvalidRule  = errors.New("invalid rule")
+)
+
+// ruleSteps are the AI steps a rule can skip
+var ruleSteps = []string{models.StepEnrichment, models.StepSummary}
+
+// compiledRule is a rule with its patterns compiled
+type compiledRule struct {
+	rule     models.Rule
+	url      *regexp.Regexp
+	title    *regexp.Regexp
+	content  *regexp.Regexp
+	metadata map[string]*regexp.Regexp
+}
+
+// RuleService manages categorization rules and evaluates them against items before the
+// AI is called
+type RuleService struct {
+	repo              *repository.RuleRepository
+	itemRepo          *repository.ItemRepository
+	categoryService   *CategoryService
+	tagService        *TagService
+	collectionService *CollectionService
+}
+
+func NewRuleService(repo *repository.RuleRepository, itemRepo *repository.ItemRepository, categoryService *CategoryService, tagService *TagService, collectionService *CollectionService) *RuleService {
+	return &RuleService{
+		repo:              repo,
+		itemRepo:          itemRepo,
+		categoryService:   categoryService,
+		tagService:        tagService,
+		collectionService: collectionService,
+	}
+}
+
+func (s *RuleService) List(ctx context.Context) ([]models.Rule, error) {
+	return s.repo.List(ctx)
+}
+
+func (s *RuleService) Get(ctx context.Context, id uuid.UUID) (*models.Rule, error) {
+	rule, err := s.repo.GetByID(ctx, id)
+	if errors.Is(err, pgx.ErrNoRows) {
+		return nil, ErrRuleNotFound
+	}
+	return rule, err
+}
+
+func (s *RuleService) Create(ctx context.Context, req *models.RuleRequest) (*models.Rule, error) {
+	rule := &models.Rule{}
+	if err := s.apply(ctx, rule, req); err != nil {
+		return nil, err
+	}
+	if err := s.repo.Create(ctx, rule); err != nil {
+		return nil, err
+	}
+	return rule, nil
+}
+
+func (s *RuleService) Update(ctx context.Context, id uuid.UUID, req *models.RuleRequest) (*models.Rule, error) {
+	rule, err := s.Get(ctx, id)
+	if err != nil {
+		return nil, err
+	}
+	if err := s.apply(ctx, rule, req); err != nil {
+		return nil, err
+	}
+	if err := s.repo.Update(ctx, rule); err != nil {
+		return nil, err
+	}
+	return rule, nil
+}
+
+func (s *RuleService) Delete(ctx context.Context, id uuid.UUID) error {
+	if _, err := s.Get(ctx, id); err != nil {
+		return err
+	}
+	return s.repo.Delete(ctx, id)
+}
+
+// Evaluate runs the enabled rules against item and combines the actions of those that
+// match. The first matching rule that sets a category decides it; tags and skipped steps
+// add up. Rules are read on every call so edits, and category renames, apply at once.
+func (s *RuleService) Evaluate(ctx context.Context, item *models.Item) *models.RuleMatch {
+	match := &models.RuleMatch{Rules: []string{}, Tags: []string{}, Collections: []uuid.UUID{}, SkipAI: []string{}}
+
+	rules, err := s.repo.List(ctx)
+	if err != nil {
+		fmt.Printf("Warning: Failed to load rules: %v\n", err)
+		return match
+	}
+
+	for _, rule := range rules {
+		if !rule.Enabled {
+			continue
+		}
+		compiled, err := compileRule(rule)
+		if err != nil {
+			fmt.Printf("Warning: Skipping rule %q: %v\n", rule.Name, err)
+			continue
+		}
+		if !compiled.matches(item) {
+			continue
+		}
+
+		match.Rules = append(match.Rules, rule.Name)
+		if match.Category == "" && rule.Actions.Category != "" {
+			match.Category = s.categoryService.Resolve(ctx, rule.Actions.Category)
+		}
+		match.Tags = append(match.Tags, rule.Actions.Tags...)
+		if id := rule.Actions.Collection; id != nil && !containsUUID(match.Collections, *id) {
+			match.Collections = append(match.Collections, *id)
+		}
+		for _, step := range rule.Actions.SkipAI {
+			if !containsString(match.SkipAI, step) {
+				match.SkipAI = append(match.SkipAI, step)
+			}
+		}
+	}
+	match.Tags = s.tagService.Normalize(ctx, match.Tags)
+	return match
+}
+
+// DryRun reports which existing items the rule described by req would affect and how,
+// without saving the rule or changing any item
+func (s *RuleService) DryRun(ctx context.Context, req *models.RuleRequest) (*models.RuleDryRunResult, error) {
+	rule := &models.Rule{}
+	if err := s.apply(ctx, rule, req); err != nil {
+		return nil, err
+	}
+	compiled, err := compileRule(*rule)
+	if err != nil {
+		return nil, err
+	}
+
+	items, err := s.itemRepo.GetAll(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	var members map[uuid.UUID]bool
+	if rule.Actions.Collection != nil {
+		if members, err = s.collectionService.ItemIDs(ctx, *rule.Actions.Collection); err != nil {
+			return nil, err
+		}
+	}
+
+	result := &models.RuleDryRunResult{Items: []models.RuleDryRunItem{}}
+	for i := range items {
+		item := &items[i]
+		if !compiled.matches(item) {
+			continue
+		}
+
+		affected := models.RuleDryRunItem{
+			ID:        item.ID,
+			Title:     item.Title,
+			Category:  item.Category,
+			AddedTags: []string{},
+		}
+		if category := rule.Actions.Category; category != "" && category != item.Category {
+			if item.CategorySource == models.SourceUser || item.CategorySource == models.SourceImport {
+				affected.CategoryLocked = true
+			} else {
+				affected.NewCategory = category
+			}
+		}
+		for _, tag := range rule.Actions.Tags {
+			if !containsString(item.Tags, tag) {
+				affected.AddedTags = append(affected.AddedTags, tag)
+			}
+		}
+		affected.AddedToCollection = members != nil && !members[item.ID]
+		result.Items = append(result.Items, affected)
+	}
+	result.Matched = len(result.Items)
+	return result, nil
+}
+
+// apply validates req and copies it onto rule
+func (s *RuleService) apply(ctx context.Context, rule *models.Rule, req *models.RuleRequest) error {
+	name := strings.TrimSpace(req.Name)
+	if name == "" {
+		return fmt.Errorf("%w: name is required", ErrInvalidRule)
+	}
+
+	conditions := req.Conditions
+	domains := []string{}
+	for _, domain := range conditions.Domains {
+		if domain = normalizeDomain(domain); domain != "" && !containsString(domains, domain) {
+			domains = append(domains, domain)
+		}
+	}
+	conditions.Domains = domains
+	types := []string{}
+	for _, t := range conditions.Types {
+		if t = strings.ToLower(strings.TrimSpace(t)); t != "" && !containsString(types, t) {
+			types = append(types, t)
+		}
+	}
+	conditions.Types = types
+	if len(conditions.Domains) == 0 && len(conditions.Types) == 0 && len(conditions.Metadata) == 0 &&
+		conditions.URLPattern == "" && conditions.TitlePattern == "" && conditions.ContentPattern == "" {
+		return fmt.Errorf("%w: at least one condition is required", ErrInvalidRule)
+	}
+
+	actions := req.Actions
+	if actions.Category != "" {
+		category := s.categoryService.Resolve(ctx, actions.Category)
+		if category == "" {
+			return fmt.Errorf("%w: unknown category %q", ErrInvalidRule, actions.Category)
+		}
+		actions.Category = category
+	}
+	actions.Tags = s.tagService.Normalize(ctx, actions.Tags)
+	if actions.Collection != nil {
+		collection, err := s.collectionService.Get(ctx, *actions.Collection)
+		if err != nil {
+			return fmt.Errorf("%w: collection: %v", ErrInvalidRule, err)
+		}
+		if collection.Filter != nil {
+			return fmt.Errorf("%w: items can't be added to a smart collection", ErrInvalidRule)
+		}
+	}
+	skip := []string{}
+	for _, step := range actions.SkipAI {
+		if !containsString(ruleSteps, step) {
+			return fmt.Errorf("%w: unknown AI step %q (use %s)", ErrInvalidRule, step, strings.Join(ruleSteps, " or "))
+		}
+		if !containsString(skip, step) {
+			skip = append(skip, step)
+		}
+	}
+	actions.SkipAI = skip
+	if actions.Category == "" && len(actions.Tags) == 0 && actions.Collection == nil && len(actions.SkipAI) == 0 {
+		return fmt.Errorf("%w: at least one action is required", ErrInvalidRule)
+	}
+
+	rule.Name = name
+	rule.Enabled = req.Enabled == nil || *req.Enabled
+	rule.Priority = req.Priority
+	rule.Conditions = conditions
+	rule.Actions = actions
+
+	_, err := compileRule(*rule)
+	return err
+}
+
+func compileRule(rule models.Rule) (*compiledRule, error) {
+	compiled := &compiledRule{rule: rule, metadata: map[string]*regexp.Regexp{}}
+
+	var err error
+	if compiled.url, err = compilePattern("url_pattern", rule.Conditions.URLPattern); err != nil {
+		return nil, err
+	}
+	if compiled.title, err = compilePattern("title_pattern", rule.Conditions.TitlePattern); err != nil {
+		return nil, err
+	}
+	if compiled.content, err = compilePattern("content_pattern", rule.Conditions.ContentPattern); err != nil {
+		return nil, err
+	}
+	for field, pattern := range rule.Conditions.Metadata {
+		re, err := compilePattern("metadata."+field, pattern)
+		if err != nil {
+			return nil, err
+		}
+		if re == nil {
+			re = regexp.MustCompile(".")
+		}
+		compiled.metadata[field] = re
+	}
+	return compiled, nil
+}
+
+func compilePattern(field, pattern string) (*regexp.Regexp, error) {
+	if pattern == "" {
+		return nil, nil
+	}
+	re, err := regexp.Compile(pattern)
+	if err != nil {
+		return nil, fmt.Errorf("%w: %s: %v", ErrInvalidRule, field, err)
+	}
+	return re, nil
+}
+
+// matches reports whether every condition of the rule holds for item
+func (c *compiledRule) matches(item *models.Item) bool {
+	conditions := c.rule.Conditions
+
+	if len(conditions.Domains) > 0 {
+		host := urlHost(item.SourceURL)
+		found := false
+		for _, domain := range conditions.Domains {
+			if host == domain || strings.HasSuffix(host, "."+domain) {
+				found = true
+				break
+			}
+		}
+		if !found {
+			return false
+		}
+	}
+	if len(conditions.Types) > 0 && !containsString(conditions.Types, strings.ToLower(item.Type)) {
+		return false
+	}
+	if c.url != nil && !c.url.MatchString(item.SourceURL) {
+		return false
+	}
+	if c.title != nil && !c.title.MatchString(item.Title) {
+		return false
+	}
+	if c.content != nil && !c.content.MatchString(itemText(item)) {
+		return false
+	}
+	for field, re := range c.metadata {
+		if !re.MatchString(item.Metadata[field]) {
+			return false
+		}
+	}
+	return true
+}
+
+func containsUUID(ids []uuid.UUID, id uuid.UUID) bool {
+	for _, v := range ids {
+		if v == id {
+			return true
+		}
+	}
+	return false
+}
+
+// normalizeDomain turns "https://www.Example.com/path" or "example.com" into "example.com"
+func normalizeDomain(domain string) string {
+	domain = strings.ToLower(strings.TrimSpace(domain))
+	if strings.Contains(domain, "://") {
+		domain = urlHost(domain)
+	}
+	domain = strings.TrimSuffix(strings.SplitN(domain, "/", 2)[0], ".")
+	return strings.TrimPrefix(domain, "www.")
+}
+
+// urlHost returns the lowercase host of rawURL without a www. prefix, or "" if it has none
+func urlHost(rawURL string) string {
+	u, err := url.Parse(strings.TrimSpace(rawURL))
+	if err != nil {
+		return ""
+	}
+	return strings.TrimPrefix(strings.ToLower(u.Hostname()), "www.")
+}
@@ -3,7 +3,9 @@ package services
 import (
 	"context"
 	"fmt"
+	"math"
 	"regexp"
+	"sort"
 	"strings"
 	"synapse/internal/db"
 	"synapse/internal/models"
@@ -12,25 +14,61 @@ import (
 	"github.com/google/uuid"
 )
 
+// snippetLength is the length in runes of the matching chunk text shown with a result
+const snippetLength = 240
+
 type SearchService struct {
-	aiService      *AIService
-	itemRepo       *repository.ItemRepository
-	collectionName string
+	aiService         *AIService
+	itemRepo          *repository.ItemRepository
+	chunkRepo         *repository.ChunkRepository
+	categoryService   *CategoryService
+	tagService        *TagService
+	collectionService *CollectionService
+	imageEmbeddings   *ImageEmbeddingService
+	collectionName    string
+	chunkCollection   string
 }
 
-func NewSearchService(aiService *AIService, itemRepo *repository.ItemRepository) *SearchService {
+func NewSearchService(aiService *AIService, itemRepo *repository.ItemRepository, chunkRepo *repository.ChunkRepository, categoryService *CategoryService, tagService *TagService, collectionService *CollectionService, imageEmbeddings *ImageEmbeddingService) *SearchService {
 	return &SearchService{
-		aiService:      aiService,
-		itemRepo:       itemRepo,
-		collectionName: "synapse_items",
+		aiService:         aiService,
+		itemRepo:          itemRepo,
+		chunkRepo:         chunkRepo,
+		categoryService:   categoryService,
+		tagService:        tagService,
+		collectionService: collectionService,
+		imageEmbeddings:   imageEmbeddings,
+		collectionName:    "synapse_items",
+		chunkCollection:   "synapse_chunks",
 	}
 }
 
 // Search performs hybrid search: semantic (ChromaDB) + text (PostgreSQL) with natural language parsing
-// Enhanced with Claude AI for query understanding and result re-ranking
-func (s *SearchService) Search(ctx context.Context, query string, limit int) ([]models.SearchResult, error) {
+// Enhanced with Claude AI for query understanding and result re-ranking.
+// A non-nil collectionID limits results to the items of that collection, and the flags
+// set in state override the ones recognized in the query.
+func (s *SearchService) Search(ctx context.Context, query string, limit int, collectionID *uuid.UUID, state models.ItemStateFilter) ([]models.SearchResult, error) {
 	// Parse natural language query
-	filters := ParseNaturalLanguageQuery(query)
+	filters := ParseNaturalLanguageQuery(query, s.categoryService.Categories(ctx))
+	// #tag filters match the tag's synonyms too
+	filters.Tags = s.tagService.Expand(ctx, filters.Tags)
+	mergeState(&filters.ItemStateFilter, state)
+
+	// Semantic search can't filter by collection, so fetch more candidates and drop the others
+	semanticLimit := limit * 2
+	var members map[uuid.UUID]bool
+	if collectionID != nil {
+		ids, err := s.collectionService.ItemIDs(ctx, *collectionID)
+		if err != nil {
+			return nil, err
+		}
+		members = ids
+		filters.ItemIDs = make([]uuid.UUID, 0, len(ids))
+		for id := range ids {
+			filters.ItemIDs = append(filters.ItemIDs, id)
+		}
+		semanticLimit = limit * 10
+	}
 
 	// Use Claude to enhance the search query - this converts plain English to searchable terms
 	// This is critical for finding content even when exact words don't match
@@ -49,8 +87,24 @@ func (s *SearchService) Search(ctx context.Context, query string, limit int) ([]
 		filters.SearchTerms = enhancedQuery
 	}
 
+	// A query that only asks for a state ("my favorites") has no search terms left
+	if enhancedQuery == "" {
+		enhancedQuery = query
+	}
+
 	// Try semantic search first (if ChromaDB is available)
-	semanticResults, semanticErr := s.semanticSearch(ctx, enhancedQuery, limit*2)
+	semanticResults, semanticErr := s.semanticSearch(ctx, enhancedQuery, semanticLimit)
+	// Images are matched against the query as the user wrote it; CLIP reads whole phrases
+	if imageResults, err := s.imageSearch(ctx, query, semanticLimit); err != nil {
+		fmt.Printf("Warning: Image search failed: %v\n", err)
+	} else if len(imageResults) > 0 {
+		semanticResults = mergeImageResults(semanticResults, imageResults)
+		semanticErr = nil
+	}
+	if members != nil {
+		semanticResults = inCollection(semanticResults, members)
+	}
+	semanticResults = inState(semanticResults, filters.ItemStateFilter)
 	
 	// Always do text search as fallback/combination (includes OCR text)
 	textResults, textErr := s.itemRepo.SearchItems(ctx, filters, limit*2)
@@ -62,6 +116,7 @@ func (s *SearchService) Search(ctx context.Context, query string, limit int) ([]
 
 	// Combine results
 	results := s.combineResults(semanticResults, textResults, limit*2) // Get more results for re-ranking
+	s.attachMatchingPages(ctx, results, filters.SearchTerms)
 
 	// For quote searches, boost items that contain the exact phrase
 	results = s.boostExactMatches(results, filters.SearchTerms)
@@ -77,6 +132,10 @@ func (s *SearchService) Search(ctx context.Context, query string, limit int) ([]
 		}
 	}
 
+	// Favorites rank above equally relevant items. This runs on the final order so the
+	// re-ranker can't undo it.
+	results = boostFavorites(results)
+
 	// Limit to requested number
 	if len(results) > limit {
 		results = results[:limit]
@@ -113,7 +172,7 @@ func (s *SearchService) boostExactMatches(results []models.SearchResult, searchT
 	
 	for i := range results {
 		item := results[i].Item
-		searchableText := strings.ToLower(item.Title + " " + item.Content + " " + item.Summary + " " + item.OcrText)
+		searchableText := strings.ToLower(item.Title + " " + itemText(&item) + " " + item.Summary + " " + item.OcrText + " " + item.ImageCaption)
 		
 		// Boost if exact phrase found
 		if strings.Contains(searchableText, lowerSearch) {
@@ -208,9 +267,160 @@ func (s *SearchService) semanticSearch(ctx context.Context, query string, limit
 		})
 	}
 
+	chunkResults, err := s.chunkSearch(ctx, queryEmbedding, limit)
+	if err != nil {
+		fmt.Printf("Warning: Chunk search failed: %v\n", err)
+		return results, nil
+	}
+	return mergeChunkResults(results, chunkResults), nil
+}
+
+// chunkSearch finds the chunks of long items nearest to the query, returning one result
+// per item for its best chunk, with the chunk's page and text
+func (s *SearchService) chunkSearch(ctx context.Context, queryEmbedding []float32, limit int) ([]models.SearchResult, error) {
+	ids, distances, err := db.Chroma.Query(s.chunkCollection, queryEmbedding, limit)
+	if err != nil || len(ids) == 0 {
+		return nil, err
+	}
+
+	chunks, err := s.chunkRepo.GetByEmbeddingIDs(ctx, ids)
+	if err != nil {
+		return nil, err
+	}
+	chunkMap := make(map[string]models.ItemChunk, len(chunks))
+	var itemIDs []uuid.UUID
+	for _, chunk := range chunks {
+		chunkMap[chunk.EmbeddingID] = chunk
+		itemIDs = append(itemIDs, chunk.ItemID)
+	}
+	items, err := s.itemRepo.GetByIDs(ctx, itemIDs)
+	if err != nil {
+		return nil, err
+	}
+	itemMap := make(map[uuid.UUID]models.Item, len(items))
+	for _, item := range items {
+		itemMap[item.ID] = item
+	}
+
+	// Chroma returns the nearest chunks first, so an item's first chunk is its best
+	var results []models.SearchResult
+	seen := make(map[uuid.UUID]bool)
+	for i, id := range ids {
+		chunk, ok := chunkMap[id]
+		if !ok || seen[chunk.ItemID] {
+			continue
+		}
+		item, ok := itemMap[chunk.ItemID]
+		if !ok {
+			continue
+		}
+		seen[chunk.ItemID] = true
+		results = append(results, models.SearchResult{
+			Item:            item,
+			SimilarityScore: math.Max(0, 1.0-distances[i]),
+			Page:            chunk.Page,
+			Snippet:         truncateRunes(chunk.Content, snippetLength),
+		})
+	}
+	return results, nil
+}
+
+// imageSearch finds the items whose images look like the query, when image embeddings
+// are enabled
+func (s *SearchService) imageSearch(ctx context.Context, query string, limit int) ([]models.SearchResult, error) {
+	itemIDs, scores, err := s.imageEmbeddings.Search(ctx, query, limit)
+	if err != nil || len(itemIDs) == 0 {
+		return nil, err
+	}
+	items, err := s.itemRepo.GetByIDs(ctx, itemIDs)
+	if err != nil {
+		return nil, err
+	}
+	itemMap := make(map[uuid.UUID]models.Item, len(items))
+	for _, item := range items {
+		itemMap[item.ID] = item
+	}
+
+	var results []models.SearchResult
+	for i, itemID := range itemIDs {
+		if item, ok := itemMap[itemID]; ok {
+			results = append(results, models.SearchResult{Item: item, SimilarityScore: scores[i]})
+		}
+	}
 	return results, nil
 }
 
+// mergeImageResults adds image matches to semantic matches; an item found both ways
+// keeps the better score
+func mergeImageResults(results, imageResults []models.SearchResult) []models.SearchResult {
+	index := make(map[uuid.UUID]int, len(results))
+	for i, result := range results {
+		index[result.Item.ID] = i
+	}
+	for _, imageResult := range imageResults {
+		if i, ok := index[imageResult.Item.ID]; ok {
+			results[i].SimilarityScore = math.Max(results[i].SimilarityScore, imageResult.SimilarityScore)
+			continue
+		}
+		index[imageResult.Item.ID] = len(results)
+		results = append(results, imageResult)
+	}
+	return results
+}
+
+// mergeChunkResults adds chunk matches to item matches. An item found both ways keeps
+// the better score and takes the page and snippet of its chunk.
+func mergeChunkResults(results, chunkResults []models.SearchResult) []models.SearchResult {
+	index := make(map[uuid.UUID]int, len(results))
+	for i, result := range results {
+		index[result.Item.ID] = i
+	}
+	for _, chunkResult := range chunkResults {
+		i, ok := index[chunkResult.Item.ID]
+		if !ok {
+			index[chunkResult.Item.ID] = len(results)
+			results = append(results, chunkResult)
+			continue
+		}
+		results[i].SimilarityScore = math.Max(results[i].SimilarityScore, chunkResult.SimilarityScore)
+		results[i].Page = chunkResult.Page
+		results[i].Snippet = chunkResult.Snippet
+	}
+	return results
+}
+
+// attachMatchingPages sets the page and snippet of results found by text search from
+// their first chunk containing a search term. Items without chunks are left as they are.
+func (s *SearchService) attachMatchingPages(ctx context.Context, results []models.SearchResult, searchTerms string) {
+	var itemIDs []uuid.UUID
+	for _, result := range results {
+		if result.Page == 0 {
+			itemIDs = append(itemIDs, result.Item.ID)
+		}
+	}
+	var patterns []string
+	for _, term := range strings.Fields(searchTerms) {
+		if len(term) >= 2 {
+			patterns = append(patterns, "%"+term+"%")
+		}
+	}
+	if len(itemIDs) == 0 || len(patterns) == 0 {
+		return
+	}
+
+	matches, err := s.chunkRepo.FirstMatches(ctx, itemIDs, patterns)
+	if err != nil {
+		fmt.Printf("Warning: Failed to find matching pages: %v\n", err)
+		return
+	}
+	for i := range results {
+		if chunk, ok := matches[results[i].Item.ID]; ok && results[i].Page == 0 {
+			results[i].Page = chunk.Page
+			results[i].Snippet = truncateRunes(chunk.Content, snippetLength)
+		}
+	}
+}
+
 func (s *SearchService) combineResults(semanticResults []models.SearchResult, textResults []models.Item, limit int) []models.SearchResult {
 	// Create a map to deduplicate and combine scores
 	resultMap := make(map[uuid.UUID]models.SearchResult)
@@ -287,6 +497,69 @@ func (s *SearchService) applyPostFilters(results []models.SearchResult, filters
 	return filtered
 }
 
+// inCollection keeps the results whose item is in members
+func inCollection(results []models.SearchResult, members map[uuid.UUID]bool) []models.SearchResult {
+	filtered := []models.SearchResult{}
+	for _, result := range results {
+		if members[result.Item.ID] {
+			filtered = append(filtered, result)
+		}
+	}
+	return filtered
+}
+
+// favoriteRank is how many places a favorite item moves up the ranked results
+const favoriteRank = 2
+
+// boostFavorites moves each favorite item up favoriteRank places. It works on positions
+// rather than scores because the re-ranked order no longer follows the scores.
+func boostFavorites(results []models.SearchResult) []models.SearchResult {
+	position := make(map[uuid.UUID]float64, len(results))
+	for i, result := range results {
+		position[result.Item.ID] = float64(i)
+		if result.Item.Favorite {
+			// The half place puts the favorite ahead of the item it lands on
+			position[result.Item.ID] -= favoriteRank + 0.5
+		}
+	}
+	sort.SliceStable(results, func(i, j int) bool {
+		return position[results[i].Item.ID] < position[results[j].Item.ID]
+	})
+	return results
+}
+
+// mergeState copies the flags set in override into state
+func mergeState(state *models.ItemStateFilter, override models.ItemStateFilter) {
+	if override.Favorite != nil {
+		state.Favorite = override.Favorite
+	}
+	if override.Pinned != nil {
+		state.Pinned = override.Pinned
+	}
+	if override.Read != nil {
+		state.Read = override.Read
+	}
+	if override.Archived != nil {
+		state.Archived = override.Archived
+	}
+}
+
+// inState keeps the results whose item matches state; semantic search can't filter them
+func inState(results []models.SearchResult, state models.ItemStateFilter) []models.SearchResult {
+	matches := func(filter *bool, value bool) bool {
+		return filter == nil || *filter == value
+	}
+	filtered := []models.SearchResult{}
+	for _, result := range results {
+		item := result.Item
+		if matches(state.Favorite, item.Favorite) && matches(state.Pinned, item.Pinned) &&
+			matches(state.Read, item.ReadAt != nil) && matches(state.Archived, item.Archived) {
+			filtered = append(filtered, result)
+		}
+	}
+	return filtered
+}
+
 func extractPriceFromContent(content string) float64 {
 	// Try to extract price from content (e.g., "Price: $299.99")
 	priceRe := regexp.MustCompile(`(?i)price[:\s]+\$?(\d+(?:\.\d+)?)`)
@@ -0,0 +1,48 @@
+package services
+
+import (
+	"testing"
+
+	"github.com/google/uuid"
+
+	"synapse/internal/models"
+)
+
+func TestBoostFavorites(t *testing.T) {
+	tests := []struct {
+		name      string
+		favorites []int // Positions of favorite items in the ranked input
+		want      []int // Input positions in output order
+	}{
+		{"none", nil, []int{0, 1, 2, 3, 4}},
+		{"moves up two places", []int{3}, []int{0, 3, 1, 2, 4}},
+		{"stops at the top", []int{1}, []int{1, 0, 2, 3, 4}},
+		{"keeps favorites in order", []int{2, 3}, []int{2, 0, 3, 1, 4}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			results := make([]models.SearchResult, 5)
+			index := map[uuid.UUID]int{}
+			for i := range results {
+				results[i].Item.ID = uuid.New()
+				// Scores rise down the list, as after a re-rank that disagrees with them
+				results[i].SimilarityScore = float64(i) / 10
+				index[results[i].Item.ID] = i
+			}
+			for _, i := range tt.favorites {
+				results[i].Item.Favorite = true
+			}
+
+			got := boostFavorites(results)
+			for i, result := range got {
+				if index[result.Item.ID] != tt.want[i] {
+					order := make([]int, len(got))
+					for j, r := range got {
+						order[j] = index[r.Item.ID]
+					}
+					t.Fatalf("order = %v, want %v", order, tt.want)
+				}
+			}
+		})
+	}
+}
@@ -0,0 +1,216 @@
+package services
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"sort"
+	"strings"
+	"synapse/internal/models"
+	"synapse/internal/repository"
+	"sync"
+)
+
+var (
+	ErrTagNotFound = errors.New("tag not found")
+	ErrInvalidTag  = errors.New("invalid tag")
+)
+
+// tagHintLimit is how many of the most used tags are suggested to the AI
+const tagHintLimit = 50
+
+// NormalizeTag returns the stored form of a tag: lowercase, without a leading #, and with
+// runs of whitespace replaced by a hyphen ("Machine Learning" becomes "machine-learning").
+// Migration 7 in db.CreateSchema applies the same rules to existing tags.
+func NormalizeTag(tag string) string {
+	tag = strings.Trim(strings.ToLower(tag), " \t\n#")
+	return strings.Join(strings.Fields(tag), "-")
+}
+
+// TagService normalizes tags on write, resolves synonyms and rewrites tags across items
+type TagService struct {
+	repo *repository.TagRepository
+
+	mu       sync.RWMutex
+	synonyms map[string]string // synonym -> canonical tag
+}
+
+func NewTagService(repo *repository.TagRepository) *TagService {
+	return &TagService{repo: repo}
+}
+
+// Normalize normalizes tags, maps synonyms to their canonical tag and drops duplicates
+func (s *TagService) Normalize(ctx context.Context, tags []string) []string {
+	synonyms := s.synonymMap(ctx)
+
+	normalized := []string{}
+	for _, tag := range tags {
+		tag = NormalizeTag(tag)
+		if canonical, ok := synonyms[tag]; ok {
+			tag = canonical
+		}
+		if tag != "" && !containsString(normalized, tag) {
+			normalized = append(normalized, tag)
+		}
+	}
+	return normalized
+}
+
+// Expand returns tags together with all of their synonyms, for #tag search filters
+func (s *TagService) Expand(ctx context.Context, tags []string) []string {
+	if len(tags) == 0 {
+		return tags
+	}
+	synonyms := s.synonymMap(ctx)
+
+	canonical := s.Normalize(ctx, tags)
+	expanded := append([]string{}, canonical...)
+	for synonym, tag := range synonyms {
+		if containsString(canonical, tag) && !containsString(expanded, synonym) {
+			expanded = append(expanded, synonym)
+		}
+	}
+	return expanded
+}
+
+// List returns every tag in use with its count and synonyms
+func (s *TagService) List(ctx context.Context) ([]models.Tag, error) {
+	tags, err := s.repo.List(ctx, 0)
+	if err != nil {
+		return nil, err
+	}
+
+	groups := map[string][]string{}
+	for synonym, tag := range s.synonymMap(ctx) {
+		groups[tag] = append(groups[tag], synonym)
+	}
+	for i := range tags {
+		if group, ok := groups[tags[i].Name]; ok {
+			sort.Strings(group)
+			tags[i].Synonyms = group
+		}
+	}
+	return tags, nil
+}
+
+// Hints returns the most used tags, passed to the AI so it reuses existing vocabulary
+func (s *TagService) Hints(ctx context.Context) []string {
+	tags, err := s.repo.List(ctx, tagHintLimit)
+	if err != nil {
+		fmt.Printf("Warning: Failed to load tag hints: %v\n", err)
+		return nil
+	}
+
+	hints := make([]string, len(tags))
+	for i, tag := range tags {
+		hints[i] = tag.Name
+	}
+	return hints
+}
+
+// Rename renames a tag on every item. Renaming to a tag that is already in use fails;
+// use Merge for that.
+func (s *TagService) Rename(ctx context.Context, from, to string) (int64, error) {
+	from, to, err := s.validatePair(ctx, from, to)
+	if err != nil {
+		return 0, err
+	}
+	if _, isSynonym := s.synonymMap(ctx)[to]; isSynonym || s.inUse(ctx, to) {
+		return 0, fmt.Errorf("%w: %q is already in use, merge into it instead", ErrInvalidTag, to)
+	}
+
+	changed, err := s.repo.Rewrite(ctx, from, to, false)
+	s.invalidate()
+	return changed, err
+}
+
+// Merge replaces tag from with into on every item and records from as a synonym of into
+func (s *TagService) Merge(ctx context.Context, from, into string) (int64, error) {
+	from, into, err := s.validatePair(ctx, from, into)
+	if err != nil {
+		return 0, err
+	}
+	if canonical, ok := s.synonymMap(ctx)[into]; ok {
+		if canonical == from {
+			return 0, fmt.Errorf("%w: %q is already a synonym of %q", ErrInvalidTag, into, from)
+		}
+		into = canonical
+	}
+
+	changed, err := s.repo.Rewrite(ctx, from, into, true)
+	s.invalidate()
+	return changed, err
+}
+
+// SetSynonyms replaces the synonym group of tag and rewrites items tagged with a synonym
+func (s *TagService) SetSynonyms(ctx context.Context, tag string, synonyms []string) (*models.Tag, error) {
+	tag = NormalizeTag(tag)
+	if tag == "" {
+		return nil, fmt.Errorf("%w: tag is required", ErrInvalidTag)
+	}
+	current := s.synonymMap(ctx)
+	if canonical, ok := current[tag]; ok {
+		return nil, fmt.Errorf("%w: %q is a synonym of %q", ErrInvalidTag, tag, canonical)
+	}
+
+	group := []string{}
+	for _, synonym := range synonyms {
+		synonym = NormalizeTag(synonym)
+		if synonym != "" && synonym != tag && !containsString(group, synonym) {
+			group = append(group, synonym)
+		}
+	}
+
+	if err := s.repo.SetSynonyms(ctx, tag, group); err != nil {
+		return nil, err
+	}
+	s.invalidate()
+
+	sort.Strings(group)
+	return &models.Tag{Name: tag, Synonyms: group}, nil
+}
+
+func (s *TagService) validatePair(ctx context.Context, from, to string) (string, string, error) {
+	from, to = NormalizeTag(from), NormalizeTag(to)
+	if from == "" || to == "" {
+		return "", "", fmt.Errorf("%w: tag names are required", ErrInvalidTag)
+	}
+	if from == to {
+		return "", "", fmt.Errorf("%w: source and target are the same tag", ErrInvalidTag)
+	}
+	if !s.inUse(ctx, from) {
+		return "", "", ErrTagNotFound
+	}
+	return from, to, nil
+}
+
+func (s *TagService) inUse(ctx context.Context, tag string) bool {
+	count, err := s.repo.Count(ctx, tag)
+	return err == nil && count > 0
+}
+
+func (s *TagService) synonymMap(ctx context.Context) map[string]string {
+	s.mu.RLock()
+	synonyms := s.synonyms
+	s.mu.RUnlock()
+	if synonyms != nil {
+		return synonyms
+	}
+
+	synonyms, err := s.repo.Synonyms(ctx)
+	if err != nil {
+		fmt.Printf("Warning: Failed to load tag synonyms: %v\n", err)
+		return map[string]string{}
+	}
+
+	s.mu.Lock()
+	s.synonyms = synonyms
+	s.mu.Unlock()
+	return synonyms
+}
+
+func (s *TagService) invalidate() {
+	s.mu.Lock()
+	s.synonyms = nil
+	s.mu.Unlock()
+}
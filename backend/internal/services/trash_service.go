@@ -0,0 +1,138 @@
+package services
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"synapse/internal/db"
+	"synapse/internal/models"
+	"synapse/internal/repository"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/jackc/pgx/v5"
+)
+
+// TrashService lists, restores and purges soft-deleted items. Items are purged for good
+// once they have been in the trash longer than the retention period.
+type TrashService struct {
+	itemRepo        *repository.ItemRepository
+	fileService     *FileService
+	retention       time.Duration
+	purgeInterval   time.Duration
+	collectionName  string
+	chunkCollection string
+	imageCollection string
+}
+
+func NewTrashService(itemRepo *repository.ItemRepository, fileService *FileService) *TrashService {
+	return &TrashService{
+		itemRepo:        itemRepo,
+		fileService:     fileService,
+		retention:       time.Duration(envCount("TRASH_RETENTION_DAYS", 30)) * 24 * time.Hour,
+		purgeInterval:   envDuration("TRASH_PURGE_INTERVAL", time.Hour),
+		collectionName:  "synapse_items",
+		chunkCollection: "synapse_chunks",
+		imageCollection: "synapse_images",
+	}
+}
+
+// List returns the items in the trash, most recently deleted first
+func (s *TrashService) List(ctx context.Context) ([]models.Item, error) {
+	return s.itemRepo.GetTrash(ctx)
+}
+
+// Restore takes an item out of the trash
+func (s *TrashService) Restore(ctx context.Context, id uuid.UUID) error {
+	err := s.itemRepo.Restore(ctx, id)
+	if errors.Is(err, pgx.ErrNoRows) {
+		return ErrItemNotFound
+	}
+	return err
+}
+
+// Purge permanently deletes one trashed item
+func (s *TrashService) Purge(ctx context.Context, id uuid.UUID) error {
+	purged, err := s.itemRepo.PurgeItem(ctx, id)
+	if err != nil {
+		return err
+	}
+	if len(purged) == 0 {
+		return ErrItemNotFound
+	}
+	s.cleanup(purged)
+	return nil
+}
+
+// PurgeExpired permanently deletes the items that have been in the trash longer than
+// the retention period, or every trashed item when all is set. It returns how many
+// items were purged.
+func (s *TrashService) PurgeExpired(ctx context.Context, all bool) (int, error) {
+	cutoff := time.Now().Add(-s.retention)
+	if all {
+		cutoff = time.Now()
+	}
+
+	purged, err := s.itemRepo.PurgeDeletedBefore(ctx, cutoff)
+	if err != nil {
+		return 0, err
+	}
+	s.cleanup(purged)
+	return len(purged), nil
+}
+
+// Run purges expired items every purge interval until ctx is done. A retention of zero
+// days keeps trashed items until they are purged by hand.
+func (s *TrashService) Run(ctx context.Context) {
+	if s.retention <= 0 || s.purgeInterval <= 0 {
+		return
+	}
+
+	ticker := time.NewTicker(s.purgeInterval)
+	defer ticker.Stop()
+	for {
+		if n, err := s.PurgeExpired(ctx, false); err != nil {
+			fmt.Printf("Warning: Failed to purge trash: %v\n", err)
+		} else if n > 0 {
+			fmt.Printf("Purged %d items from the trash\n", n)
+		}
+
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+		}
+	}
+}
+
+// cleanup removes what purged items leave outside the database. Stored files are
+// shared by content, so they are removed once no item uses them; the sweep also
+// catches files left behind by failed saves.
+func (s *TrashService) cleanup(items []models.Item) {
+	if _, err := s.fileService.DeleteUnreferenced(context.Background()); err != nil {
+		fmt.Printf("Warning: Failed to delete unused files: %v\n", err)
+	}
+	if len(items) == 0 {
+		return
+	}
+	ids := make([]string, 0, len(items))
+	itemIDs := make([]string, 0, len(items))
+	for _, item := range items {
+		if item.EmbeddingID != "" {
+			ids = append(ids, item.EmbeddingID)
+		}
+		itemIDs = append(itemIDs, item.ID.String())
+	}
+	if err := db.Chroma.DeleteEmbeddings(s.collectionName, ids); err != nil {
+		fmt.Printf("Warning: Failed to delete embeddings of purged items: %v\n", err)
+	}
+	// Chunk rows go with their items; their vectors are found by item id
+	where := map[string]interface{}{"item_id": map[string]interface{}{"$in": itemIDs}}
+	if err := db.Chroma.DeleteWhere(s.chunkCollection, where); err != nil {
+		fmt.Printf("Warning: Failed to delete chunk embeddings of purged items: %v\n", err)
+	}
+	// Image embeddings are stored under their item's id
+	if err := db.Chroma.DeleteEmbeddings(s.imageCollection, itemIDs); err != nil {
+		fmt.Printf("Warning: Failed to delete image embeddings of purged items: %v\n", err)
+	}
+}
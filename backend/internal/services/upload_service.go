@@ -0,0 +1,219 @@
+package services
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net/http"
+	"path/filepath"
+	"strings"
+	"synapse/internal/models"
+	"unicode/utf8"
+
+	"golang.org/x/net/html/charset"
+)
+
+var (
+	ErrUnsupportedUpload = errors.New("unsupported file type")
+	ErrUploadTooLarge    = errors.New("file too large")
+	ErrInvalidUpload     = errors.New("invalid upload")
+)
+
+// Kinds of uploaded files
+const (
+	uploadImage    = "image"
+	uploadPDF      = "pdf"
+	uploadText     = "text"
+	uploadMarkdown = "markdown"
+	uploadDOCX     = "docx"
+	uploadEPUB     = "epub"
+)
+
+// uploadImageTypes are the image types accepted, as sniffed from the file
+var uploadImageTypes = []string{"image/png", "image/jpeg", "image/gif", "image/webp"}
+
+// UploadService creates items from uploaded files. The file type is sniffed from its
+// bytes rather than trusted from the client, and each type goes through its own
+// extraction: OCR for images, page-aware text for PDFs and Markdown for documents.
+// The original file is kept in the blob store and attached to the item.
+type UploadService struct {
+	itemService  *ItemService
+	fileService  *FileService
+	maxSize      int64
+	maxImageSize int64
+}
+
+func NewUploadService(itemService *ItemService, fileService *FileService) *UploadService {
+	return &UploadService{
+		itemService:  itemService,
+		fileService:  fileService,
+		maxSize:      int64(envInt("UPLOAD_MAX_MB", 25)) << 20,
+		maxImageSize: int64(envInt("UPLOAD_MAX_IMAGE_MB", 10)) << 20,
+	}
+}
+
+// MaxSize is the largest file accepted
+func (s *UploadService) MaxSize() int64 {
+	return s.maxSize
+}
+
+// Upload creates an item from a file. title and note are optional: the title defaults
+// to the document's own title or the file name, and the note is saved with the item.
+// languages are hints for OCR of images.
+func (s *UploadService) Upload(ctx context.Context, filename string, data []byte, title, note string, languages []string) (*models.Item, error) {
+	if len(data) == 0 {
+		return nil, fmt.Errorf("%w: empty file", ErrInvalidUpload)
+	}
+	if int64(len(data)) > s.maxSize {
+		return nil, fmt.Errorf("%w: limit is %d MB", ErrUploadTooLarge, s.maxSize>>20)
+	}
+
+	kind, mimeType := sniffUpload(filename, data)
+	if kind == "" {
+		return nil, fmt.Errorf("%w: %s", ErrUnsupportedUpload, mimeType)
+	}
+
+	req := &models.CreateItemRequest{
+		Title:    strings.TrimSpace(title),
+		Metadata: map[string]string{"filename": filepath.Base(filename), "mime_type": mimeType},
+	}
+	source := &sourceDocument{}
+
+	switch kind {
+	case uploadImage:
+		if int64(len(data)) > s.maxImageSize {
+			return nil, fmt.Errorf("%w: images are limited to %d MB", ErrUploadTooLarge, s.maxImageSize>>20)
+		}
+		req.Type = "image"
+		req.Content = note
+		req.OCRLanguages = languages
+		source.image = data
+		source.imageType = mimeType
+	case uploadPDF:
+		doc, err := ExtractPDF(data)
+		if err != nil {
+			return nil, fmt.Errorf("%w: %v", ErrInvalidUpload, err)
+		}
+		req.Type = "document"
+		req.Content = joinNote(note, pdfText(doc))
+		if req.Title == "" {
+			req.Title = firstNonEmpty(doc.Title, fileTitle(filename))
+		}
+		if doc.Author != "" {
+			req.Metadata["author"] = doc.Author
+		}
+		req.Metadata["page_count"] = fmt.Sprint(doc.PageCount)
+		source.pdf = doc
+	case uploadDOCX, uploadEPUB:
+		extract, itemType := ExtractDOCX, "document"
+		if kind == uploadEPUB {
+			extract, itemType = ExtractEPUB, "book"
+		}
+		doc, err := extract(data)
+		if err != nil {
+			return nil, fmt.Errorf("%w: %v", ErrInvalidUpload, err)
+		}
+		req.Type = itemType
+		req.Format = models.FormatMarkdown
+		req.Content = joinNote(markdownEscaper.Replace(note), doc.content)
+		if req.Title == "" {
+			req.Title = firstNonEmpty(doc.title, fileTitle(filename))
+		}
+		if doc.author != "" {
+			req.Metadata["author"] = doc.author
+		}
+		if doc.language != "" {
+			req.Metadata["language"] = doc.language
+		}
+	case uploadText, uploadMarkdown:
+		req.Type = "text"
+		req.Content = joinNote(note, decodeText(data))
+		if kind == uploadMarkdown {
+			req.Format = models.FormatMarkdown
+		}
+	}
+	if req.Title == "" {
+		req.Title = fileTitle(filename)
+	}
+
+	store := s.fileService.Store
+	if kind == uploadImage {
+		// Uploaded photos are kept without their EXIF data, GPS position included
+		store = s.fileService.StoreImage
+	}
+	original, err := store(ctx, data, mimeType)
+	if err != nil {
+		return nil, err
+	}
+	if kind == uploadImage {
+		req.ImageURL = s.fileService.URL(original.Hash)
+	}
+
+	item, err := s.itemService.createItem(ctx, req, source)
+	if err != nil {
+		return nil, err
+	}
+	if err := s.fileService.Attach(ctx, item.ID, models.FileRoleOriginal, original.Hash, filepath.Base(filename)); err != nil {
+		fmt.Printf("Warning: Failed to attach original file to item %s: %v\n", item.ID, err)
+	}
+	return item, nil
+}
+
+// sniffUpload returns the kind and MIME type of a file, or an empty kind if it isn't
+// accepted. Zip-based formats are told apart by their contents, and plain text by the
+// file extension.
+func sniffUpload(filename string, data []byte) (string, string) {
+	mimeType, _, _ := strings.Cut(http.DetectContentType(data), ";")
+	ext := strings.ToLower(filepath.Ext(filename))
+
+	switch {
+	case containsString(uploadImageTypes, mimeType):
+		return uploadImage, mimeType
+	case mimeType == "application/pdf":
+		return uploadPDF, mimeType
+	case mimeType == "application/zip" && zipHasFile(data, "word/document.xml"):
+		return uploadDOCX, "application/vnd.openxmlformats-officedocument.wordprocessingml.document"
+	case mimeType == "application/zip" && zipHasFile(data, "META-INF/container.xml"):
+		return uploadEPUB, "application/epub+zip"
+	case mimeType == "text/plain" && (ext == ".md" || ext == ".markdown"):
+		return uploadMarkdown, "text/markdown"
+	case mimeType == "text/plain" && (ext == ".txt" || ext == ""):
+		return uploadText, mimeType
+	}
+	return "", mimeType
+}
+
+// decodeText converts text in another encoding to UTF-8
+func decodeText(data []byte) string {
+	if utf8.Valid(data) {
+		return strings.TrimPrefix(string(data), "\ufeff")
+	}
+	encoding, _, _ := charset.DetermineEncoding(data, "text/plain")
+	decoded, err := encoding.NewDecoder().Bytes(data)
+	if err != nil {
+		return strings.ToValidUTF8(string(data), "")
+	}
+	return string(decoded)
+}
+
+// joinNote puts the uploader's note above the extracted text
+func joinNote(note, text string) string {
+	note = strings.TrimSpace(note)
+	if note == "" {
+		return text
+	}
+	if text == "" {
+		return note
+	}
+	return note + "\n\n---\n\n" + text
+}
+
+// fileTitle names an item after its file, without the extension
+func fileTitle(filename string) string {
+	base := filepath.Base(filename)
+	name := strings.TrimSuffix(base, filepath.Ext(base))
+	if name == "" || name == "." {
+		return "Untitled"
+	}
+	return strings.NewReplacer("_", " ", "-", " ").Replace(name)
+}
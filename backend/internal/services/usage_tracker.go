@@ -0,0 +1,233 @@
+package services
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"strconv"
+	"strings"
+	"synapse/internal/models"
+	"synapse/internal/repository"
+	"sync"
+	"time"
+)
+
+// AI operations recorded with each provider request
+const (
+	OpEnrich   = "enrich"
+	OpTags     = "tags"
+	OpCategory = "category"
+	OpSummary  = "summary"
+	OpRerank   = "rerank"
+	OpEnhance  = "enhance"
+	OpEmbed    = "embed"
+	OpOCR      = "ocr"
+	OpCaption  = "caption"
+)
+
+type aiOperationKey struct{}
+
+// withAIOperation labels provider requests made with ctx for usage accounting
+func withAIOperation(ctx context.Context, operation string) context.Context {
+	return context.WithValue(ctx, aiOperationKey{}, operation)
+}
+
+func aiOperation(ctx context.Context) string {
+	if op, ok := ctx.Value(aiOperationKey{}).(string); ok {
+		return op
+	}
+	return "other"
+}
+
+// modelPrice is USD per million tokens
+type modelPrice struct {
+	input  float64
+	output float64
+}
+
+// modelPrices lists list prices of the models Synapse calls. Models are matched by
+// prefix so dated and preview variants share their family's price.
+var modelPrices = []struct {
+	prefix string
+	price  modelPrice
+}{
+	{"claude-opus-4", modelPrice{15, 75}},
+	{"claude-sonnet-4", modelPrice{3, 15}},
+	{"claude-haiku-4", modelPrice{1, 5}},
+	{"gemini-2.5-pro", modelPrice{1.25, 10}},
+	{"gemini-2.5-flash", modelPrice{0.30, 2.50}},
+	{"gemini-1.5-pro", modelPrice{1.25, 5}},
+	{"gemini-1.5-flash", modelPrice{0.075, 0.30}},
+	{"gemini-embedding-001", modelPrice{0.15, 0}},
+	{"text-embedding-004", modelPrice{0, 0}},
+	{"gpt-4o-mini", modelPrice{0.15, 0.60}},
+	{"text-embedding-3-small", modelPrice{0.02, 0}},
+}
+
+// estimateCost returns the estimated USD cost of a request; unknown models cost 0
+func estimateCost(model string, inputTokens, outputTokens int) float64 {
+	for _, p := range modelPrices {
+		if strings.HasPrefix(model, p.prefix) {
+			return (float64(inputTokens)*p.price.input + float64(outputTokens)*p.price.output) / 1e6
+		}
+	}
+	return 0
+}
+
+// estimateTokens approximates a token count for providers that don't report usage
+func estimateTokens(text string) int {
+	return (len(text) + 3) / 4
+}
+
+// UsageTracker records every AI provider request and enforces the optional monthly budget.
+// A nil *UsageTracker records nothing and never reports the budget as exceeded.
+type UsageTracker struct {
+	repo          *repository.UsageRepository
+	monthlyBudget float64
+
+	mu          sync.Mutex
+	spent       float64
+	spentMonth  time.Time
+	refreshedAt time.Time
+}
+
+// budgetRefreshInterval bounds how stale the cached month-to-date spend may be
+const budgetRefreshInterval = time.Minute
+
+func NewUsageTracker(repo *repository.UsageRepository) *UsageTracker {
+	var budget float64
+	if v := os.Getenv("AI_MONTHLY_BUDGET_USD"); v != "" {
+		if b, err := strconv.ParseFloat(v, 64); err == nil && b > 0 {
+			budget = b
+		}
+	}
+	return &UsageTracker{repo: repo, monthlyBudget: budget}
+}
+
+// Record stores a provider request. It never blocks the caller on the database.
+func (t *UsageTracker) Record(ctx context.Context, provider, model string, inputTokens, outputTokens int, latency time.Duration, success bool) {
+	if t == nil {
+		return
+	}
+
+	record := &models.AIUsageRecord{
+		Provider:     provider,
+		Model:        model,
+		Operation:    aiOperation(ctx),
+		InputTokens:  inputTokens,
+		OutputTokens: outputTokens,
+		LatencyMs:    int(latency.Milliseconds()),
+		CostUSD:      estimateCost(model, inputTokens, outputTokens),
+		Success:      success,
+		CreatedAt:    time.Now(),
+	}
+
+	t.mu.Lock()
+	if !t.spentMonth.IsZero() && startOfMonth(record.CreatedAt).Equal(t.spentMonth) {
+		t.spent += record.CostUSD
+	}
+	t.mu.Unlock()
+
+	go func() {
+		if err := t.repo.Create(context.Background(), record); err != nil {
+			fmt.Printf("Warning: Failed to record AI usage: %v\n", err)
+		}
+	}()
+}
+
+// BudgetExceeded reports whether month-to-date spend has reached AI_MONTHLY_BUDGET_USD.
+// Non-essential AI steps (re-ranking, query enhancement) are skipped while it returns true.
+func (t *UsageTracker) BudgetExceeded(ctx context.Context) bool {
+	if t == nil || t.monthlyBudget <= 0 {
+		return false
+	}
+	spent, err := t.monthToDate(ctx)
+	if err != nil {
+		fmt.Printf("Warning: Failed to check AI budget: %v\n", err)
+		return false
+	}
+	return spent >= t.monthlyBudget
+}
+
+// Budget returns the monthly budget and month-to-date spend
+func (t *UsageTracker) Budget(ctx context.Context) (models.AIBudgetStatus, error) {
+	if t == nil {
+		return models.AIBudgetStatus{}, nil
+	}
+	spent, err := t.monthToDate(ctx)
+	if err != nil {
+		return models.AIBudgetStatus{}, err
+	}
+	return models.AIBudgetStatus{
+		MonthlyUSD: t.monthlyBudget,
+		SpentUSD:   spent,
+		Exceeded:   t.monthlyBudget > 0 && spent >= t.monthlyBudget,
+	}, nil
+}
+
+func (t *UsageTracker) monthToDate(ctx context.Context) (float64, error) {
+	month := startOfMonth(time.Now())
+
+	t.mu.Lock()
+	if t.spentMonth.Equal(month) && time.Since(t.refreshedAt) < budgetRefreshInterval {
+		spent := t.spent
+		t.mu.Unlock()
+		return spent, nil
+	}
+	t.mu.Unlock()
+
+	spent, err := t.repo.CostSince(ctx, month)
+	if err != nil {
+		return 0, err
+	}
+
+	t.mu.Lock()
+	t.spent = spent
+	t.spentMonth = month
+	t.refreshedAt = time.Now()
+	t.mu.Unlock()
+	return spent, nil
+}
+
+// Report aggregates usage between from and to
+func (t *UsageTracker) Report(ctx context.Context, from, to time.Time, groupBy string) (*models.AIUsageReport, error) {
+	if t == nil {
+		return nil, fmt.Errorf("usage tracking is not configured")
+	}
+
+	groups, err := t.repo.Aggregate(ctx, from, to, groupBy)
+	if err != nil {
+		return nil, err
+	}
+
+	report := &models.AIUsageReport{
+		From:    from,
+		To:      to,
+		GroupBy: groupBy,
+		Totals:  models.AIUsageGroup{Key: "total"},
+		Groups:  groups,
+	}
+
+	var latencyWeighted float64
+	for _, g := range groups {
+		report.Totals.Calls += g.Calls
+		report.Totals.Failures += g.Failures
+		report.Totals.InputTokens += g.InputTokens
+		report.Totals.OutputTokens += g.OutputTokens
+		report.Totals.CostUSD += g.CostUSD
+		latencyWeighted += g.AvgLatencyMs * float64(g.Calls)
+	}
+	if report.Totals.Calls > 0 {
+		report.Totals.AvgLatencyMs = latencyWeighted / float64(report.Totals.Calls)
+	}
+
+	report.Budget, err = t.Budget(ctx)
+	if err != nil {
+		return nil, err
+	}
+	return report, nil
+}
+
+func startOfMonth(t time.Time) time.Time {
+	return time.Date(t.Year(), t.Month(), 1, 0, 0, 0, 0, t.Location())
+}
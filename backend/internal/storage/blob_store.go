@@ -0,0 +1,64 @@
+// Package storage keeps file contents (screenshots, uploaded originals, generated
+// images) outside the database. Blobs are content-addressed: a blob's key is the
+// SHA-256 of its bytes, so storing the same file twice keeps one copy.
+package storage
+
+import (
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"regexp"
+)
+
+var ErrNotFound = errors.New("blob not found")
+
+// BlobStore stores blobs under their hash
+type BlobStore interface {
+	// Put stores data under hash. Storing a hash that already exists is a no-op.
+	Put(ctx context.Context, hash string, data []byte, contentType string) error
+	// Get opens a blob, or returns ErrNotFound
+	Get(ctx context.Context, hash string) (io.ReadCloser, error)
+	// Delete removes a blob; deleting a missing blob is not an error
+	Delete(ctx context.Context, hash string) error
+}
+
+var hashPattern = regexp.MustCompile(`^[0-9a-f]{64}$`)
+
+// Hash returns the key data is stored under
+func Hash(data []byte) string {
+	sum := sha256.Sum256(data)
+	return hex.EncodeToString(sum[:])
+}
+
+// ValidHash reports whether s is a blob key
+func ValidHash(s string) bool {
+	return hashPattern.MatchString(s)
+}
+
+// NewFromEnv returns the store selected by BLOB_STORE: "filesystem" (the default)
+// under BLOB_DIR, or "s3" for any S3-compatible service
+func NewFromEnv() (BlobStore, error) {
+	switch backend := os.Getenv("BLOB_STORE"); backend {
+	case "", "filesystem", "fs":
+		dir := os.Getenv("BLOB_DIR")
+		if dir == "" {
+			dir = "./data/blobs"
+		}
+		return NewFilesystemStore(dir)
+	case "s3":
+		return NewS3Store(S3Config{
+			Endpoint:  os.Getenv("S3_ENDPOINT"),
+			Region:    os.Getenv("S3_REGION"),
+			Bucket:    os.Getenv("S3_BUCKET"),
+			Prefix:    os.Getenv("S3_PREFIX"),
+			AccessKey: os.Getenv("S3_ACCESS_KEY_ID"),
+			SecretKey: os.Getenv("S3_SECRET_ACCESS_KEY"),
+		})
+	default:
+		return nil, fmt.Errorf("unknown BLOB_STORE %q", backend)
+	}
+}
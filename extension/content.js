@@ -193,6 +193,7 @@ function extractBlogPost() {
       const clone = el.cloneNode(true);
       clone.querySelectorAll('script, style, nav, aside, .ad, .advertisement').forEach(n => n.remove());
       blog.content = clone.innerText.trim();
+      blog.html = clone.innerHTML;
       if (blog.content.length > 200) break;
     }
   }
@@ -633,6 +634,8 @@ chrome.runtime.onMessage.addListener((request, sender, sendResponse) => {
           const blog = extractBlogPost();
           data.title = blog.title || document.title;
           data.content = blog.content || '';
+          // The backend keeps the post's structure by converting its HTML to Markdown
+          data.html = blog.html || '';
           data.metadata = {
             author: blog.author,
             date: blog.date,
@@ -672,6 +675,7 @@ chrome.runtime.onMessage.addListener((request, sender, sendResponse) => {
           } else {
             // Otherwise, prepend to existing content
             data.content = selectedText + '\n\n---\n\n' + data.content;
+            data.html = '';
           }
         }
 
@@ -126,8 +126,7 @@ document.addEventListener('DOMContentLoaded', async () => {
           },
           body: JSON.stringify({
             title: tab.title || 'Screenshot',
-            content: `Screenshot captured from: ${tab.url}`,
-            source_url: tab.url,
+            source_url: tab.url, // The text in the screenshot is read by OCR
             type: 'image',
             image_url: dataUrl, // Store as data URL
           }),
@@ -183,6 +182,11 @@ document.addEventListener('DOMContentLoaded', async () => {
         type: extractResponse?.type || 'url',
       };
 
+      // Send the page's HTML unless the content was edited in the popup
+      if (extractResponse?.html && (!content || content === extractResponse.content)) {
+        payload.html = extractResponse.html;
+      }
+
       // Add metadata if available (important for video descriptions, product info, etc.)
       if (extractResponse?.metadata) {
         payload.metadata = extractResponse.metadata;
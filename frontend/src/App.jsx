@@ -36,10 +36,12 @@ function App() {
     setLoading(true);
     try {
       const response = await searchAPI.search(query);
-      // Search results come as SearchResult objects with {item, similarity_score}
-      // Extract just the items for display
-      const results = (response.data || []).map(result => 
-        result.item || result // Handle both formats
+      // Search results come as SearchResult objects with {item, similarity_score, page, snippet}
+      // Extract just the items for display, keeping the page that matched
+      const results = (response.data || []).map(result =>
+        result.item
+          ? { ...result.item, match_page: result.page, match_snippet: result.snippet }
+          : result // Handle both formats
       );
       setSearchResults(results);
     } catch (error) {
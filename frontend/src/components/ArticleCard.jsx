@@ -1,4 +1,5 @@
 import { Link } from 'react-router-dom';
+import { cardImageProps } from '../services/images';
 
 export default function ArticleCard({ item }) {
   const formatDate = (dateString) => {
@@ -23,7 +24,7 @@ export default function ArticleCard({ item }) {
     >
       {item.image_url && (
         <img
-          src={item.image_url}
+          {...cardImageProps(item)}
           alt={item.title}
           className="w-full h-48 object-cover"
           onError={(e) => {
@@ -1,4 +1,5 @@
 import { Link } from 'react-router-dom';
+import { cardImageProps } from '../services/images';
 
 export default function BookCard({ item }) {
   const formatDate = (dateString) => {
@@ -25,7 +26,7 @@ export default function BookCard({ item }) {
         {item.image_url && (
           <div className="w-32 flex-shrink-0">
             <img
-              src={item.image_url}
+              {...cardImageProps(item, '128px')}
               alt={item.title}
               className="w-full h-full object-cover"
               onError={(e) => {
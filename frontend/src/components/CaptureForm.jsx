@@ -1,11 +1,12 @@
 import { useState } from 'react';
 import { useNavigate } from 'react-router-dom';
-import { itemsAPI } from '../services/api';
+import { itemsAPI, uploadsAPI } from '../services/api';
 
 export default function CaptureForm({ onSuccess }) {
   const navigate = useNavigate();
   const [activeTab, setActiveTab] = useState('text');
   const [loading, setLoading] = useState(false);
+  const [file, setFile] = useState(null);
   const [formData, setFormData] = useState({
     title: '',
     content: '',
@@ -18,6 +19,17 @@ export default function CaptureForm({ onSuccess }) {
     setLoading(true);
 
     try {
+      if (activeTab === 'image') {
+        if (!file) {
+          alert('Please choose a file to upload.');
+          return;
+        }
+        await uploadsAPI.upload(file, formData.title, formData.content);
+        if (onSuccess) onSuccess();
+        navigate('/');
+        return;
+      }
+
       const data = {
         title: formData.title || 'Untitled',
         content: formData.content,
@@ -30,24 +42,15 @@ export default function CaptureForm({ onSuccess }) {
       navigate('/');
     } catch (error) {
       console.error('Failed to create item:', error);
-      alert('Failed to save item. Please try again.');
+      alert(error.response?.data?.error || 'Failed to save item. Please try again.');
     } finally {
       setLoading(false);
     }
   };
 
   const handleFileUpload = (e) => {
-    const file = e.target.files[0];
-    if (file) {
-      // For MVP, we'll just read the file name
-      // In production, you'd upload to backend for OCR processing
-      setFormData({
-        ...formData,
-        title: file.name,
-        content: `Image file: ${file.name}`,
-        type: 'image',
-      });
-    }
+    // The file is sent as is; the server names the item after it unless a title is given
+    setFile(e.target.files[0] || null);
   };
 
   return (
@@ -87,7 +90,7 @@ export default function CaptureForm({ onSuccess }) {
                 : 'text-gray-500 hover:text-gray-700'
             }`}
           >
-            Image
+            File
           </button>
         </div>
 
@@ -178,11 +181,11 @@ export default function CaptureForm({ onSuccess }) {
             <>
               <div className="mb-4">
                 <label className="block text-sm font-medium text-gray-700 mb-2">
-                  Upload Image
+                  Upload a file (image, PDF, text, Markdown, Word or EPUB)
                 </label>
                 <input
                   type="file"
-                  accept="image/*"
+                  accept="image/*,.pdf,.txt,.md,.markdown,.docx,.epub"
                   onChange={handleFileUpload}
                   className="w-full px-4 py-2 border border-gray-300 rounded-lg"
                 />
@@ -198,7 +201,7 @@ export default function CaptureForm({ onSuccess }) {
                     setFormData({ ...formData, title: e.target.value })
                   }
                   className="w-full px-4 py-2 border border-gray-300 rounded-lg focus:ring-2 focus:ring-indigo-500"
-                  placeholder="Image title..."
+                  placeholder="Title (optional)"
                 />
               </div>
               <div className="mb-4">
@@ -212,7 +215,7 @@ export default function CaptureForm({ onSuccess }) {
                   }
                   rows={6}
                   className="w-full px-4 py-2 border border-gray-300 rounded-lg focus:ring-2 focus:ring-indigo-500"
-                  placeholder="Describe the image or add notes..."
+                  placeholder="Add notes about the file..."
                 />
               </div>
             </>
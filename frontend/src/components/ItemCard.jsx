@@ -1,4 +1,5 @@
 import { Link } from 'react-router-dom';
+import { cardImageProps } from '../services/images';
 import BookCard from './BookCard';
 import RecipeCard from './RecipeCard';
 import ArticleCard from './ArticleCard';
@@ -77,7 +78,7 @@ function DefaultItemCard({ item }) {
     >
       {item.image_url && (
         <img
-          src={item.image_url}
+          {...cardImageProps(item)}
           alt={item.title}
           className="w-full h-48 object-cover"
           onError={(e) => {
@@ -94,6 +95,25 @@ function DefaultItemCard({ item }) {
           {item.summary}
         </p>
       )}
+      {item.match_page > 0 && (
+        <div className="mb-4 text-sm">
+          <button
+            type="button"
+            onClick={(e) => {
+              // The card itself is a link to the item
+              e.preventDefault();
+              e.stopPropagation();
+              window.open(`${item.source_url}#page=${item.match_page}`, '_blank', 'noopener');
+            }}
+            className="text-indigo-600 hover:underline font-medium"
+          >
+            Page {item.match_page}
+          </button>
+          {item.match_snippet && (
+            <p className="text-gray-500 text-xs mt-1 line-clamp-2">{item.match_snippet}</p>
+          )}
+        </div>
+      )}
       {item.tags && item.tags.length > 0 && (
         <div className="flex flex-wrap gap-2 mb-4">
           {item.tags.slice(0, 3).map((tag, idx) => (
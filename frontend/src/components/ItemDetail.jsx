@@ -86,11 +86,16 @@ export default function ItemDetail({ onDelete }) {
     item.source_url.includes('youtu.be')
   );
 
-  // Check if it's a PDF
-  const isPDF = item.source_url && (
+  // Uploaded items keep their original file
+  const originalFile = item.files?.find((file) => file.role === 'original');
+
+  // Check if it's a PDF, linked or uploaded
+  const isPDFLink = item.source_url && (
     item.source_url.toLowerCase().endsWith('.pdf') ||
     item.source_url.toLowerCase().includes('.pdf?')
   );
+  const pdfURL = isPDFLink ? item.source_url : originalFile?.content_type === 'application/pdf' ? originalFile.url : null;
+  const isPDF = Boolean(pdfURL);
 
   // Check if it's a todo list
   const isTodo = item.type === 'text' && (
@@ -204,7 +209,19 @@ export default function ItemDetail({ onDelete }) {
           </button>
         </div>
 
-        {item.source_url && !isPDF && (
+        {originalFile && (
+          <div className="mb-6 text-sm">
+            <a
+              href={originalFile.url}
+              download={originalFile.filename || true}
+              className="text-indigo-600 hover:text-indigo-700"
+            >
+              Download original{originalFile.filename ? ` (${originalFile.filename})` : ''}
+            </a>
+          </div>
+        )}
+
+        {item.source_url && !isPDFLink && (
           <div className="mb-6">
             <a
               href={item.source_url}
@@ -222,7 +239,7 @@ export default function ItemDetail({ onDelete }) {
           <div className="mb-6 rounded-lg overflow-hidden shadow-lg border border-gray-200">
             <div className="relative" style={{ paddingBottom: '75%', height: 0, overflow: 'hidden', minHeight: '600px' }}>
               <iframe
-                src={item.source_url}
+                src={pdfURL}
                 className="absolute top-0 left-0 w-full h-full"
                 style={{ border: 'none' }}
                 title={item.title}
@@ -319,7 +336,7 @@ export default function ItemDetail({ onDelete }) {
         )}
 
         {readerMode && (item.type === 'blog' || item.type === 'url' || item.type === 'article') && item.type !== 'video' && !isYouTubeVideo && !isPDF ? (
-          <ReaderMode content={item.content} title={item.title} />
+          <ReaderMode content={item.content} format={item.content_format} title={item.title} />
         ) : (
           <div className="mb-6">
             <h2 className="font-semibold text-gray-700 mb-2">Content</h2>
@@ -0,0 +1,215 @@
+// Renders the sanitized Markdown the backend stores for captured pages. Output is built
+// from React elements only, so nothing in the content is ever injected as HTML.
+
+const INLINE_PATTERN =
+  /\\([\\`*_{}[\]()#+\-.!<>|])|(`+)(.+?)\2|!\[([^\]]*)\]\(([^)\s]+)\)|\[([^\]]+)\]\(([^)\s]+)\)|\*\*(.+?)\*\*|__(.+?)__|(?<![\w\\])([*_])(\S(?:.*?[^\s\\])??)\10(?!\w)| {2}\n/g;
+
+const SAFE_URL = /^(https?:|mailto:|#)/i;
+
+function renderInline(text, keyPrefix = 'i') {
+  const nodes = [];
+  let last = 0;
+  let match;
+  let n = 0;
+  const pattern = new RegExp(INLINE_PATTERN.source, 'g');
+  while ((match = pattern.exec(text)) !== null) {
+    if (match.index > last) nodes.push(text.slice(last, match.index));
+    const key = `${keyPrefix}-${n++}`;
+    const [whole, escaped, , code, imgAlt, imgSrc, linkText, linkHref, strong, strongAlt, , em] = match;
+    if (escaped !== undefined) {
+      nodes.push(escaped);
+    } else if (code !== undefined) {
+      nodes.push(<code key={key} className="bg-gray-100 rounded px-1 text-sm">{code}</code>);
+    } else if (imgSrc !== undefined) {
+      if (SAFE_URL.test(imgSrc)) {
+        nodes.push(<img key={key} src={imgSrc} alt={imgAlt} className="my-4 rounded max-w-full" />);
+      }
+    } else if (linkHref !== undefined) {
+      const children = renderInline(linkText, key);
+      nodes.push(
+        SAFE_URL.test(linkHref) ? (
+          <a key={key} href={linkHref} target="_blank" rel="noopener noreferrer" className="text-blue-600 underline">
+            {children}
+          </a>
+        ) : (
+          <span key={key}>{children}</span>
+        )
+      );
+    } else if (strong !== undefined || strongAlt !== undefined) {
+      nodes.push(<strong key={key}>{renderInline(strong ?? strongAlt, key)}</strong>);
+    } else if (em !== undefined) {
+      nodes.push(<em key={key}>{renderInline(em, key)}</em>);
+    } else if (whole.endsWith('\n')) {
+      nodes.push(<br key={key} />);
+    }
+    last = match.index + whole.length;
+  }
+  if (last < text.length) nodes.push(text.slice(last));
+  return nodes;
+}
+
+function splitCells(row) {
+  return row
+    .trim()
+    .replace(/^\||\|$/g, '')
+    .split(/(?<!\\)\|/)
+    .map((cell) => cell.trim());
+}
+
+function parseBlocks(markdown) {
+  const lines = markdown.replace(/\r\n/g, '\n').split('\n');
+  const blocks = [];
+  let i = 0;
+  while (i < lines.length) {
+    const line = lines[i];
+    if (line.trim() === '') {
+      i++;
+      continue;
+    }
+
+    const fence = line.match(/^```(\S*)/);
+    if (fence) {
+      const code = [];
+      i++;
+      while (i < lines.length && !lines[i].startsWith('```')) code.push(lines[i++]);
+      i++;
+      blocks.push({ type: 'code', language: fence[1], text: code.join('\n') });
+      continue;
+    }
+
+    const heading = line.match(/^(#{1,6})\s+(.*)$/);
+    if (heading) {
+      blocks.push({ type: 'heading', level: heading[1].length, text: heading[2] });
+      i++;
+      continue;
+    }
+
+    if (/^(-{3,}|\*{3,}|_{3,})$/.test(line.trim())) {
+      blocks.push({ type: 'rule' });
+      i++;
+      continue;
+    }
+
+    if (line.startsWith('>')) {
+      const quote = [];
+      while (i < lines.length && lines[i].startsWith('>')) quote.push(lines[i++].replace(/^>\s?/, ''));
+      blocks.push({ type: 'quote', blocks: parseBlocks(quote.join('\n')) });
+      continue;
+    }
+
+    if (line.startsWith('|') && i + 1 < lines.length && /^\|?\s*:?-{3,}/.test(lines[i + 1])) {
+      const header = splitCells(line);
+      const rows = [];
+      i += 2;
+      while (i < lines.length && lines[i].startsWith('|')) rows.push(splitCells(lines[i++]));
+      blocks.push({ type: 'table', header, rows });
+      continue;
+    }
+
+    const marker = line.match(/^([-+*]|\d+[.)])\s+/);
+    if (marker) {
+      const ordered = /\d/.test(marker[1]);
+      const items = [];
+      while (i < lines.length) {
+        const itemMarker = lines[i].match(/^([-+*]|\d+[.)])\s+/);
+        if (!itemMarker) break;
+        const body = [lines[i].slice(itemMarker[0].length)];
+        i++;
+        // Continuation lines are indented under the marker
+        while (i < lines.length && /^\s{2,}\S/.test(lines[i])) body.push(lines[i++].replace(/^\s{2,3}/, ''));
+        items.push(parseBlocks(body.join('\n')));
+      }
+      blocks.push({ type: 'list', ordered, start: ordered ? parseInt(marker[1], 10) : 1, items });
+      continue;
+    }
+
+    const paragraph = [];
+    while (i < lines.length && lines[i].trim() !== '' && !/^(```|#{1,6}\s|>)/.test(lines[i])) {
+      paragraph.push(lines[i++]);
+    }
+    blocks.push({ type: 'paragraph', text: paragraph.join('\n') });
+  }
+  return blocks;
+}
+
+const HEADING_CLASSES = {
+  1: 'text-3xl font-bold mt-8 mb-4',
+  2: 'text-2xl font-bold mt-8 mb-4',
+  3: 'text-xl font-semibold mt-6 mb-3',
+  4: 'text-lg font-semibold mt-6 mb-3',
+  5: 'font-semibold mt-4 mb-2',
+  6: 'font-semibold mt-4 mb-2',
+};
+
+function renderBlocks(blocks, keyPrefix = 'b', tight = false) {
+  return blocks.map((block, idx) => {
+    const key = `${keyPrefix}-${idx}`;
+    switch (block.type) {
+      case 'heading': {
+        const Tag = `h${block.level}`;
+        return <Tag key={key} className={HEADING_CLASSES[block.level]}>{renderInline(block.text, key)}</Tag>;
+      }
+      case 'code':
+        return (
+          <pre key={key} className="bg-gray-900 text-gray-100 rounded p-4 mb-6 overflow-x-auto text-sm font-mono">
+            <code>{block.text}</code>
+          </pre>
+        );
+      case 'quote':
+        return (
+          <blockquote key={key} className="border-l-4 border-gray-300 pl-4 italic text-gray-600 mb-6">
+            {renderBlocks(block.blocks, key)}
+          </blockquote>
+        );
+      case 'list': {
+        const Tag = block.ordered ? 'ol' : 'ul';
+        return (
+          <Tag
+            key={key}
+            start={block.ordered ? block.start : undefined}
+            className={`${block.ordered ? 'list-decimal' : 'list-disc'} pl-6 ${tight ? '' : 'mb-6'} space-y-1`}
+          >
+            {block.items.map((item, itemIdx) => (
+              <li key={`${key}-${itemIdx}`}>{renderBlocks(item, `${key}-${itemIdx}`, true)}</li>
+            ))}
+          </Tag>
+        );
+      }
+      case 'table':
+        return (
+          <div key={key} className="overflow-x-auto mb-6">
+            <table className="min-w-full border text-sm">
+              <thead className="bg-gray-50">
+                <tr>
+                  {block.header.map((cell, c) => (
+                    <th key={c} className="border px-3 py-2 text-left">{renderInline(cell, `${key}-h${c}`)}</th>
+                  ))}
+                </tr>
+              </thead>
+              <tbody>
+                {block.rows.map((row, r) => (
+                  <tr key={r}>
+                    {row.map((cell, c) => (
+                      <td key={c} className="border px-3 py-2">{renderInline(cell, `${key}-${r}-${c}`)}</td>
+                    ))}
+                  </tr>
+                ))}
+              </tbody>
+            </table>
+          </div>
+        );
+      case 'rule':
+        return <hr key={key} className="my-8" />;
+      default:
+        return tight ? (
+          <span key={key}>{renderInline(block.text, key)}</span>
+        ) : (
+          <p key={key} className="mb-6">{renderInline(block.text, key)}</p>
+        );
+    }
+  });
+}
+
+export default function Markdown({ content }) {
+  return <>{renderBlocks(parseBlocks(content || ''))}</>;
+}
@@ -1,4 +1,5 @@
 import { Link } from 'react-router-dom';
+import { cardImageProps } from '../services/images';
 
 export default function ProductCard({ item }) {
   const formatDate = (dateString) => {
@@ -49,7 +50,7 @@ export default function ProductCard({ item }) {
       {item.image_url && (
         <div className="relative h-48 overflow-hidden bg-white">
           <img
-            src={item.image_url}
+            {...cardImageProps(item)}
             alt={item.title}
             className="w-full h-full object-contain p-4"
             onError={(e) => {
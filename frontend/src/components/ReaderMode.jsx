@@ -1,6 +1,7 @@
 import { useState } from 'react';
+import Markdown from './Markdown';
 
-export default function ReaderMode({ content, title }) {
+export default function ReaderMode({ content, format, title }) {
   const [fontSize, setFontSize] = useState('base');
   const [fontFamily, setFontFamily] = useState('sans');
 
@@ -61,11 +62,15 @@ export default function ReaderMode({ content, title }) {
             wordSpacing: '0.05em',
           }}
         >
-          {content.split('\n\n').map((paragraph, idx) => (
-            <p key={idx} className="mb-6">
-              {paragraph.trim()}
-            </p>
-          ))}
+          {format === 'markdown' ? (
+            <Markdown content={content} />
+          ) : (
+            content.split('\n\n').map((paragraph, idx) => (
+              <p key={idx} className="mb-6">
+                {paragraph.trim()}
+              </p>
+            ))
+          )}
         </div>
       </div>
     </div>
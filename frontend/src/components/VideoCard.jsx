@@ -1,4 +1,5 @@
 import { Link } from 'react-router-dom';
+import { cardImageProps } from '../services/images';
 
 export default function VideoCard({ item }) {
   const formatDate = (dateString) => {
@@ -25,7 +26,7 @@ export default function VideoCard({ item }) {
         {item.image_url && (
           <div className="relative h-48 overflow-hidden">
             <img
-              src={item.image_url}
+              {...cardImageProps(item)}
               alt={item.title}
               className="w-full h-full object-cover"
               onError={(e) => {
@@ -20,6 +20,17 @@ export const itemsAPI = {
   refreshSummary: (id) => api.post(`/items/${id}/refresh-summary`),
 };
 
+export const uploadsAPI = {
+  // Creates an item from a file; the server detects its type and extracts its text
+  upload: (file, title = '', note = '') => {
+    const form = new FormData();
+    form.append('file', file);
+    form.append('title', title);
+    form.append('note', note);
+    return api.post('/uploads', form, { headers: { 'Content-Type': 'multipart/form-data' } });
+  },
+};
+
 export const searchAPI = {
   search: (query, limit = 10) => api.get('/search', { params: { q: query, limit } }),
 };
@@ -0,0 +1,20 @@
+// Image attributes for an item's card. Processed images come with resized variants,
+// so the browser can pick the smallest one that fills the card, and a dominant color
+// shown while it loads.
+export function cardImageProps(item, sizes = '(min-width: 1024px) 33vw, (min-width: 768px) 50vw, 100vw') {
+  const image = item.image;
+  const variants = image?.variants || [];
+  if (variants.length === 0) {
+    return { src: item.image_url, style: image?.color ? { backgroundColor: image.color } : undefined };
+  }
+
+  const candidates = variants.map((variant) => `${variant.url} ${variant.width}w`);
+  if (image.width) candidates.push(`${item.image_url} ${image.width}w`);
+  return {
+    src: variants[variants.length - 1].url,
+    srcSet: candidates.join(', '),
+    sizes,
+    loading: 'lazy',
+    style: image.color ? { backgroundColor: image.color } : undefined,
+  };
+}